@@ -10,3 +10,9 @@ var DefaultTokenExpiry = 5 * time.Minute
 
 // DefaultLeeway allows n minutes time difference (clocks out of sync etc)
 var DefaultLeeway = 5 * time.Minute
+
+// DefaultPreAuthorizedCodeExpiry is how long a pre-authorized code can be redeemed
+var DefaultPreAuthorizedCodeExpiry = 10 * time.Minute
+
+// DefaultCredentialOfferExpiry is how long an offer passed by reference can be fetched
+var DefaultCredentialOfferExpiry = 10 * time.Minute
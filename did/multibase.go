@@ -0,0 +1,141 @@
+package did
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// multicodec prefixes of public keys, https://github.com/multiformats/multicodec
+var (
+	ed25519Codec = []byte{0xed, 0x01}
+	p256Codec    = []byte{0x80, 0x24}
+	p384Codec    = []byte{0x81, 0x24}
+)
+
+// EncodeMultibase returns the base58btc multibase encoding (prefix z) used for proof values and keys.
+func EncodeMultibase(b []byte) string {
+	return "z" + encodeBase58(b)
+}
+
+// DecodeMultibase decodes a base58btc multibase value, other bases are not supported.
+func DecodeMultibase(s string) ([]byte, error) {
+	if len(s) == 0 || s[0] != 'z' {
+		return nil, errors.New("multibase value is no base58btc")
+	}
+	return decodeBase58(s[1:])
+}
+
+func encodeBase58(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		encoded = append(encoded, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	return string(encoded)
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+
+	for _, c := range []byte(s) {
+		digit := -1
+		for i := 0; i < len(base58Alphabet); i++ {
+			if base58Alphabet[i] == c {
+				digit = i
+				break
+			}
+		}
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// EncodeMultikey returns the multibase encoding of an Ed25519, P-256 or P-384 public key with its multicodec prefix.
+func EncodeMultikey(key jwk.Key) (string, error) {
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return "", err
+	}
+
+	switch k := raw.(type) {
+	case ed25519.PublicKey:
+		return EncodeMultibase(append(append([]byte{}, ed25519Codec...), k...)), nil
+	case *ecdsa.PublicKey:
+		compressed := elliptic.MarshalCompressed(k.Curve, k.X, k.Y)
+		switch k.Curve {
+		case elliptic.P256():
+			return EncodeMultibase(append(append([]byte{}, p256Codec...), compressed...)), nil
+		case elliptic.P384():
+			return EncodeMultibase(append(append([]byte{}, p384Codec...), compressed...)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported multikey type %T", raw)
+}
+
+// DecodeMultikey decodes a multibase encoded Ed25519, P-256 or P-384 public key, e.g. publicKeyMultibase or did:key.
+func DecodeMultikey(s string) (jwk.Key, error) {
+	b, err := DecodeMultibase(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 2 {
+		return nil, errors.New("multikey too short")
+	}
+
+	codec, value := b[:2], b[2:]
+	switch {
+	case codec[0] == ed25519Codec[0] && codec[1] == ed25519Codec[1]:
+		if len(value) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 multikey")
+		}
+		return jwk.FromRaw(ed25519.PublicKey(value))
+	case codec[0] == p256Codec[0] && codec[1] == p256Codec[1]:
+		return ecdsaMultikey(elliptic.P256(), value)
+	case codec[0] == p384Codec[0] && codec[1] == p384Codec[1]:
+		return ecdsaMultikey(elliptic.P384(), value)
+	}
+	return nil, fmt.Errorf("unsupported multicodec %x", codec)
+}
+
+func ecdsaMultikey(curve elliptic.Curve, compressed []byte) (jwk.Key, error) {
+	x, y := elliptic.UnmarshalCompressed(curve, compressed)
+	if x == nil {
+		return nil, fmt.Errorf("invalid %s multikey", curve.Params().Name)
+	}
+	return jwk.FromRaw(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+}
@@ -0,0 +1,20 @@
+package did
+
+import "testing"
+
+func TestMultibase(t *testing.T) {
+	if EncodeMultibase([]byte("hello world")) != "zStV1DL6CwTryKyV" {
+		t.Error(EncodeMultibase([]byte("hello world")))
+	}
+	if b, err := DecodeMultibase("z1StV1DL6CwTryKyV"); err != nil || string(b) != "\x00hello world" {
+		t.Error(b, err)
+	}
+
+	key, err := DecodeMultikey("z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded, _ := EncodeMultikey(key); encoded != "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK" {
+		t.Error(encoded)
+	}
+}
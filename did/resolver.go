@@ -0,0 +1,189 @@
+package did
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+)
+
+var (
+	ErrUnsupportedMethod          = errors.New("unsupported did method")
+	ErrVerificationMethodNotFound = errors.New("verification method not found")
+	ErrControllerMismatch         = errors.New("verification method does not belong to the did")
+)
+
+/*
+Resolver returns the public keys of the verification methods of a DID. The fragment of a DID url selects a single
+verification method, e.g. did:jwk:eyJ...#0.
+*/
+type Resolver func(didUrl string) ([]jwk.Key, error)
+
+// VerificationMethod is an entry of the verificationMethod of a DID document.
+type VerificationMethod struct {
+	Id                 string          `json:"id"`
+	Type               string          `json:"type"`
+	Controller         string          `json:"controller,omitempty"`
+	PublicKeyJwk       json.RawMessage `json:"publicKeyJwk,omitempty"`
+	PublicKeyMultibase string          `json:"publicKeyMultibase,omitempty"`
+	PublicKeyBase58    string          `json:"publicKeyBase58,omitempty"`
+}
+
+// Document is the part of a DID document which is needed to resolve keys.
+type Document struct {
+	Id                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+}
+
+// NewResolver resolves did:key and did:jwk locally and did:web with client.
+func NewResolver(client helper.HttpClient) Resolver {
+	return func(didUrl string) ([]jwk.Key, error) {
+		did := Controller(didUrl)
+		_, fragment, hasFragment := strings.Cut(didUrl, "#")
+
+		switch {
+		case strings.HasPrefix(did, "did:key:"):
+			multikey := strings.TrimPrefix(did, "did:key:")
+			if hasFragment && fragment != multikey {
+				return nil, fmt.Errorf("%w: %s", ErrVerificationMethodNotFound, didUrl)
+			}
+			key, err := DecodeMultikey(multikey)
+			if err != nil {
+				return nil, fmt.Errorf("invalid did:key: %w", err)
+			}
+			return []jwk.Key{key}, nil
+		case strings.HasPrefix(did, "did:jwk:"):
+			if hasFragment && fragment != "0" {
+				return nil, fmt.Errorf("%w: %s", ErrVerificationMethodNotFound, didUrl)
+			}
+			b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(did, "did:jwk:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid did:jwk: %w", err)
+			}
+			key, err := jwk.ParseKey(b)
+			if err != nil {
+				return nil, fmt.Errorf("invalid did:jwk: %w", err)
+			}
+			return []jwk.Key{key}, nil
+		case strings.HasPrefix(did, "did:web:"):
+			return resolveWeb(client, did, didUrl)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMethod, did)
+	}
+}
+
+/*
+IssuerKeys resolves the keys of a DID issuer of a jwt, header is its protected header. A DID url in the kid header
+must belong to the issuer, so a token can not name its own key for another issuer.
+*/
+func (resolver Resolver) IssuerKeys(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+	if !strings.HasPrefix(issuer, "did:") {
+		return nil, fmt.Errorf("%w: issuer %s is no did", ErrUnsupportedMethod, issuer)
+	}
+
+	didUrl := issuer
+	kid, _ := header["kid"].(string)
+	switch {
+	case strings.HasPrefix(kid, "did:"):
+		if Controller(kid) != issuer {
+			return nil, fmt.Errorf("%w: kid %s, issuer %s", ErrControllerMismatch, kid, issuer)
+		}
+		didUrl = kid
+	case strings.HasPrefix(kid, "#"):
+		didUrl = issuer + kid
+	}
+	return resolver(didUrl)
+}
+
+// Controller returns the DID of a DID url, which is the DID without fragment.
+func Controller(didUrl string) string {
+	did, _, _ := strings.Cut(didUrl, "#")
+	return did
+}
+
+func resolveWeb(client helper.HttpClient, did string, didUrl string) ([]jwk.Key, error) {
+	location, err := WebLocation(did)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := helper.GetWithClient(client, location)
+	if err != nil {
+		return nil, fmt.Errorf("can not resolve %s: %w", did, err)
+	}
+
+	var document Document
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, fmt.Errorf("invalid did document of %s: %w", did, err)
+	}
+	if document.Id != did {
+		return nil, fmt.Errorf("did document %s does not belong to %s", document.Id, did)
+	}
+
+	_, fragment, hasFragment := strings.Cut(didUrl, "#")
+	keys := make([]jwk.Key, 0, len(document.VerificationMethod))
+	for _, method := range document.VerificationMethod {
+		if hasFragment && method.Id != didUrl && method.Id != "#"+fragment {
+			continue
+		}
+		key, err := method.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrVerificationMethodNotFound, didUrl)
+	}
+	return keys, nil
+}
+
+// WebLocation returns the url of the did document of a did:web.
+func WebLocation(did string) (string, error) {
+	identifier := strings.TrimPrefix(did, "did:web:")
+	if identifier == did || identifier == "" {
+		return "", fmt.Errorf("no did:web: %s", did)
+	}
+
+	segments := strings.Split(identifier, ":")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", fmt.Errorf("invalid did:web %s: %w", did, err)
+		}
+		segments[i] = unescaped
+	}
+
+	if len(segments) == 1 {
+		return "https://" + segments[0] + "/.well-known/did.json", nil
+	}
+	return "https://" + strings.Join(segments, "/") + "/did.json", nil
+}
+
+// PublicKey decodes publicKeyJwk, publicKeyMultibase or the publicKeyBase58 of Ed25519VerificationKey2018.
+func (method *VerificationMethod) PublicKey() (jwk.Key, error) {
+	switch {
+	case len(method.PublicKeyJwk) > 0:
+		return jwk.ParseKey(method.PublicKeyJwk)
+	case method.PublicKeyMultibase != "":
+		return DecodeMultikey(method.PublicKeyMultibase)
+	case method.PublicKeyBase58 != "":
+		b, err := decodeBase58(method.PublicKeyBase58)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != ed25519.PublicKeySize {
+			return nil, errors.New("publicKeyBase58 is no ed25519 key")
+		}
+		return jwk.FromRaw(ed25519.PublicKey(b))
+	}
+	return nil, fmt.Errorf("verification method %s without public key", method.Id)
+}
@@ -0,0 +1,86 @@
+package did
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDidJwk = "did:jwk:eyJjcnYiOiJQLTI1NiIsImt0eSI6IkVDIiwieCI6ImFjYklRaXVNczNpOF91c3pFakoydHBUdFJNNEVVM3l6OTFQSDZDZEgyVjAiLCJ5IjoiX0tjeUxqOXZXTXB0bm1LdG00NkdxRHo4d2Y3NEk1TEtncmwyR3pIM25TRSJ9"
+
+func TestResolve(t *testing.T) {
+	resolver := NewResolver(nil)
+
+	keys, err := resolver(testDidJwk + "#0")
+	if err != nil || len(keys) != 1 {
+		t.Fatal(keys, err)
+	}
+	if _, err := resolver(testDidJwk + "#1"); !errors.Is(err, ErrVerificationMethodNotFound) {
+		t.Error(err)
+	}
+	const multikey = "z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK"
+	if keys, err := resolver("did:key:" + multikey + "#" + multikey); err != nil || len(keys) != 1 {
+		t.Error(keys, err)
+	}
+	if _, err := resolver("did:key:" + multikey + "#other"); !errors.Is(err, ErrVerificationMethodNotFound) {
+		t.Error(err)
+	}
+	if _, err := resolver("did:example:123"); !errors.Is(err, ErrUnsupportedMethod) {
+		t.Error(err)
+	}
+}
+
+func TestIssuerKeys(t *testing.T) {
+	resolver := NewResolver(nil)
+
+	if _, err := resolver.IssuerKeys(testDidJwk, map[string]interface{}{"kid": testDidJwk + "#0"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := resolver.IssuerKeys(testDidJwk, map[string]interface{}{"kid": "#0"}); err != nil {
+		t.Error(err)
+	}
+
+	// a token must not name its own key for another issuer
+	for _, issuer := range []string{"did:web:issuer.example.com", "https://issuer.example.com"} {
+		if _, err := resolver.IssuerKeys(issuer, map[string]interface{}{"kid": testDidJwk + "#0"}); err == nil {
+			t.Error("kid of another did accepted for", issuer)
+		}
+	}
+}
+
+func TestResolveWeb(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		did := "did:web:" + strings.ReplaceAll(strings.TrimPrefix(srv.URL, "https://"), ":", "%3A")
+		b, _ := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(testDidJwk, "did:jwk:"))
+		json.NewEncoder(w).Encode(Document{
+			Id:                 did,
+			VerificationMethod: []VerificationMethod{{Id: did + "#key-1", Type: "JsonWebKey2020", PublicKeyJwk: b}},
+		})
+	}))
+	defer srv.Close()
+
+	did := "did:web:" + strings.ReplaceAll(strings.TrimPrefix(srv.URL, "https://"), ":", "%3A")
+	resolver := NewResolver(srv.Client())
+	if keys, err := resolver(did + "#key-1"); err != nil || len(keys) != 1 {
+		t.Error(keys, err)
+	}
+	if _, err := resolver(did + "#key-2"); !errors.Is(err, ErrVerificationMethodNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestWebLocation(t *testing.T) {
+	for did, expected := range map[string]string{
+		"did:web:example.com":                    "https://example.com/.well-known/did.json",
+		"did:web:example.com%3A3000:issuers:one": "https://example.com:3000/issuers/one/did.json",
+	} {
+		if location, err := WebLocation(did); err != nil || location != expected {
+			t.Error(did, location, err)
+		}
+	}
+}
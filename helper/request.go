@@ -3,9 +3,12 @@ package helper
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
 )
 
 type ContentType string
@@ -15,6 +18,20 @@ const (
 	ApplicationUrlForm ContentType = "application/x-www-form-urlencoded"
 )
 
+// RequestError is returned by Get and Post when the remote side answered with a non success status.
+// The body is kept to allow callers to evaluate OAuth/OID4VCI error responses.
+type RequestError struct {
+	Method     string
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s request failed! "+
+		"response code: %d status: %s data: %s", e.Method, e.StatusCode, e.Status, string(e.Body))
+}
+
 func DisableTlsVerification() {
 	tr := http.DefaultTransport.(*http.Transport)
 	if tr.TLSClientConfig == nil {
@@ -23,8 +40,28 @@ func DisableTlsVerification() {
 	tr.TLSClientConfig.InsecureSkipVerify = true
 }
 
+// HttpClient sends the requests of resolvers, *http.Client implements it. Replace it for tests or custom transports.
+type HttpClient interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// NewHttpClient returns a client with the default timeout of the library.
+func NewHttpClient() *http.Client {
+	return &http.Client{Timeout: config.DefaultHTTPTimeout}
+}
+
 func Get(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+	return GetWithClient(http.DefaultClient, url)
+}
+
+// GetWithClient sends a get request with the given client.
+func GetWithClient(client HttpClient, url string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not build request: %w", err)
+	}
+
+	resp, err := client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("can not make get request: %w", err)
 	}
@@ -34,8 +71,7 @@ func Get(url string) ([]byte, error) {
 		return nil, fmt.Errorf("can not read body of response with status %s: %w ", resp.Status, err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("get request failed! "+
-			"response code: %d status: %s data: %s", resp.StatusCode, resp.Status, string(respBody))
+		return nil, &RequestError{Method: "get", StatusCode: resp.StatusCode, Status: resp.Status, Body: respBody}
 	}
 
 	return respBody, nil
@@ -65,10 +101,22 @@ func Post(url string, body []byte, contentType ContentType, token *string) ([]by
 	if err != nil {
 		return nil, fmt.Errorf("can not read body of response with status %s: %w ", resp.Status, err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("post request failed! "+
-			"response code: %d status: %s data: %s", resp.StatusCode, resp.Status, string(respBody))
+	// some endpoints (e.g. notification) answer with 204 No Content
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, &RequestError{Method: "post", StatusCode: resp.StatusCode, Status: resp.Status, Body: respBody}
 	}
 
 	return respBody, nil
 }
+
+// WriteJson writes v as JSON response with the given status code.
+func WriteJson(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "can not marshal response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", string(ApplicationJson))
+	w.WriteHeader(status)
+	w.Write(b)
+}
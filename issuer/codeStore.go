@@ -0,0 +1,113 @@
+package issuer
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCodeNotFound   = errors.New("pre-authorized code not found")
+	ErrCodeExpired    = errors.New("pre-authorized code expired")
+	ErrTooManyTxCodes = errors.New("pre-authorized code invalidated after too many tx_code attempts")
+)
+
+// PreAuthorization is the issuer side state behind a pre-authorized code.
+type PreAuthorization struct {
+	Code                       string    `json:"code"`
+	TxCode                     string    `json:"tx_code,omitempty"`
+	CredentialConfigurationIds []string  `json:"credential_configuration_ids"`
+	ExpiresAt                  time.Time `json:"expires_at"`
+	// Subject is an optional reference of the issuer to the holder or the data to issue
+	Subject        string `json:"subject,omitempty"`
+	FailedAttempts int    `json:"failed_attempts,omitempty"`
+}
+
+func (authorization *PreAuthorization) Expired() bool {
+	return time.Now().After(authorization.ExpiresAt)
+}
+
+/*
+CodeStore persists pre-authorizations until they are redeemed.
+Redeem must be atomic: the check is called with the stored authorization and the code is only deleted when the check
+passed. This guarantees that a code is redeemed exactly once, even with concurrent token requests. A failed check
+increments the failed attempts in the same step, codes with maxAttempts failures are deleted and rejected with
+ErrTooManyTxCodes without calling the check.
+*/
+type CodeStore interface {
+	Save(authorization PreAuthorization) error
+	Redeem(code string, maxAttempts int, check func(authorization PreAuthorization) error) (*PreAuthorization, error)
+	Delete(code string) error
+}
+
+type MemoryCodeStore struct {
+	mutex          sync.Mutex
+	authorizations map[string]PreAuthorization
+}
+
+func NewMemoryCodeStore() *MemoryCodeStore {
+	return &MemoryCodeStore{
+		authorizations: make(map[string]PreAuthorization),
+	}
+}
+
+func (store *MemoryCodeStore) Save(authorization PreAuthorization) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.removeExpired()
+	store.authorizations[authorization.Code] = authorization
+	return nil
+}
+
+func (store *MemoryCodeStore) Redeem(code string, maxAttempts int, check func(authorization PreAuthorization) error) (*PreAuthorization, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	authorization, ok := store.authorizations[code]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+
+	if authorization.Expired() {
+		delete(store.authorizations, code)
+		return nil, ErrCodeExpired
+	}
+
+	if maxAttempts > 0 && authorization.FailedAttempts >= maxAttempts {
+		delete(store.authorizations, code)
+		return nil, ErrTooManyTxCodes
+	}
+
+	if check != nil {
+		if err := check(authorization); err != nil {
+			// the code is burned after too many guesses, the holder needs a new offer
+			authorization.FailedAttempts++
+			if maxAttempts > 0 && authorization.FailedAttempts >= maxAttempts {
+				delete(store.authorizations, code)
+			} else {
+				store.authorizations[code] = authorization
+			}
+			return nil, err
+		}
+	}
+
+	delete(store.authorizations, code)
+	return &authorization, nil
+}
+
+func (store *MemoryCodeStore) Delete(code string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.authorizations, code)
+	return nil
+}
+
+func (store *MemoryCodeStore) removeExpired() {
+	for code, authorization := range store.authorizations {
+		if authorization.Expired() {
+			delete(store.authorizations, code)
+		}
+	}
+}
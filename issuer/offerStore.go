@@ -0,0 +1,156 @@
+package issuer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+)
+
+var (
+	ErrOfferNotFound = errors.New("credential offer not found")
+	ErrOfferExpired  = errors.New("credential offer expired")
+)
+
+type StoredOffer struct {
+	Id         string                               `json:"id"`
+	Offer      credential.CredentialOfferParameters `json:"offer"`
+	ExpiresAt  time.Time                            `json:"expires_at"`
+	OneTimeUse bool                                 `json:"one_time_use,omitempty"`
+}
+
+func (offer *StoredOffer) Expired() bool {
+	return time.Now().After(offer.ExpiresAt)
+}
+
+/*
+OfferStore hosts credential offers which are passed by reference.
+Load must remove one time offers atomically, so that they can be fetched only once.
+*/
+type OfferStore interface {
+	Save(offer StoredOffer) error
+	Load(id string) (*StoredOffer, error)
+	Delete(id string) error
+}
+
+type MemoryOfferStore struct {
+	mutex  sync.Mutex
+	offers map[string]StoredOffer
+}
+
+func NewMemoryOfferStore() *MemoryOfferStore {
+	return &MemoryOfferStore{
+		offers: make(map[string]StoredOffer),
+	}
+}
+
+func (store *MemoryOfferStore) Save(offer StoredOffer) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for id, o := range store.offers {
+		if o.Expired() {
+			delete(store.offers, id)
+		}
+	}
+	store.offers[offer.Id] = offer
+	return nil
+}
+
+func (store *MemoryOfferStore) Load(id string) (*StoredOffer, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	offer, ok := store.offers[id]
+	if !ok {
+		return nil, ErrOfferNotFound
+	}
+
+	if offer.Expired() {
+		delete(store.offers, id)
+		return nil, ErrOfferExpired
+	}
+
+	if offer.OneTimeUse {
+		delete(store.offers, id)
+	}
+	return &offer, nil
+}
+
+func (store *MemoryOfferStore) Delete(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.offers, id)
+	return nil
+}
+
+/*
+Offers publishes credential offers by reference. It is an http.Handler which serves the stored offer parameters
+under BaseUri/{id}, where BaseUri is the public url the handler is mounted at.
+*/
+type Offers struct {
+	Store   OfferStore
+	BaseUri string
+	// Expiry of the offers, defaults to config.DefaultCredentialOfferExpiry
+	Expiry time.Duration
+}
+
+func NewOffers(store OfferStore, baseUri string) *Offers {
+	if store == nil {
+		store = NewMemoryOfferStore()
+	}
+	return &Offers{
+		Store:   store,
+		BaseUri: baseUri,
+	}
+}
+
+// Publish stores the offer under an opaque id and returns the openid-credential-offer link with credential_offer_uri.
+func (offers *Offers) Publish(offer credential.CredentialOfferParameters, oneTimeUse bool) (*credential.CredentialOffer, error) {
+	id, err := GenerateCode()
+	if err != nil {
+		return nil, err
+	}
+
+	expiry := offers.Expiry
+	if expiry <= 0 {
+		expiry = config.DefaultCredentialOfferExpiry
+	}
+
+	err = offers.Store.Save(StoredOffer{
+		Id:         id,
+		Offer:      offer,
+		ExpiresAt:  time.Now().Add(expiry),
+		OneTimeUse: oneTimeUse,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not store credential offer: %w", err)
+	}
+
+	return credential.CreateOfferUriLink(strings.TrimSuffix(offers.BaseUri, "/") + "/" + id)
+}
+
+func (offers *Offers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	offer, err := offers.Store.Load(path.Base(r.URL.Path))
+	if err != nil {
+		helper.WriteJson(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+
+	helper.WriteJson(w, http.StatusOK, offer.Offer)
+}
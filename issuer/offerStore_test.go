@@ -0,0 +1,69 @@
+package issuer
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+)
+
+func TestOfferByReference(t *testing.T) {
+	offers := NewOffers(nil, "")
+	srv := httptest.NewServer(offers)
+	defer srv.Close()
+	offers.BaseUri = srv.URL + "/offers/"
+
+	params := credential.CredentialOfferParameters{
+		CredentialIssuer: "https://issuer.example.com",
+		Credentials:      []string{"A"},
+	}
+
+	link, err := offers.Publish(params, true)
+
+	if err != nil || !strings.HasPrefix(link.CredentialOfferUri, "openid-credential-offer://?credential_offer_uri=") {
+		t.Fatal(err)
+	}
+
+	resolved, err := link.GetOfferParameters()
+
+	if err != nil || resolved.CredentialIssuer != params.CredentialIssuer || resolved.Credentials[0] != "A" {
+		t.Fatal(err)
+	}
+
+	_, err = link.GetOfferParameters()
+
+	if err == nil {
+		t.Error("one time offer fetched twice")
+	}
+
+	link, _ = offers.Publish(params, false)
+
+	for i := 0; i < 2; i++ {
+		if _, err := link.GetOfferParameters(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestOfferExpiry(t *testing.T) {
+	offers := NewOffers(nil, "https://issuer.example.com/offers")
+	offers.Expiry = time.Millisecond
+
+	link, _ := offers.Publish(credential.CredentialOfferParameters{}, false)
+	time.Sleep(5 * time.Millisecond)
+
+	id := link.CredentialOfferUri[strings.LastIndex(link.CredentialOfferUri, "%2F")+3:]
+	_, err := offers.Store.Load(id)
+
+	if err != ErrOfferExpired {
+		t.Error(err)
+	}
+}
+
+func TestCreateOfferUriLink(t *testing.T) {
+	if _, err := credential.CreateOfferUriLink("issuer/offer/1"); err == nil {
+		t.Error()
+	}
+}
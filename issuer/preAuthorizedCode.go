@@ -0,0 +1,189 @@
+package issuer
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const (
+	TxCodeInputModeNumeric = "numeric"
+	TxCodeInputModeText    = "text"
+)
+
+var DefaultTxCodeLength = 6
+
+// DefaultMaxTxCodeAttempts invalidates a pre-authorized code after n wrong tx_codes
+var DefaultMaxTxCodeAttempts = 5
+
+// characters which are hard to confuse when typed by the user
+const textAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+const numericAlphabet = "0123456789"
+
+// 256 bit of entropy
+const codeBytes = 32
+
+/*
+PreAuthorizedCodes mints and tracks the pre-authorized codes and tx_codes of credential offers and redeems them
+at the token endpoint.
+*/
+type PreAuthorizedCodes struct {
+	Store CodeStore
+	// Expiry of the codes, defaults to config.DefaultPreAuthorizedCodeExpiry
+	Expiry time.Duration
+	// MaxTxCodeAttempts defaults to DefaultMaxTxCodeAttempts
+	MaxTxCodeAttempts int
+}
+
+func NewPreAuthorizedCodes(store CodeStore) *PreAuthorizedCodes {
+	if store == nil {
+		store = NewMemoryCodeStore()
+	}
+	return &PreAuthorizedCodes{Store: store}
+}
+
+/*
+Creates a pre-authorized code bound to the credential configurations. If txCode is set, a transaction code is
+generated according to its input mode and length. The generated value must be sent to the holder on another channel.
+*/
+func (codes *PreAuthorizedCodes) Create(credentialConfigurationIds []string, txCode *credential.TxCode, subject string) (*credential.PreAuthorizedCode, string, error) {
+	if len(credentialConfigurationIds) == 0 {
+		return nil, "", errors.New("no credential configuration ids given")
+	}
+
+	code, err := GenerateCode()
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiry := codes.Expiry
+	if expiry <= 0 {
+		expiry = config.DefaultPreAuthorizedCodeExpiry
+	}
+
+	authorization := PreAuthorization{
+		Code:                       code,
+		CredentialConfigurationIds: credentialConfigurationIds,
+		ExpiresAt:                  time.Now().Add(expiry),
+		Subject:                    subject,
+	}
+
+	var grant = &credential.PreAuthorizedCode{
+		PreAuthorizationCode: code,
+	}
+
+	if txCode != nil {
+		t := *txCode
+		if t.InputMode == "" {
+			t.InputMode = TxCodeInputModeNumeric
+		}
+		if t.Length <= 0 {
+			t.Length = DefaultTxCodeLength
+		}
+
+		authorization.TxCode, err = GenerateTxCode(t)
+		if err != nil {
+			return nil, "", err
+		}
+		grant.TxCode = &t
+	}
+
+	if err := codes.Store.Save(authorization); err != nil {
+		return nil, "", fmt.Errorf("can not store pre-authorized code: %w", err)
+	}
+
+	return grant, authorization.TxCode, nil
+}
+
+// CreateOffer creates the offer parameters together with a new pre-authorized code.
+func (codes *PreAuthorizedCodes) CreateOffer(credentialIssuer string, credentialConfigurationIds []string, txCode *credential.TxCode, subject string) (*credential.CredentialOfferParameters, string, error) {
+	grant, txCodeValue, err := codes.Create(credentialConfigurationIds, txCode, subject)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &credential.CredentialOfferParameters{
+		CredentialIssuer: credentialIssuer,
+		Credentials:      credentialConfigurationIds,
+		Grants: credential.Grants{
+			PreAuthorizedCode: grant,
+		},
+	}, txCodeValue, nil
+}
+
+/*
+Redeem checks the code and tx_code and removes the code from the store. Wrong tx_codes are counted by the store, the
+code is invalidated after MaxTxCodeAttempts.
+*/
+func (codes *PreAuthorizedCodes) Redeem(code string, txCode string) (*PreAuthorization, error) {
+	if code == "" {
+		return nil, oauth.ErrorResponse{ErrorMsg: oauth.InvalidRequest, ErrorDescription: "pre-authorized_code missing"}
+	}
+
+	maxAttempts := codes.MaxTxCodeAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxTxCodeAttempts
+	}
+
+	authorization, err := codes.Store.Redeem(code, maxAttempts, func(authorization PreAuthorization) error {
+		if authorization.TxCode != "" && subtle.ConstantTimeCompare([]byte(authorization.TxCode), []byte(txCode)) != 1 {
+			return oauth.ErrorResponse{ErrorMsg: oauth.InvalidGrant, ErrorDescription: "tx_code invalid"}
+		}
+		return nil
+	})
+
+	if err != nil {
+		var errResponse oauth.ErrorResponse
+		if errors.As(err, &errResponse) {
+			return nil, errResponse
+		}
+		return nil, oauth.ErrorResponse{ErrorMsg: oauth.InvalidGrant, ErrorDescription: err.Error()}
+	}
+	return authorization, nil
+}
+
+// GenerateCode returns a url safe random code with 256 bit of entropy.
+func GenerateCode() (string, error) {
+	b := make([]byte, codeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("can not generate code: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateTxCode returns a random transaction code matching the input mode and length of the tx_code object.
+func GenerateTxCode(txCode credential.TxCode) (string, error) {
+	var alphabet string
+	switch txCode.InputMode {
+	case "", TxCodeInputModeNumeric:
+		alphabet = numericAlphabet
+	case TxCodeInputModeText:
+		alphabet = textAlphabet
+	default:
+		return "", fmt.Errorf("unsupported tx_code input mode %s", txCode.InputMode)
+	}
+
+	length := txCode.Length
+	if length <= 0 {
+		length = DefaultTxCodeLength
+	}
+
+	max := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("can not generate tx_code: %w", err)
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
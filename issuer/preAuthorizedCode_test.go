@@ -0,0 +1,119 @@
+package issuer
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+func TestGenerateTxCode(t *testing.T) {
+	code, err := GenerateTxCode(credential.TxCode{InputMode: TxCodeInputModeNumeric, Length: 4})
+
+	if err != nil || !regexp.MustCompile(`^[0-9]{4}$`).MatchString(code) {
+		t.Error(code)
+	}
+
+	code, err = GenerateTxCode(credential.TxCode{InputMode: TxCodeInputModeText, Length: 8})
+
+	if err != nil || len(code) != 8 {
+		t.Error(code)
+	}
+
+	code, err = GenerateTxCode(credential.TxCode{})
+
+	if err != nil || len(code) != DefaultTxCodeLength {
+		t.Error(code)
+	}
+
+	_, err = GenerateTxCode(credential.TxCode{InputMode: "emoji"})
+
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestCreateOffer(t *testing.T) {
+	codes := NewPreAuthorizedCodes(nil)
+
+	offer, txCode, err := codes.CreateOffer("https://issuer.example.com", []string{"A"}, &credential.TxCode{Length: 5}, "")
+
+	if err != nil || len(txCode) != 5 {
+		t.Fatal(err)
+	}
+
+	grant := offer.Grants.PreAuthorizedCode
+	if grant.TxCode.InputMode != TxCodeInputModeNumeric || len(grant.PreAuthorizationCode) < 43 {
+		t.Error()
+	}
+
+	_, _, err = codes.CreateOffer("https://issuer.example.com", nil, nil, "")
+
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	codes := NewPreAuthorizedCodes(nil)
+	srv := httptest.NewServer(&TokenEndpoint{Codes: codes})
+	defer srv.Close()
+
+	grant, txCode, err := codes.Create([]string{"A", "B"}, &credential.TxCode{Length: 4}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := oauth.OpenIdConfiguration{Token_Endpoint: srv.URL}
+
+	_, err = config.GetToken(oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: grant.PreAuthorizationCode,
+		TxCode:            "wrong",
+	})
+
+	if err == nil {
+		t.Error("wrong tx_code accepted")
+	}
+
+	token, err := config.GetToken(oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: grant.PreAuthorizationCode,
+		TxCode:            txCode,
+	})
+
+	if err != nil || token.AccessToken == "" || token.CNonce == "" {
+		t.Fatal(err)
+	}
+
+	if len(token.AuthorizationDetails) != 2 || token.AuthorizationDetails[1].CredentialConfigurationID != "B" {
+		t.Error()
+	}
+
+	_, err = config.GetToken(oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: grant.PreAuthorizationCode,
+		TxCode:            txCode,
+	})
+
+	if err == nil {
+		t.Error("code redeemed twice")
+	}
+}
+
+func TestExpiredCode(t *testing.T) {
+	codes := NewPreAuthorizedCodes(nil)
+	codes.Expiry = time.Millisecond
+
+	grant, _, _ := codes.Create([]string{"A"}, nil, "")
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := codes.Redeem(grant.PreAuthorizationCode, "")
+
+	if err == nil {
+		t.Error()
+	}
+}
@@ -0,0 +1,102 @@
+package issuer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+RateLimitStore counts requests per key in fixed windows. Increment returns the number of requests of the key in the
+current window including this one and the time when the window resets.
+*/
+type RateLimitStore interface {
+	Increment(key string, window time.Duration) (int, time.Time, error)
+}
+
+type rateLimitWindow struct {
+	count int
+	reset time.Time
+}
+
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex
+	windows map[string]*rateLimitWindow
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		windows: make(map[string]*rateLimitWindow),
+	}
+}
+
+func (store *MemoryRateLimitStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	w, ok := store.windows[key]
+	if !ok || now.After(w.reset) {
+		if len(store.windows) > 10000 {
+			store.removeExpired(now)
+		}
+		w = &rateLimitWindow{reset: now.Add(window)}
+		store.windows[key] = w
+	}
+
+	w.count++
+	return w.count, w.reset, nil
+}
+
+func (store *MemoryRateLimitStore) removeExpired(now time.Time) {
+	for key, w := range store.windows {
+		if now.After(w.reset) {
+			delete(store.windows, key)
+		}
+	}
+}
+
+// RateLimiter allows Limit requests per client within Window.
+type RateLimiter struct {
+	Store  RateLimitStore
+	Limit  int
+	Window time.Duration
+	// ClientKey identifies the client of a request, defaults to ClientKeyOf
+	ClientKey func(r *http.Request) string
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Store:  NewMemoryRateLimitStore(),
+		Limit:  limit,
+		Window: window,
+	}
+}
+
+// Allow counts the request and reports if it is within the limit. Store errors deny the request and are returned.
+func (limiter *RateLimiter) Allow(r *http.Request) (bool, time.Time, error) {
+	key := ClientKeyOf(r)
+	if limiter.ClientKey != nil {
+		key = limiter.ClientKey(r)
+	}
+
+	count, reset, err := limiter.Store.Increment(key, limiter.Window)
+	if err != nil {
+		return false, reset, fmt.Errorf("can not count request: %w", err)
+	}
+	return count <= limiter.Limit, reset, nil
+}
+
+/*
+ClientKeyOf uses the remote address. The client_id of public clients is not authenticated and chosen freely, so it
+must not be used as key; a ClientKey may use it after the client is authenticated.
+*/
+func ClientKeyOf(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,157 @@
+package issuer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(2, 50*time.Millisecond)
+
+	request := func(clientId string, remoteAddr string) *http.Request {
+		r := httptest.NewRequest("POST", "/token", strings.NewReader(url.Values{"client_id": {clientId}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.RemoteAddr = remoteAddr
+		return r
+	}
+	r := request("wallet", "192.0.2.1:1234")
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := limiter.Allow(r); !ok {
+			t.Error()
+		}
+	}
+
+	if ok, _, _ := limiter.Allow(r); ok {
+		t.Error("limit exceeded")
+	}
+	if ok, _, _ := limiter.Allow(request("other-wallet", "192.0.2.1:4321")); ok {
+		t.Error("a new client_id must not reset the limit")
+	}
+
+	other := request("wallet", "198.51.100.7:1234")
+	if ok, _, _ := limiter.Allow(other); !ok {
+		t.Error("other clients are not affected")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if ok, _, _ := limiter.Allow(r); !ok {
+		t.Error("window reset")
+	}
+}
+
+func TestTxCodeBruteForce(t *testing.T) {
+	codes := NewPreAuthorizedCodes(nil)
+	codes.MaxTxCodeAttempts = 3
+
+	grant, txCode, _ := codes.Create([]string{"A"}, &credential.TxCode{Length: 4}, "")
+
+	for i := 0; i < 3; i++ {
+		_, err := codes.Redeem(grant.PreAuthorizationCode, "wrong")
+
+		if errResponse, ok := err.(oauth.ErrorResponse); !ok || errResponse.ErrorMsg != oauth.InvalidGrant {
+			t.Error(err)
+		}
+	}
+
+	_, err := codes.Redeem(grant.PreAuthorizationCode, txCode)
+
+	if err == nil {
+		t.Error("code must be invalidated after too many failures")
+	}
+
+	grant, txCode, _ = codes.Create([]string{"A"}, &credential.TxCode{Length: 4}, "")
+	codes.Redeem(grant.PreAuthorizationCode, "wrong")
+
+	if _, err := codes.Redeem(grant.PreAuthorizationCode, txCode); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTxCodeConcurrentGuesses(t *testing.T) {
+	codes := NewPreAuthorizedCodes(nil)
+	codes.MaxTxCodeAttempts = 3
+
+	grant, _, _ := codes.Create([]string{"A"}, &credential.TxCode{Length: 4}, "")
+
+	var checks atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes.Store.Redeem(grant.PreAuthorizationCode, codes.MaxTxCodeAttempts, func(PreAuthorization) error {
+				checks.Add(1)
+				return errors.New("tx_code invalid")
+			})
+		}()
+	}
+	wg.Wait()
+
+	if checks.Load() != 3 {
+		t.Error("guesses after the limit were checked", checks.Load())
+	}
+	if _, err := codes.Store.Redeem(grant.PreAuthorizationCode, codes.MaxTxCodeAttempts, nil); err == nil {
+		t.Error("code must be invalidated after too many failures")
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Increment(key string, window time.Duration) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("store unreachable")
+}
+
+func TestRateLimiterStoreFailure(t *testing.T) {
+	limiter := NewRateLimiter(10, time.Minute)
+	limiter.Store = failingRateLimitStore{}
+
+	r := httptest.NewRequest("POST", "/token", nil)
+	if ok, _, err := limiter.Allow(r); ok || err == nil {
+		t.Error("store errors must deny the request")
+	}
+
+	endpoint := &TokenEndpoint{Codes: NewPreAuthorizedCodes(nil), RateLimiter: limiter}
+	form := url.Values{"grant_type": {string(oauth.PreAuthorizedCodeGrant)}, "pre-authorized_code": {"unknown"}}
+	r = httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	endpoint.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Error(w.Code)
+	}
+}
+
+func TestTokenEndpointRateLimit(t *testing.T) {
+	endpoint := &TokenEndpoint{
+		Codes:       NewPreAuthorizedCodes(nil),
+		RateLimiter: NewRateLimiter(1, time.Minute),
+	}
+
+	form := url.Values{"grant_type": {string(oauth.PreAuthorizedCodeGrant)}, "pre-authorized_code": {"unknown"}}
+
+	for i, status := range []int{400, 429} {
+		r := httptest.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		endpoint.ServeHTTP(w, r)
+
+		if w.Code != status {
+			t.Error(i, w.Code)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package issuer
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+// AccessTokenIssuer mints the access token for a redeemed pre-authorization (e.g. a signed JWT or an opaque session id).
+type AccessTokenIssuer func(authorization PreAuthorization) (*oauth.Token, error)
+
+/*
+TokenEndpoint is an http.Handler for the token endpoint of the pre-authorized code flow. Codes are redeemed exactly once,
+the response contains authorization_details for every credential configuration bound to the code.
+*/
+type TokenEndpoint struct {
+	Codes *PreAuthorizedCodes
+	// AccessTokens defaults to random opaque access tokens with c_nonce.
+	AccessTokens AccessTokenIssuer
+	// RateLimiter is optional and limits the token requests per client.
+	RateLimiter *RateLimiter
+}
+
+func (endpoint *TokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Method != http.MethodPost {
+		helper.WriteJson(w, http.StatusMethodNotAllowed, oauth.ErrorResponse{ErrorMsg: oauth.InvalidRequest})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.InvalidRequest})
+		return
+	}
+
+	if endpoint.RateLimiter != nil {
+		ok, reset, err := endpoint.RateLimiter.Allow(r)
+		if err != nil {
+			helper.WriteJson(w, http.StatusInternalServerError, oauth.ErrorResponse{ErrorMsg: "server_error"})
+			return
+		}
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+			helper.WriteJson(w, http.StatusTooManyRequests, oauth.ErrorResponse{ErrorMsg: oauth.SlowDown, ErrorDescription: "too many requests"})
+			return
+		}
+	}
+
+	if oauth.GrantType(r.PostForm.Get("grant_type")) != oauth.PreAuthorizedCodeGrant {
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.UnsupportedGrantType})
+		return
+	}
+
+	authorization, err := endpoint.Codes.Redeem(r.PostForm.Get("pre-authorized_code"), r.PostForm.Get("tx_code"))
+	if err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, err)
+		return
+	}
+
+	issue := endpoint.AccessTokens
+	if issue == nil {
+		issue = OpaqueAccessToken
+	}
+
+	token, err := issue(*authorization)
+	if err != nil {
+		helper.WriteJson(w, http.StatusInternalServerError, oauth.ErrorResponse{ErrorMsg: "server_error"})
+		return
+	}
+
+	if len(token.AuthorizationDetails) == 0 {
+		token.AuthorizationDetails = AuthorizationDetails(*authorization)
+	}
+
+	helper.WriteJson(w, http.StatusOK, token)
+}
+
+// AuthorizationDetails returns one openid_credential entry per credential configuration of the authorization.
+func AuthorizationDetails(authorization PreAuthorization) []oauth.AuthorizationDetails {
+	details := make([]oauth.AuthorizationDetails, 0, len(authorization.CredentialConfigurationIds))
+	for _, id := range authorization.CredentialConfigurationIds {
+		details = append(details, oauth.AuthorizationDetails{
+			Type:                      oauth.AuthorizationDetailsTypeOpenIdCredential,
+			CredentialConfigurationID: id,
+		})
+	}
+	return details
+}
+
+// OpaqueAccessToken creates a random access token and c_nonce.
+func OpaqueAccessToken(authorization PreAuthorization) (*oauth.Token, error) {
+	accessToken, err := GenerateCode()
+	if err != nil {
+		return nil, err
+	}
+
+	cNonce, err := GenerateCode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth.Token{
+		AccessToken:     accessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(config.DefaultTokenExpiry.Seconds()),
+		CNonce:          cNonce,
+		CNonceExpiresIn: int64(config.DefaultTokenExpiry.Seconds()),
+	}, nil
+}
@@ -0,0 +1,187 @@
+package jwtkeys
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+)
+
+// IssuerMetadataPath is the well-known path of the JWT VC issuer metadata.
+const IssuerMetadataPath = "/.well-known/jwt-vc-issuer"
+
+var ErrKeyNotFound = errors.New("no key of the issuer found")
+
+// Resolver returns the keys which may have signed the jwt of an issuer, header is the protected header of the jwt.
+type Resolver func(issuer string, header map[string]interface{}) ([]jwk.Key, error)
+
+// IssuerMetadata is published by issuers under IssuerMetadataPath.
+type IssuerMetadata struct {
+	Issuer  string          `json:"issuer"`
+	Jwks    json.RawMessage `json:"jwks,omitempty"`
+	JwksUri string          `json:"jwks_uri,omitempty"`
+}
+
+// Static trusts the given keys for all issuers.
+func Static(keys ...jwk.Key) Resolver {
+	return func(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+		return keys, nil
+	}
+}
+
+/*
+NewResolver resolves the key from the x5c header if present, DID issuers with the DID resolver and https issuers by
+the jwks or jwks_uri of their issuer metadata. A DID in the kid header is only trusted if it is the issuer.
+Certificates are checked against roots, nil uses the system roots.
+*/
+func NewResolver(client helper.HttpClient, roots *x509.CertPool) Resolver {
+	x5c := X5c(roots)
+	dids := did.NewResolver(client)
+	metadata := Metadata(client)
+
+	return func(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+		if _, ok := header["x5c"]; ok {
+			return x5c(issuer, header)
+		}
+		if strings.HasPrefix(issuer, "did:") {
+			return dids.IssuerKeys(issuer, header)
+		}
+		return metadata(issuer, header)
+	}
+}
+
+/*
+X5c takes the key of the leaf certificate of the x5c header. The chain must be valid for roots and the leaf must
+name the issuer as uniformResourceIdentifier or, for https issuers, as dNSName.
+*/
+func X5c(roots *x509.CertPool) Resolver {
+	return func(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+		encoded, _ := header["x5c"].([]interface{})
+		if len(encoded) == 0 {
+			return nil, fmt.Errorf("%w: x5c header missing", ErrKeyNotFound)
+		}
+
+		certificates := make([]*x509.Certificate, 0, len(encoded))
+		for _, e := range encoded {
+			s, _ := e.(string)
+			der, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid x5c certificate: %w", err)
+			}
+			certificate, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("invalid x5c certificate: %w", err)
+			}
+			certificates = append(certificates, certificate)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, certificate := range certificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		leaf := certificates[0]
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("x5c certificate chain invalid: %w", err)
+		}
+
+		if !certifies(leaf, issuer) {
+			return nil, fmt.Errorf("x5c certificate is not issued for %s", issuer)
+		}
+
+		key, err := jwk.FromRaw(leaf.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return []jwk.Key{key}, nil
+	}
+}
+
+func certifies(certificate *x509.Certificate, issuer string) bool {
+	for _, uri := range certificate.URIs {
+		if uri.String() == issuer {
+			return true
+		}
+	}
+
+	u, err := url.Parse(issuer)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	return slices.Contains(certificate.DNSNames, u.Hostname())
+}
+
+// Metadata resolves the keys of https issuers from their issuer metadata, a kid header selects the key.
+func Metadata(client helper.HttpClient) Resolver {
+	return func(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+		location, err := MetadataLocation(issuer)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := helper.GetWithClient(client, location)
+		if err != nil {
+			return nil, fmt.Errorf("can not fetch issuer metadata of %s: %w", issuer, err)
+		}
+
+		var metadata IssuerMetadata
+		if err := json.Unmarshal(b, &metadata); err != nil {
+			return nil, fmt.Errorf("invalid issuer metadata of %s: %w", issuer, err)
+		}
+		if metadata.Issuer != issuer {
+			return nil, fmt.Errorf("issuer metadata of %s belongs to %s", issuer, metadata.Issuer)
+		}
+
+		jwks := []byte(metadata.Jwks)
+		if len(jwks) == 0 {
+			if metadata.JwksUri == "" {
+				return nil, fmt.Errorf("%w: issuer metadata of %s without jwks", ErrKeyNotFound, issuer)
+			}
+			jwks, err = helper.GetWithClient(client, metadata.JwksUri)
+			if err != nil {
+				return nil, fmt.Errorf("can not fetch jwks of %s: %w", issuer, err)
+			}
+		}
+
+		set, err := jwk.Parse(jwks)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks of %s: %w", issuer, err)
+		}
+
+		kid, _ := header["kid"].(string)
+		keys := make([]jwk.Key, 0, set.Len())
+		for i := 0; i < set.Len(); i++ {
+			key, _ := set.Key(i)
+			if kid == "" || key.KeyID() == kid {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("%w: kid %s of %s", ErrKeyNotFound, kid, issuer)
+		}
+		return keys, nil
+	}
+}
+
+// MetadataLocation inserts the well-known path between host and path of the issuer.
+func MetadataLocation(issuer string) (string, error) {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return "", fmt.Errorf("%w: issuer %s is no url", ErrKeyNotFound, issuer)
+	}
+	return u.Scheme + "://" + u.Host + IssuerMetadataPath + strings.TrimSuffix(u.Path, "/"), nil
+}
@@ -0,0 +1,50 @@
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestX5c(t *testing.T) {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		URIs:                  []*url.URL{{Scheme: "https", Host: "issuer.example.com"}},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &raw.PublicKey, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	header := map[string]interface{}{"x5c": []interface{}{base64.StdEncoding.EncodeToString(der)}}
+	if keys, err := X5c(roots)("https://issuer.example.com", header); err != nil || len(keys) != 1 {
+		t.Error(err)
+	}
+	if _, err := X5c(roots)("https://other.example.com", header); err == nil {
+		t.Error("certificate of another issuer accepted")
+	}
+	if _, err := X5c(x509.NewCertPool())("https://issuer.example.com", header); err == nil {
+		t.Error("untrusted certificate accepted")
+	}
+}
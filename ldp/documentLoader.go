@@ -0,0 +1,122 @@
+package ldp
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+var ErrContextNotFound = errors.New("json-ld context not found")
+
+//go:embed contexts/*.jsonld
+var contextFiles embed.FS
+
+// bundledContexts maps the urls of the contexts which are available offline to their files.
+var bundledContexts = map[string]string{
+	"https://www.w3.org/2018/credentials/v1":           "contexts/credentials-v1.jsonld",
+	"https://www.w3.org/ns/credentials/v2":             "contexts/credentials-v2.jsonld",
+	"https://www.w3.org/2018/credentials/examples/v1":  "contexts/credentials-examples-v1.jsonld",
+	"https://www.w3.org/ns/credentials/examples/v2":    "contexts/credentials-examples-v2.jsonld",
+	"https://w3id.org/security/suites/ed25519-2018/v1": "contexts/ed25519-2018-v1.jsonld",
+	"https://w3id.org/security/suites/ed25519-2020/v1": "contexts/ed25519-2020-v1.jsonld",
+	"https://w3id.org/security/suites/jws-2020/v1":     "contexts/jws-2020-v1.jsonld",
+	"https://w3id.org/security/data-integrity/v2":      "contexts/data-integrity-v2.jsonld",
+}
+
+/*
+DocumentLoader resolves JSON-LD contexts from memory. It contains the bundled contexts of the credential data models and
+the supported proof suites, further contexts can be added with AddContext. Unknown urls are passed to Fallback, a nil
+Fallback keeps the loader offline.
+*/
+type DocumentLoader struct {
+	Fallback ld.DocumentLoader
+
+	mutex     sync.RWMutex
+	documents map[string][]byte
+}
+
+// NewDocumentLoader creates a loader with the bundled contexts, fallback may be nil.
+func NewDocumentLoader(fallback ld.DocumentLoader) *DocumentLoader {
+	loader := DocumentLoader{
+		Fallback:  fallback,
+		documents: make(map[string][]byte, len(bundledContexts)),
+	}
+	for url, file := range bundledContexts {
+		b, err := contextFiles.ReadFile(file)
+		if err != nil {
+			panic(fmt.Sprintf("bundled context %s missing: %v", file, err))
+		}
+		loader.documents[url] = b
+	}
+	return &loader
+}
+
+// AddContext makes the context document available under url, existing documents are replaced.
+func (loader *DocumentLoader) AddContext(url string, document []byte) error {
+	if _, err := ld.DocumentFromReader(bytes.NewReader(document)); err != nil {
+		return fmt.Errorf("invalid context %s: %w", url, err)
+	}
+
+	loader.mutex.Lock()
+	defer loader.mutex.Unlock()
+	loader.documents[url] = document
+	return nil
+}
+
+// LoadDocument implements ld.DocumentLoader.
+func (loader *DocumentLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	loader.mutex.RLock()
+	b, ok := loader.documents[url]
+	loader.mutex.RUnlock()
+
+	if !ok {
+		if loader.Fallback == nil {
+			return nil, fmt.Errorf("%w: %s", ErrContextNotFound, url)
+		}
+		return loader.Fallback.LoadDocument(url)
+	}
+
+	// every load decodes a fresh copy, the processor must not share mutable documents
+	document, err := ld.DocumentFromReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return &ld.RemoteDocument{DocumentURL: url, Document: document}, nil
+}
+
+/*
+Canonicalize returns the canonical N-Quads (RDF Dataset Canonicalization, URDNA2015) of a JSON-LD document. Properties
+which are not defined by the contexts are rejected instead of dropped, they would not be covered by a signature.
+*/
+func Canonicalize(document map[string]interface{}, loader ld.DocumentLoader) (string, error) {
+	processor := ld.NewJsonLdProcessor()
+
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = loader
+	options.ProcessingMode = ld.JsonLd_1_1
+	options.Format = "application/n-quads"
+	options.SafeMode = true
+
+	// Normalize does not pass the safe mode to its RDF conversion, so the dataset is converted first
+	dataset, err := processor.ToRDF(document, options)
+	if err != nil {
+		return "", fmt.Errorf("can not convert document to rdf: %w", err)
+	}
+
+	options.InputFormat = "application/n-quads"
+	options.Algorithm = ld.AlgorithmURDNA2015
+	normalized, err := processor.Normalize(dataset, options)
+	if err != nil {
+		return "", fmt.Errorf("can not canonicalize document: %w", err)
+	}
+
+	nquads, ok := normalized.(string)
+	if !ok {
+		return "", errors.New("canonicalization returned no n-quads")
+	}
+	return nquads, nil
+}
@@ -0,0 +1,43 @@
+package ldp
+
+import (
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+)
+
+// KeyResolver returns the public key of a verification method, e.g. did:key:z6Mk...#z6Mk...
+type KeyResolver func(verificationMethod string) (jwk.Key, error)
+
+// StaticKeys resolves the verification methods from keys.
+func StaticKeys(keys map[string]jwk.Key) KeyResolver {
+	return func(verificationMethod string) (jwk.Key, error) {
+		key, ok := keys[verificationMethod]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", did.ErrVerificationMethodNotFound, verificationMethod)
+		}
+		return key, nil
+	}
+}
+
+// DefaultKeyResolver resolves verification methods with the DID resolver, did:web documents are fetched with client.
+func DefaultKeyResolver(client helper.HttpClient) KeyResolver {
+	return DidKeys(did.NewResolver(client))
+}
+
+// DidKeys resolves verification methods with resolver, which must return exactly one key for the DID url.
+func DidKeys(resolver did.Resolver) KeyResolver {
+	return func(verificationMethod string) (jwk.Key, error) {
+		keys, err := resolver(verificationMethod)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) != 1 {
+			return nil, fmt.Errorf("%w: %s is ambiguous", did.ErrVerificationMethodNotFound, verificationMethod)
+		}
+		return keys[0], nil
+	}
+}
@@ -0,0 +1,192 @@
+package ldp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+)
+
+// Proof types and Data Integrity cryptosuites, https://www.w3.org/TR/vc-data-integrity/
+const (
+	Ed25519Signature2018 = "Ed25519Signature2018"
+	Ed25519Signature2020 = "Ed25519Signature2020"
+	JsonWebSignature2020 = "JsonWebSignature2020"
+	DataIntegrityProof   = "DataIntegrityProof"
+
+	EddsaRdfc2022 = "eddsa-rdfc-2022"
+	EcdsaRdfc2019 = "ecdsa-rdfc-2019"
+)
+
+// Proof purposes of credentials and presentations.
+const (
+	AssertionMethod = "assertionMethod"
+	Authentication  = "authentication"
+)
+
+var (
+	ErrProofInvalid     = errors.New("data integrity proof invalid")
+	ErrUnsupportedSuite = errors.New("unsupported proof suite")
+)
+
+// Suite returns the name of the suite of a proof, which is the cryptosuite for a DataIntegrityProof.
+func Suite(proof map[string]interface{}) string {
+	proofType, _ := proof["type"].(string)
+	if proofType == DataIntegrityProof {
+		cryptosuite, _ := proof["cryptosuite"].(string)
+		return cryptosuite
+	}
+	return proofType
+}
+
+/*
+hashData returns the input of the signature: the hash of the canonical proof options followed by the hash of the
+canonical document. The proof options are the proof without its value and with the @context of the document.
+*/
+func hashData(document map[string]interface{}, proof map[string]interface{}, loader ld.DocumentLoader, hash crypto.Hash) ([]byte, error) {
+	unsecured := make(map[string]interface{}, len(document))
+	for k, v := range document {
+		if k != "proof" {
+			unsecured[k] = v
+		}
+	}
+
+	options := make(map[string]interface{}, len(proof))
+	for k, v := range proof {
+		switch k {
+		case "proofValue", "jws", "signatureValue":
+		default:
+			options[k] = v
+		}
+	}
+	options["@context"] = document["@context"]
+
+	canonicalOptions, err := Canonicalize(options, loader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proof options: %w", ErrProofInvalid, err)
+	}
+	canonicalDocument, err := Canonicalize(unsecured, loader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: document: %w", ErrProofInvalid, err)
+	}
+
+	h := hash.New()
+	h.Write([]byte(canonicalOptions))
+	data := h.Sum(nil)
+
+	h = hash.New()
+	h.Write([]byte(canonicalDocument))
+	return h.Sum(data), nil
+}
+
+// suiteHash is SHA-384 for ecdsa-rdfc-2019 with P-384 keys and SHA-256 otherwise.
+func suiteHash(suite string, key jwk.Key) crypto.Hash {
+	if suite == EcdsaRdfc2019 {
+		if k, ok := key.(jwk.ECDSAPublicKey); ok && k.Crv() == jwa.P384 {
+			return crypto.SHA384
+		}
+	}
+	return crypto.SHA256
+}
+
+// verifySignature checks the proof value or detached jws of the proof over data with key.
+func verifySignature(suite string, proof map[string]interface{}, data []byte, key jwk.Key) error {
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return err
+	}
+
+	switch suite {
+	case Ed25519Signature2020, EddsaRdfc2022:
+		signature, err := proofValue(proof)
+		if err != nil {
+			return err
+		}
+		public, ok := raw.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an ed25519 key", ErrProofInvalid, suite)
+		}
+		if !ed25519.Verify(public, data, signature) {
+			return fmt.Errorf("%w: signature does not match", ErrProofInvalid)
+		}
+		return nil
+	case EcdsaRdfc2019:
+		signature, err := proofValue(proof)
+		if err != nil {
+			return err
+		}
+		public, ok := raw.(*ecdsa.PublicKey)
+		if !ok || public.Curve != elliptic.P256() && public.Curve != elliptic.P384() {
+			return fmt.Errorf("%w: %s requires a P-256 or P-384 key", ErrProofInvalid, suite)
+		}
+		hash := suiteHash(suite, key).New()
+		hash.Write(data)
+
+		size := (public.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: signature must be r || s", ErrProofInvalid)
+		}
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(public, hash.Sum(nil), r, s) {
+			return fmt.Errorf("%w: signature does not match", ErrProofInvalid)
+		}
+		return nil
+	case Ed25519Signature2018, JsonWebSignature2020:
+		return verifyDetachedJws(suite, proof, data, key)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedSuite, suite)
+}
+
+func proofValue(proof map[string]interface{}) ([]byte, error) {
+	value, _ := proof["proofValue"].(string)
+	signature, err := did.DecodeMultibase(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: proofValue: %w", ErrProofInvalid, err)
+	}
+	return signature, nil
+}
+
+// verifyDetachedJws checks the jws of the JWS based suites, a compact jws with unencoded detached payload (b64 false).
+func verifyDetachedJws(suite string, proof map[string]interface{}, data []byte, key jwk.Key) error {
+	compact, _ := proof["jws"].(string)
+	if strings.Count(compact, ".") != 2 || strings.Split(compact, ".")[1] != "" {
+		return fmt.Errorf("%w: jws must have a detached payload", ErrProofInvalid)
+	}
+
+	message, err := jws.Parse([]byte(compact))
+	if err != nil || len(message.Signatures()) != 1 {
+		return fmt.Errorf("%w: invalid jws", ErrProofInvalid)
+	}
+	headers := message.Signatures()[0].ProtectedHeaders()
+
+	alg := headers.Algorithm()
+	switch alg {
+	case "", jwa.NoSignature, jwa.HS256, jwa.HS384, jwa.HS512:
+		return fmt.Errorf("%w: alg %s not allowed", ErrProofInvalid, alg)
+	}
+	if suite == Ed25519Signature2018 && alg != jwa.EdDSA {
+		return fmt.Errorf("%w: %s requires EdDSA", ErrProofInvalid, suite)
+	}
+
+	if b64, ok := headers.Get("b64"); !ok || b64 != false {
+		return fmt.Errorf("%w: jws payload must be unencoded", ErrProofInvalid)
+	}
+
+	if _, err := jws.Verify([]byte(compact), jws.WithKey(alg, key), jws.WithDetachedPayload(data)); err != nil {
+		return fmt.Errorf("%w: %w", ErrProofInvalid, err)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package ldp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/piprate/json-gold/ld"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+// suiteContexts are added to documents which do not define the proof type yet.
+var suiteContexts = map[string]string{
+	Ed25519Signature2018: "https://w3id.org/security/suites/ed25519-2018/v1",
+	Ed25519Signature2020: "https://w3id.org/security/suites/ed25519-2020/v1",
+	JsonWebSignature2020: "https://w3id.org/security/suites/jws-2020/v1",
+	DataIntegrityProof:   "https://w3id.org/security/data-integrity/v2",
+}
+
+/*
+Signer secures credentials and presentations with Linked Data proofs. The private key operation is delegated to
+signing.Signer, the key must be referenced by VerificationMethod, e.g. did:key:z6Mk...#z6Mk...
+*/
+type Signer struct {
+	Signer             signing.Signer
+	VerificationMethod string
+	// Suite is a proof type or a cryptosuite of DataIntegrityProof, see DefaultSuite
+	Suite  string
+	Loader ld.DocumentLoader
+}
+
+// NewSigner creates a signer with the default suite of the key and the offline document loader.
+func NewSigner(signer signing.Signer, verificationMethod string) (*Signer, error) {
+	if signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	if verificationMethod == "" {
+		return nil, errors.New("verification method missing")
+	}
+
+	suite, err := DefaultSuite(signer.Algorithm())
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		Signer:             signer,
+		VerificationMethod: verificationMethod,
+		Suite:              suite,
+		Loader:             NewDocumentLoader(nil),
+	}, nil
+}
+
+// DefaultSuite returns eddsa-rdfc-2022 for EdDSA, ecdsa-rdfc-2019 for ES256/ES384 and JsonWebSignature2020 otherwise.
+func DefaultSuite(alg jwa.SignatureAlgorithm) (string, error) {
+	switch alg {
+	case jwa.EdDSA:
+		return EddsaRdfc2022, nil
+	case jwa.ES256, jwa.ES384:
+		return EcdsaRdfc2019, nil
+	case jwa.ES512, jwa.PS256, jwa.PS384, jwa.PS512, jwa.RS256, jwa.RS384, jwa.RS512, jwa.ES256K:
+		return JsonWebSignature2020, nil
+	}
+	return "", fmt.Errorf("%w: no suite for alg %s", ErrUnsupportedSuite, alg)
+}
+
+// Holder returns the controller of the verification method, the DID without fragment.
+func (signer *Signer) Holder() string {
+	controller, _, _ := strings.Cut(signer.VerificationMethod, "#")
+	return controller
+}
+
+// SignCredential checks the data model of the credential and returns a copy with an assertionMethod proof.
+func (signer *Signer) SignCredential(credential map[string]interface{}) (map[string]interface{}, error) {
+	vc, err := types.ParseVerifiableCredential(credential)
+	if err != nil {
+		return nil, err
+	}
+	if vc.Issuer != signer.Holder() {
+		return nil, fmt.Errorf("verification method %s does not belong to issuer %s", signer.VerificationMethod, vc.Issuer)
+	}
+	return signer.Sign(credential, map[string]interface{}{"proofPurpose": AssertionMethod})
+}
+
+/*
+SignPresentation returns a copy of the presentation with an authentication proof over challenge and domain. In OID4VP
+the challenge is the nonce and the domain the client_id of the request.
+*/
+func (signer *Signer) SignPresentation(presentation map[string]interface{}, challenge string, domain string) (map[string]interface{}, error) {
+	if _, err := types.ParseVerifiablePresentation(presentation); err != nil {
+		return nil, err
+	}
+	if challenge == "" || domain == "" {
+		return nil, errors.New("challenge and domain are required")
+	}
+	return signer.Sign(presentation, map[string]interface{}{
+		"proofPurpose": Authentication,
+		"challenge":    challenge,
+		"domain":       domain,
+	})
+}
+
+/*
+Sign returns a copy of the document with a proof of the suite. options are further proof properties like
+proofPurpose, challenge or domain, type, cryptosuite, verificationMethod and created are set by the signer.
+*/
+func (signer *Signer) Sign(document map[string]interface{}, options map[string]interface{}) (map[string]interface{}, error) {
+	if signer.Signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	if _, ok := document["proof"]; ok {
+		return nil, errors.New("document is already secured")
+	}
+
+	proof := make(map[string]interface{}, len(options)+4)
+	for k, v := range options {
+		proof[k] = v
+	}
+	switch signer.Suite {
+	case EddsaRdfc2022, EcdsaRdfc2019:
+		proof["type"] = DataIntegrityProof
+		proof["cryptosuite"] = signer.Suite
+	case Ed25519Signature2018, Ed25519Signature2020, JsonWebSignature2020:
+		proof["type"] = signer.Suite
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedSuite, signer.Suite)
+	}
+	proof["verificationMethod"] = signer.VerificationMethod
+	proof["created"] = time.Now().UTC().Format(time.RFC3339)
+
+	secured := make(map[string]interface{}, len(document)+1)
+	for k, v := range document {
+		secured[k] = v
+	}
+	secured["@context"] = withSuiteContext(document["@context"], proof["type"].(string))
+
+	loader := signer.Loader
+	if loader == nil {
+		loader = NewDocumentLoader(nil)
+	}
+	data, err := hashData(secured, proof, loader, suiteHash(signer.Suite, signer.Signer.PublicKey()))
+	if err != nil {
+		return nil, err
+	}
+
+	switch signer.Suite {
+	case Ed25519Signature2018, JsonWebSignature2020:
+		compact, err := signer.detachedJws(data)
+		if err != nil {
+			return nil, err
+		}
+		proof["jws"] = compact
+	default:
+		if err := signer.checkAlgorithm(); err != nil {
+			return nil, err
+		}
+		signature, err := signer.Signer.Sign(data)
+		if err != nil {
+			return nil, fmt.Errorf("can not sign proof: %w", err)
+		}
+		proof["proofValue"] = did.EncodeMultibase(signature)
+	}
+
+	secured["proof"] = proof
+	return secured, nil
+}
+
+// checkAlgorithm matches the algorithm of the signer with the cryptosuites which sign the hash data directly.
+func (signer *Signer) checkAlgorithm() error {
+	alg := signer.Signer.Algorithm()
+	switch signer.Suite {
+	case Ed25519Signature2020, EddsaRdfc2022:
+		if alg == jwa.EdDSA {
+			return nil
+		}
+	case EcdsaRdfc2019:
+		if alg == jwa.ES256 || alg == jwa.ES384 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s can not be used with %s", ErrUnsupportedSuite, signer.Suite, alg)
+}
+
+// detachedJws signs data as unencoded payload (b64 false) and returns the jws without payload.
+func (signer *Signer) detachedJws(data []byte) (string, error) {
+	alg := signer.Signer.Algorithm()
+	if signer.Suite == Ed25519Signature2018 && alg != jwa.EdDSA {
+		return "", fmt.Errorf("%w: %s requires EdDSA", ErrUnsupportedSuite, signer.Suite)
+	}
+
+	header, err := json.Marshal(map[string]interface{}{
+		"alg":  alg.String(),
+		"b64":  false,
+		"crit": []string{"b64"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(header)
+	signature, err := signer.Signer.Sign(append([]byte(encoded+"."), data...))
+	if err != nil {
+		return "", fmt.Errorf("can not sign proof: %w", err)
+	}
+	return encoded + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// withSuiteContext appends the context of the proof type if the base context of the document does not define it.
+func withSuiteContext(context interface{}, proofType string) interface{} {
+	var contexts []interface{}
+	switch c := context.(type) {
+	case []interface{}:
+		contexts = append(contexts, c...)
+	case nil:
+	default:
+		contexts = append(contexts, c)
+	}
+
+	suiteContext := suiteContexts[proofType]
+	if slices.Contains(contexts, interface{}(suiteContext)) || len(contexts) == 0 {
+		return context
+	}
+
+	switch contexts[0] {
+	case types.ContextV2:
+		// the credentials v2 context defines DataIntegrityProof
+		if proofType == DataIntegrityProof {
+			return context
+		}
+	case types.ContextV1:
+		// the credentials v1 context defines Ed25519Signature2018
+		if proofType == Ed25519Signature2018 {
+			return context
+		}
+	}
+	return append(contexts, suiteContext)
+}
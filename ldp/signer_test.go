@@ -0,0 +1,111 @@
+package ldp
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func testLdpSigner(t *testing.T, private interface{}, suite string) *Signer {
+	method, key := testDidKey(t, private)
+	jwkSigner, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSigner(jwkSigner, method)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suite != "" {
+		signer.Suite = suite
+	}
+	return signer
+}
+
+func TestSignCredential(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	ecPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Private, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	tests := []struct {
+		suite   string
+		private interface{}
+	}{
+		{EddsaRdfc2022, edPrivate},
+		{EcdsaRdfc2019, ecPrivate},
+		{EcdsaRdfc2019, p384Private},
+		{Ed25519Signature2020, edPrivate},
+		{Ed25519Signature2018, edPrivate},
+		{JsonWebSignature2020, ecPrivate},
+	}
+
+	for _, test := range tests {
+		signer := testLdpSigner(t, test.private, test.suite)
+		credential := testDocument(t, signer.Holder())
+
+		signed, err := signer.SignCredential(credential)
+		if err != nil {
+			t.Error(test.suite, err)
+			continue
+		}
+		if _, ok := credential["proof"]; ok {
+			t.Error("credential must not be modified")
+		}
+		if Suite(signed["proof"].(map[string]interface{})) != test.suite {
+			t.Error(test.suite, signed["proof"])
+		}
+
+		if _, err := NewVerifier(nil).VerifyCredential(signed); err != nil {
+			t.Error(test.suite, err)
+		}
+	}
+}
+
+func TestSignCredentialV1(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, suite := range []string{Ed25519Signature2018, Ed25519Signature2020, EddsaRdfc2022} {
+		signer := testLdpSigner(t, private, suite)
+		credential := map[string]interface{}{
+			"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1", "https://www.w3.org/2018/credentials/examples/v1"},
+			"type":              []interface{}{"VerifiableCredential", "UniversityDegreeCredential"},
+			"issuer":            signer.Holder(),
+			"issuanceDate":      "2024-01-01T00:00:00Z",
+			"credentialSubject": map[string]interface{}{"id": "did:example:subject", "degree": "Bachelor of Science"},
+		}
+
+		signed, err := signer.SignCredential(credential)
+		if err != nil {
+			t.Error(suite, err)
+			continue
+		}
+		if _, err := NewVerifier(nil).VerifyCredential(signed); err != nil {
+			t.Error(suite, err)
+		}
+	}
+}
+
+func TestSignRequirements(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+
+	signer := testLdpSigner(t, private, EcdsaRdfc2019)
+	if _, err := signer.SignCredential(testDocument(t, signer.Holder())); err == nil {
+		t.Error("ecdsa suite with ed25519 key accepted")
+	}
+
+	signer = testLdpSigner(t, private, "")
+	if _, err := signer.SignCredential(testDocument(t, "did:example:other")); err == nil {
+		t.Error("credential of another issuer signed")
+	}
+	if _, err := signer.SignPresentation(map[string]interface{}{
+		"@context": []interface{}{"https://www.w3.org/ns/credentials/v2"},
+		"type":     "VerifiablePresentation",
+	}, "", "x509_san_dns:verifier.example.org"); err == nil || !strings.Contains(err.Error(), "challenge") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,179 @@
+package ldp
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+)
+
+/*
+Verifier checks the Linked Data proofs of ldp_vc and ldp_vp documents. Documents are canonicalized with the contexts of
+Loader, the keys of the verification methods are resolved by Keys.
+*/
+type Verifier struct {
+	Keys   KeyResolver
+	Loader ld.DocumentLoader
+	// Leeway for clock differences, defaults to config.DefaultLeeway
+	Leeway time.Duration
+}
+
+// NewVerifier creates a verifier with the offline document loader, nil keys use DefaultKeyResolver.
+func NewVerifier(keys KeyResolver) *Verifier {
+	if keys == nil {
+		keys = DefaultKeyResolver(helper.NewHttpClient())
+	}
+	return &Verifier{Keys: keys, Loader: NewDocumentLoader(nil)}
+}
+
+// VerifyCredential checks the assertion proofs of the issuer, the data model and the validity window of a credential.
+func (verifier *Verifier) VerifyCredential(document map[string]interface{}) (*types.Credential, error) {
+	vc, err := types.ParseVerifiableCredential(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := verifier.VerifyProof(document, AssertionMethod, vc.Issuer); err != nil {
+		return nil, err
+	}
+	if err := vc.CheckValidity(time.Now(), verifier.leeway()); err != nil {
+		return nil, err
+	}
+
+	credential := types.Credential{
+		Format:     types.LDPVC,
+		Json:       document,
+		Issuer:     vc.Issuer,
+		ValidFrom:  vc.ValidFrom,
+		ValidUntil: vc.ValidUntil,
+	}
+	if id, ok := vc.CredentialSubject[0]["id"].(string); ok {
+		credential.Subject = id
+	}
+	return &credential, nil
+}
+
+/*
+VerifyPresentation checks the authentication proof of the holder, which must contain challenge and domain, and all
+embedded credentials. Presentations without holder are rejected. It returns the parsed presentation and the verified credentials.
+*/
+func (verifier *Verifier) VerifyPresentation(document map[string]interface{}, challenge string, domain string) (*types.VerifiablePresentation, []*types.Credential, error) {
+	vp, err := types.ParseVerifiablePresentation(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vp.Holder == "" {
+		return nil, nil, fmt.Errorf("%w: holder missing", types.ErrInvalidPresentation)
+	}
+
+	proofs, err := verifier.VerifyProof(document, Authentication, vp.Holder)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, proof := range proofs {
+		domains, _ := proof["domain"].([]interface{})
+		if d, ok := proof["domain"].(string); ok {
+			domains = []interface{}{d}
+		}
+		if proof["challenge"] != challenge || !slices.Contains(domains, interface{}(domain)) {
+			return nil, nil, fmt.Errorf("%w: challenge or domain does not match", ErrProofInvalid)
+		}
+	}
+
+	credentials := make([]*types.Credential, 0, len(vp.VerifiableCredential))
+	for _, embedded := range vp.VerifiableCredential {
+		object, ok := embedded.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: only credentials with data integrity proofs are supported", types.ErrInvalidPresentation)
+		}
+		credential, err := verifier.VerifyCredential(object)
+		if err != nil {
+			return nil, nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return vp, credentials, nil
+}
+
+/*
+VerifyProof checks every proof of the document for the proof purpose and returns them. The verification methods must
+belong to the controller, an empty controller accepts any verification method.
+*/
+func (verifier *Verifier) VerifyProof(document map[string]interface{}, purpose string, controller string) ([]map[string]interface{}, error) {
+	var proofs []map[string]interface{}
+	switch p := document["proof"].(type) {
+	case map[string]interface{}:
+		proofs = append(proofs, p)
+	case []interface{}:
+		for _, e := range p {
+			proof, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: proof is no object", ErrProofInvalid)
+			}
+			proofs = append(proofs, proof)
+		}
+	}
+	if len(proofs) == 0 {
+		return nil, fmt.Errorf("%w: proof missing", ErrProofInvalid)
+	}
+
+	for _, proof := range proofs {
+		if err := verifier.verifyProof(document, proof, purpose, controller); err != nil {
+			return nil, err
+		}
+	}
+	return proofs, nil
+}
+
+func (verifier *Verifier) verifyProof(document map[string]interface{}, proof map[string]interface{}, purpose string, controller string) error {
+	suite := Suite(proof)
+	if proof["proofPurpose"] != purpose {
+		return fmt.Errorf("%w: proofPurpose must be %s", ErrProofInvalid, purpose)
+	}
+
+	method, _ := proof["verificationMethod"].(string)
+	if method == "" {
+		return fmt.Errorf("%w: verificationMethod missing", ErrProofInvalid)
+	}
+	if owner, _, _ := strings.Cut(method, "#"); controller != "" && owner != controller {
+		return fmt.Errorf("%w: verification method %s does not belong to %s", ErrProofInvalid, method, controller)
+	}
+
+	if expires, ok := proof["expires"].(string); ok {
+		t, err := time.Parse(time.RFC3339, expires)
+		if err != nil || time.Now().After(t.Add(verifier.leeway())) {
+			return fmt.Errorf("%w: proof expired", ErrProofInvalid)
+		}
+	}
+
+	if verifier.Keys == nil {
+		return fmt.Errorf("%w: no key resolver", ErrProofInvalid)
+	}
+	key, err := verifier.Keys(method)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProofInvalid, err)
+	}
+
+	loader := verifier.Loader
+	if loader == nil {
+		loader = NewDocumentLoader(nil)
+	}
+	data, err := hashData(document, proof, loader, suiteHash(suite, key))
+	if err != nil {
+		return err
+	}
+	return verifySignature(suite, proof, data, key)
+}
+
+func (verifier *Verifier) leeway() time.Duration {
+	if verifier.Leeway > 0 {
+		return verifier.Leeway
+	}
+	return config.DefaultLeeway
+}
@@ -0,0 +1,248 @@
+package ldp
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+)
+
+const testCredential = `{
+	"@context": ["https://www.w3.org/ns/credentials/v2", "https://www.w3.org/ns/credentials/examples/v2"],
+	"id": "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+	"type": ["VerifiableCredential", "AlumniCredential"],
+	"name": "Alumni Credential",
+	"issuer": "did:example:issuer",
+	"validFrom": "2023-01-01T00:00:00Z",
+	"credentialSubject": {
+		"id": "did:example:subject",
+		"alumniOf": "The School of Examples"
+	}
+}`
+
+func testDocument(t *testing.T, issuer string) map[string]interface{} {
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(testCredential), &document); err != nil {
+		t.Fatal(err)
+	}
+	document["issuer"] = issuer
+	return document
+}
+
+// testDidKey returns a did:key verification method of the private key.
+func testDidKey(t *testing.T, private interface{}) (string, jwk.Key) {
+	key, err := jwk.FromRaw(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, _ := key.PublicKey()
+	multikey, err := did.EncodeMultikey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "did:key:" + multikey + "#" + multikey, key
+}
+
+// testSign adds a proof of the suite, the signature is created as described by the suite specifications.
+func testSign(t *testing.T, document map[string]interface{}, proof map[string]interface{}, key jwk.Key) {
+	public, _ := key.PublicKey()
+	suite := Suite(proof)
+	data, err := hashData(document, proof, NewDocumentLoader(nil), suiteHash(suite, public))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		t.Fatal(err)
+	}
+
+	switch suite {
+	case Ed25519Signature2020, EddsaRdfc2022:
+		proof["proofValue"] = did.EncodeMultibase(ed25519.Sign(raw.(ed25519.PrivateKey), data))
+	case EcdsaRdfc2019:
+		h := suiteHash(suite, public).New()
+		h.Write(data)
+		r, s, err := ecdsa.Sign(rand.Reader, raw.(*ecdsa.PrivateKey), h.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		proof["proofValue"] = did.EncodeMultibase(signature)
+	default:
+		alg := jwa.ES256
+		if suite == Ed25519Signature2018 {
+			alg = jwa.EdDSA
+		}
+		headers := jws.NewHeaders()
+		_ = headers.Set("b64", false)
+		_ = headers.Set(jws.CriticalKey, []string{"b64"})
+		compact, err := jws.Sign(nil, jws.WithKey(alg, key, jws.WithProtectedHeaders(headers)), jws.WithDetachedPayload(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof["jws"] = string(compact)
+	}
+	document["proof"] = proof
+}
+
+func TestVerifyCredentialSuites(t *testing.T) {
+	_, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	ecPrivate, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		name    string
+		private interface{}
+		proof   map[string]interface{}
+	}{
+		{"eddsa-rdfc-2022", edPrivate, map[string]interface{}{"type": DataIntegrityProof, "cryptosuite": EddsaRdfc2022}},
+		{"ecdsa-rdfc-2019", ecPrivate, map[string]interface{}{"type": DataIntegrityProof, "cryptosuite": EcdsaRdfc2019}},
+		{"Ed25519Signature2020", edPrivate, map[string]interface{}{"type": Ed25519Signature2020}},
+		{"Ed25519Signature2018", edPrivate, map[string]interface{}{"type": Ed25519Signature2018}},
+		{"JsonWebSignature2020", ecPrivate, map[string]interface{}{"type": JsonWebSignature2020}},
+	}
+
+	for _, test := range tests {
+		method, key := testDidKey(t, test.private)
+		issuer, _, _ := strings.Cut(method, "#")
+
+		document := testDocument(t, issuer)
+		switch test.name {
+		case "Ed25519Signature2020":
+			document["@context"] = append(document["@context"].([]interface{}), "https://w3id.org/security/suites/ed25519-2020/v1")
+		case "Ed25519Signature2018":
+			document["@context"] = append(document["@context"].([]interface{}), "https://w3id.org/security/suites/ed25519-2018/v1")
+		case "JsonWebSignature2020":
+			document["@context"] = append(document["@context"].([]interface{}), "https://w3id.org/security/suites/jws-2020/v1")
+		}
+
+		proof := test.proof
+		proof["created"] = "2024-01-01T00:00:00Z"
+		proof["proofPurpose"] = AssertionMethod
+		proof["verificationMethod"] = method
+		testSign(t, document, proof, key)
+
+		credential, err := NewVerifier(nil).VerifyCredential(document)
+		if err != nil {
+			t.Error(test.name, err)
+			continue
+		}
+		if credential.Issuer != issuer || credential.Subject != "did:example:subject" {
+			t.Error(test.name, credential)
+		}
+
+		document["credentialSubject"].(map[string]interface{})["alumniOf"] = "Another School"
+		if _, err := NewVerifier(nil).VerifyCredential(document); !errors.Is(err, ErrProofInvalid) {
+			t.Error(test.name, "modified credential accepted", err)
+		}
+	}
+}
+
+func TestVerifyCredentialController(t *testing.T) {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	method, key := testDidKey(t, private)
+
+	document := testDocument(t, "did:example:issuer")
+	testSign(t, document, map[string]interface{}{
+		"type":               DataIntegrityProof,
+		"cryptosuite":        EddsaRdfc2022,
+		"proofPurpose":       AssertionMethod,
+		"verificationMethod": method,
+	}, key)
+
+	if _, err := NewVerifier(nil).VerifyCredential(document); !errors.Is(err, ErrProofInvalid) {
+		t.Error("key of another controller accepted", err)
+	}
+}
+
+func TestVerifyPresentation(t *testing.T) {
+	_, issuerPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	_, holderPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	issuerMethod, issuerKey := testDidKey(t, issuerPrivate)
+	holderMethod, holderKey := testDidKey(t, holderPrivate)
+	issuer, _, _ := strings.Cut(issuerMethod, "#")
+	holder, _, _ := strings.Cut(holderMethod, "#")
+
+	credential := testDocument(t, issuer)
+	testSign(t, credential, map[string]interface{}{
+		"type":               DataIntegrityProof,
+		"cryptosuite":        EddsaRdfc2022,
+		"proofPurpose":       AssertionMethod,
+		"verificationMethod": issuerMethod,
+	}, issuerKey)
+
+	presentation := func(challenge string, holder string) map[string]interface{} {
+		vp := map[string]interface{}{
+			"@context":             []interface{}{"https://www.w3.org/ns/credentials/v2"},
+			"type":                 []interface{}{"VerifiablePresentation"},
+			"verifiableCredential": []interface{}{credential},
+		}
+		if holder != "" {
+			vp["holder"] = holder
+		}
+		testSign(t, vp, map[string]interface{}{
+			"type":               DataIntegrityProof,
+			"cryptosuite":        EddsaRdfc2022,
+			"proofPurpose":       Authentication,
+			"verificationMethod": holderMethod,
+			"challenge":          challenge,
+			"domain":             "x509_san_dns:verifier.example.org",
+		}, holderKey)
+		return vp
+	}
+
+	vp, credentials, err := NewVerifier(nil).VerifyPresentation(presentation("n-0S6_WzA2Mj", holder), "n-0S6_WzA2Mj", "x509_san_dns:verifier.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vp.Holder != holder || len(credentials) != 1 || credentials[0].Issuer != issuer {
+		t.Error(vp, credentials)
+	}
+
+	if _, _, err := NewVerifier(nil).VerifyPresentation(presentation("replayed", holder), "n-0S6_WzA2Mj", "x509_san_dns:verifier.example.org"); !errors.Is(err, ErrProofInvalid) {
+		t.Error(err)
+	}
+
+	if _, _, err := NewVerifier(nil).VerifyPresentation(presentation("n-0S6_WzA2Mj", ""), "n-0S6_WzA2Mj", "x509_san_dns:verifier.example.org"); !errors.Is(err, types.ErrInvalidPresentation) {
+		t.Error("presentation without holder accepted", err)
+	}
+}
+
+func TestCanonicalizeUndefinedTerm(t *testing.T) {
+	document := map[string]interface{}{
+		"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1"},
+		"type":              []interface{}{"VerifiableCredential"},
+		"issuer":            "did:example:issuer",
+		"issuanceDate":      "2024-01-01T00:00:00Z",
+		"credentialSubject": map[string]interface{}{"unsigned": "value"},
+	}
+	if _, err := Canonicalize(document, NewDocumentLoader(nil)); err == nil {
+		t.Error("undefined terms must be rejected")
+	}
+
+	document["@context"] = append(document["@context"].([]interface{}), "https://www.w3.org/2018/credentials/examples/v1")
+	nquads, err := Canonicalize(document, NewDocumentLoader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(nquads, "<https://www.w3.org/2018/credentials/examples#unsigned> \"value\"") {
+		t.Error(nquads)
+	}
+
+	if _, err := Canonicalize(map[string]interface{}{"@context": "https://example.org/unknown"}, NewDocumentLoader(nil)); err == nil {
+		t.Error("unknown contexts must not be fetched")
+	}
+}
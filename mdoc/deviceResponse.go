@@ -0,0 +1,269 @@
+package mdoc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/veraison/go-cose"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+const (
+	DeviceResponseVersion = "1.0"
+	// StatusOK is the status of a device response without errors.
+	StatusOK uint64 = 0
+)
+
+var ErrDeviceAuthInvalid = errors.New("mdoc device authentication invalid")
+
+// DeviceResponse is the response of the holder device, in OID4VP it is the base64url encoded vp_token.
+type DeviceResponse struct {
+	Version   string             `cbor:"version"`
+	Documents []ResponseDocument `cbor:"documents,omitempty"`
+	Status    uint64             `cbor:"status"`
+}
+
+// ResponseDocument is the Document of a device response: the disclosed issuer signed elements and the device authentication.
+type ResponseDocument struct {
+	DocType      string       `cbor:"docType"`
+	IssuerSigned IssuerSigned `cbor:"issuerSigned"`
+	DeviceSigned DeviceSigned `cbor:"deviceSigned"`
+}
+
+type DeviceSigned struct {
+	// NameSpaces contains the DeviceNameSpacesBytes, device signed elements are not supported and it is always empty.
+	NameSpaces cbor.RawMessage `cbor:"nameSpaces"`
+	DeviceAuth DeviceAuth      `cbor:"deviceAuth"`
+}
+
+// DeviceAuth authenticates the device by a signature over the DeviceAuthentication, MACs are not supported.
+type DeviceAuth struct {
+	DeviceSignature *cose.UntaggedSign1Message `cbor:"deviceSignature,omitempty"`
+	DeviceMac       cbor.RawMessage            `cbor:"deviceMac,omitempty"`
+}
+
+func NewDeviceResponse(documents ...ResponseDocument) *DeviceResponse {
+	return &DeviceResponse{
+		Version:   DeviceResponseVersion,
+		Documents: documents,
+		Status:    StatusOK,
+	}
+}
+
+// ParseDeviceResponse decodes the base64url encoded device response of a vp_token.
+func ParseDeviceResponse(encoded string) (*DeviceResponse, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var response DeviceResponse
+	if err := decMode.Unmarshal(b, &response); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return &response, nil
+}
+
+// Encode returns the CBOR encoding.
+func (response *DeviceResponse) Encode() ([]byte, error) {
+	return encMode.Marshal(response)
+}
+
+// String returns the base64url encoding used as vp_token.
+func (response *DeviceResponse) String() string {
+	b, err := response.Encode()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+/*
+Disclose returns a copy which contains only the data elements of the paths. A path is [namespace, element identifier],
+a path with only the namespace discloses all of its elements. Fails with oauth.ErrClaimNotFound for missing elements.
+*/
+func (issuerSigned *IssuerSigned) Disclose(paths ...oauth.ClaimPath) (*IssuerSigned, error) {
+	items, err := issuerSigned.Items()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]map[int]bool)
+	for _, path := range paths {
+		if len(path) == 0 {
+			return nil, errors.New("empty mdoc claim path")
+		}
+		namespace, ok := path[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid mdoc claim path %s", path)
+		}
+		if selected[namespace] == nil {
+			selected[namespace] = make(map[int]bool)
+		}
+
+		found := false
+		for i, item := range items[namespace] {
+			if len(path) == 1 || item.ElementIdentifier == path[1] {
+				selected[namespace][i] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", oauth.ErrClaimNotFound, path)
+		}
+	}
+
+	disclosed := IssuerSigned{
+		NameSpaces: make(map[string][]cbor.RawMessage, len(selected)),
+		IssuerAuth: issuerSigned.IssuerAuth,
+	}
+	for namespace, indices := range selected {
+		sorted := make([]int, 0, len(indices))
+		for i := range indices {
+			sorted = append(sorted, i)
+		}
+		sort.Ints(sorted)
+
+		for _, i := range sorted {
+			disclosed.NameSpaces[namespace] = append(disclosed.NameSpaces[namespace], issuerSigned.NameSpaces[namespace][i])
+		}
+	}
+	return &disclosed, nil
+}
+
+/*
+Present creates the document of a device response and signs the DeviceAuthentication over the session transcript with
+the device key. The signer must hold the device key of the mobile security object.
+*/
+func Present(issuerSigned *IssuerSigned, signer signing.Signer, sessionTranscript []byte) (*ResponseDocument, error) {
+	if signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+
+	mso, err := issuerSigned.MobileSecurityObject()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDevice(mso, signer.PublicKey()); err != nil {
+		return nil, err
+	}
+
+	nameSpaces, err := encodeEmbedded(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := deviceAuthentication(sessionTranscript, mso.DocType, nameSpaces)
+	if err != nil {
+		return nil, err
+	}
+
+	coseSigner, err := coseSigner(signer)
+	if err != nil {
+		return nil, err
+	}
+	signature := cose.UntaggedSign1Message{
+		Headers: cose.Headers{Protected: cose.ProtectedHeader{cose.HeaderLabelAlgorithm: coseSigner.Algorithm()}},
+		Payload: payload,
+	}
+	if err := signature.Sign(rand.Reader, nil, coseSigner); err != nil {
+		return nil, fmt.Errorf("can not sign device authentication: %w", err)
+	}
+	// the payload is detached, the verifier reconstructs it from the session transcript
+	signature.Payload = nil
+
+	return &ResponseDocument{
+		DocType:      mso.DocType,
+		IssuerSigned: *issuerSigned,
+		DeviceSigned: DeviceSigned{
+			NameSpaces: nameSpaces,
+			DeviceAuth: DeviceAuth{DeviceSignature: &signature},
+		},
+	}, nil
+}
+
+/*
+VerifyDocument checks the issuer signed part of the document and the device signature over the session transcript,
+it returns the mobile security object.
+*/
+func (verifier *Verifier) VerifyDocument(document *ResponseDocument, sessionTranscript []byte) (*MobileSecurityObject, error) {
+	mso, err := verifier.Verify(&document.IssuerSigned)
+	if err != nil {
+		return nil, err
+	}
+	if document.DocType != mso.DocType {
+		return nil, fmt.Errorf("%w: doctype %s does not match %s", ErrMalformed, document.DocType, mso.DocType)
+	}
+
+	signature := document.DeviceSigned.DeviceAuth.DeviceSignature
+	if signature == nil {
+		return nil, fmt.Errorf("%w: device signature missing", ErrDeviceAuthInvalid)
+	}
+
+	payload, err := deviceAuthentication(sessionTranscript, mso.DocType, document.DeviceSigned.NameSpaces)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := mso.DevicePublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceKeyInvalid, err)
+	}
+	algorithm, err := signature.Headers.Protected.Algorithm()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceAuthInvalid, err)
+	}
+	coseVerifier, err := cose.NewVerifier(algorithm, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceAuthInvalid, err)
+	}
+
+	detached := *signature
+	detached.Payload = payload
+	if err := detached.Verify(nil, coseVerifier); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceAuthInvalid, err)
+	}
+	return mso, nil
+}
+
+// deviceAuthentication returns the DeviceAuthenticationBytes, the detached payload of the device signature.
+func deviceAuthentication(sessionTranscript []byte, docType string, nameSpaces cbor.RawMessage) ([]byte, error) {
+	if len(sessionTranscript) == 0 {
+		return nil, errors.New("session transcript missing")
+	}
+	if len(nameSpaces) == 0 {
+		return nil, fmt.Errorf("%w: device namespaces missing", ErrMalformed)
+	}
+	return encodeEmbedded([]interface{}{"DeviceAuthentication", cbor.RawMessage(sessionTranscript), docType, nameSpaces})
+}
+
+// checkDevice compares the key of the signer with the device key of the mobile security object.
+func checkDevice(mso *MobileSecurityObject, key jwk.Key) error {
+	if key == nil {
+		return nil
+	}
+
+	device, err := mso.DevicePublicKey()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDeviceKeyInvalid, err)
+	}
+	raw, err := jwk.PublicRawKeyOf(key)
+	if err != nil {
+		return err
+	}
+
+	comparable, ok := device.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !comparable.Equal(raw) {
+		return errors.New("signer does not hold the device key")
+	}
+	return nil
+}
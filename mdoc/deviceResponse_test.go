@@ -0,0 +1,101 @@
+package mdoc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+// testTranscript is an arbitrary CBOR array, the session transcript is opaque to the device response.
+var testTranscript = []byte{0x83, 0xf6, 0xf6, 0x61, 0x61}
+
+func testDevice(t *testing.T) (Document, signing.Signer) {
+	device := testKey(t)
+	signer, err := signing.NewJwkSigner(device)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := testDocument(t)
+	document.DeviceKey, _ = device.PublicKey()
+	return document, signer
+}
+
+func TestDeviceResponse(t *testing.T) {
+	issuer, roots := testIssuer(t)
+	document, device := testDevice(t)
+
+	issued, err := issuer.Issue(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disclosed, err := issued.Disclose(oauth.ClaimPath{testNameSpace, "family_name"}, oauth.ClaimPath{testNameSpace, "age_over_18"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	presented, err := Present(disclosed, device, testTranscript)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ParseDeviceResponse(NewDeviceResponse(*presented).String())
+	if err != nil || len(response.Documents) != 1 {
+		t.Fatal(err)
+	}
+
+	mso, err := NewVerifier(roots).VerifyDocument(&response.Documents[0], testTranscript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mso.DocType != testDocType {
+		t.Error()
+	}
+
+	claims, err := response.Documents[0].IssuerSigned.Claims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := claims[testNameSpace].(map[string]interface{})
+	if len(elements) != 2 || elements["family_name"] != "Mustermann" {
+		t.Error("only the selected elements should be disclosed", elements)
+	}
+
+	if _, err := NewVerifier(roots).VerifyDocument(&response.Documents[0], []byte{0x80}); !errors.Is(err, ErrDeviceAuthInvalid) {
+		t.Error("session transcript of another request must be rejected", err)
+	}
+}
+
+func TestPresentRequiresDeviceKey(t *testing.T) {
+	issuer, _ := testIssuer(t)
+	document, _ := testDevice(t)
+
+	issued, err := issuer.Issue(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := signing.NewJwkSigner(testKey(t))
+	if _, err := Present(issued, other, testTranscript); err == nil {
+		t.Error("signer without device key accepted")
+	}
+}
+
+func TestDiscloseMissingElement(t *testing.T) {
+	issuer, _ := testIssuer(t)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := issued.Disclose(oauth.ClaimPath{testNameSpace, "resident_city"}); !errors.Is(err, oauth.ErrClaimNotFound) {
+		t.Error(err)
+	}
+	if _, err := issued.Disclose(oauth.ClaimPath{testNameSpace}); err != nil {
+		t.Error(err)
+	}
+}
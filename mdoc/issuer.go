@@ -0,0 +1,234 @@
+package mdoc
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/veraison/go-cose"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+// Document holds the content of an mdoc to issue.
+type Document struct {
+	DocType string
+	// NameSpaces maps namespace and element identifier to the element value.
+	NameSpaces map[string]map[string]interface{}
+	// DeviceKey is the public key of the holder device.
+	DeviceKey jwk.Key
+	// Signed defaults to now, ValidFrom defaults to Signed
+	Signed     time.Time
+	ValidFrom  time.Time
+	ValidUntil time.Time
+}
+
+/*
+Issuer creates mdocs. Certificates is the document signer certificate chain (leaf first) for the x5chain header, the
+leaf must certify the public key of Signer.
+*/
+type Issuer struct {
+	Signer       signing.Signer
+	Certificates []*x509.Certificate
+	// DigestAlgorithm of the value digests, defaults to SHA-256
+	DigestAlgorithm string
+}
+
+func NewIssuer(signer signing.Signer, certificates ...*x509.Certificate) *Issuer {
+	return &Issuer{
+		Signer:       signer,
+		Certificates: certificates,
+	}
+}
+
+// Issue signs the mobile security object over all data elements of the document.
+func (issuer *Issuer) Issue(document Document) (*IssuerSigned, error) {
+	if issuer.Signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	if len(issuer.Certificates) == 0 {
+		return nil, errors.New("document signer certificate missing")
+	}
+	if document.DocType == "" {
+		return nil, errors.New("doctype missing")
+	}
+	if document.DeviceKey == nil {
+		return nil, errors.New("device key missing")
+	}
+	if document.ValidUntil.IsZero() {
+		return nil, errors.New("validUntil missing")
+	}
+
+	algorithm := issuer.DigestAlgorithm
+	if algorithm == "" {
+		algorithm = DefaultDigestAlgorithm
+	}
+
+	raw, err := jwk.PublicRawKeyOf(document.DeviceKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device key: %w", err)
+	}
+	deviceKey, err := cose.NewKeyFromPublic(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid device key: %w", err)
+	}
+
+	signed := document.Signed
+	if signed.IsZero() {
+		signed = time.Now()
+	}
+	validFrom := document.ValidFrom
+	if validFrom.IsZero() {
+		validFrom = signed
+	}
+
+	mso := MobileSecurityObject{
+		Version:         "1.0",
+		DigestAlgorithm: algorithm,
+		ValueDigests:    make(map[string]map[uint64][]byte, len(document.NameSpaces)),
+		DeviceKeyInfo:   DeviceKeyInfo{DeviceKey: *deviceKey},
+		DocType:         document.DocType,
+		ValidityInfo: ValidityInfo{
+			Signed:     signed.UTC().Truncate(time.Second),
+			ValidFrom:  validFrom.UTC().Truncate(time.Second),
+			ValidUntil: document.ValidUntil.UTC().Truncate(time.Second),
+		},
+	}
+
+	issuerSigned := IssuerSigned{NameSpaces: make(map[string][]cbor.RawMessage, len(document.NameSpaces))}
+	for namespace, elements := range document.NameSpaces {
+		items, digests, err := signedItems(algorithm, elements)
+		if err != nil {
+			return nil, fmt.Errorf("can not encode namespace %s: %w", namespace, err)
+		}
+		issuerSigned.NameSpaces[namespace] = items
+		mso.ValueDigests[namespace] = digests
+	}
+
+	payload, err := encodeEmbedded(mso)
+	if err != nil {
+		return nil, fmt.Errorf("can not encode mobile security object: %w", err)
+	}
+
+	signer, err := coseSigner(issuer.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([]interface{}, 0, len(issuer.Certificates))
+	for _, certificate := range issuer.Certificates {
+		chain = append(chain, certificate.Raw)
+	}
+	var x5chain interface{} = chain
+	if len(chain) == 1 {
+		x5chain = chain[0]
+	}
+
+	issuerSigned.IssuerAuth = cose.UntaggedSign1Message{
+		Headers: cose.Headers{
+			Protected:   cose.ProtectedHeader{cose.HeaderLabelAlgorithm: signer.Algorithm()},
+			Unprotected: cose.UnprotectedHeader{cose.HeaderLabelX5Chain: x5chain},
+		},
+		Payload: payload,
+	}
+	if err := issuerSigned.IssuerAuth.Sign(rand.Reader, nil, signer); err != nil {
+		return nil, fmt.Errorf("can not sign mobile security object: %w", err)
+	}
+	return &issuerSigned, nil
+}
+
+// signedItems salts the elements and assigns random digest ids, so that the ids do not reveal the element order.
+func signedItems(algorithm string, elements map[string]interface{}) ([]cbor.RawMessage, map[uint64][]byte, error) {
+	names := make([]string, 0, len(elements))
+	for name := range elements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ids, err := permutation(len(names))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]cbor.RawMessage, 0, len(names))
+	digests := make(map[uint64][]byte, len(names))
+	for i, name := range names {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+
+		item, err := encodeEmbedded(IssuerSignedItem{
+			DigestID:          ids[i],
+			Random:            random,
+			ElementIdentifier: name,
+			ElementValue:      elements[name],
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		digest, err := Digest(algorithm, item)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+		digests[ids[i]] = digest
+	}
+	return items, digests, nil
+}
+
+func permutation(n int) ([]uint64, error) {
+	ids := make([]uint64, n)
+	for i := range ids {
+		ids[i] = uint64(i)
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i)+1))
+		if err != nil {
+			return nil, err
+		}
+		ids[i], ids[j.Int64()] = ids[j.Int64()], ids[i]
+	}
+	return ids, nil
+}
+
+var coseAlgorithms = map[jwa.SignatureAlgorithm]cose.Algorithm{
+	jwa.ES256: cose.AlgorithmES256,
+	jwa.ES384: cose.AlgorithmES384,
+	jwa.ES512: cose.AlgorithmES512,
+	jwa.PS256: cose.AlgorithmPS256,
+	jwa.PS384: cose.AlgorithmPS384,
+	jwa.PS512: cose.AlgorithmPS512,
+	jwa.EdDSA: cose.AlgorithmEdDSA,
+}
+
+// signerAdapter signs COSE structures with a signing.Signer, JWS and COSE share the signature format.
+type signerAdapter struct {
+	signer    signing.Signer
+	algorithm cose.Algorithm
+}
+
+func coseSigner(signer signing.Signer) (cose.Signer, error) {
+	algorithm, ok := coseAlgorithms[signer.Algorithm()]
+	if !ok {
+		return nil, fmt.Errorf("algorithm %s not supported for cose", signer.Algorithm())
+	}
+	return &signerAdapter{signer: signer, algorithm: algorithm}, nil
+}
+
+func (s *signerAdapter) Algorithm() cose.Algorithm {
+	return s.algorithm
+}
+
+func (s *signerAdapter) Sign(_ io.Reader, content []byte) ([]byte, error) {
+	return s.signer.Sign(content)
+}
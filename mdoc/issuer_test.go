@@ -0,0 +1,148 @@
+package mdoc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+const (
+	testDocType   = "org.iso.18013.5.1.mDL"
+	testNameSpace = "org.iso.18013.5.1"
+)
+
+func testKey(t *testing.T) jwk.Key {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testIssuer creates an issuer with a self signed document signer certificate and the pool trusting it.
+func testIssuer(t *testing.T) (*Issuer, *x509.CertPool) {
+	return testIssuerWithUsages(t, []asn1.ObjectIdentifier{DocumentSignerKeyUsage})
+}
+
+// testIssuerWithUsages creates an issuer with a self signed certificate with the extended key usages.
+func testIssuerWithUsages(t *testing.T, usages []asn1.ObjectIdentifier) (*Issuer, *x509.CertPool) {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "document signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		UnknownExtKeyUsage:    usages,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &raw.PublicKey, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIssuer(signer, certificate), roots
+}
+
+func testDocument(t *testing.T) Document {
+	return Document{
+		DocType: testDocType,
+		NameSpaces: map[string]map[string]interface{}{
+			testNameSpace: {
+				"family_name":        "Mustermann",
+				"given_name":         "Erika",
+				"birth_date":         cbor.Tag{Number: 1004, Content: "1964-08-12"},
+				"age_over_18":        true,
+				"driving_privileges": []interface{}{map[string]interface{}{"vehicle_category_code": "B"}},
+				"portrait":           []byte{0xff, 0xd8},
+			},
+		},
+		DeviceKey:  testKey(t),
+		ValidUntil: time.Now().Add(time.Hour),
+	}
+}
+
+func TestIssue(t *testing.T) {
+	issuer, _ := testIssuer(t)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseIssuerSigned(issued.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mso, err := parsed.MobileSecurityObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mso.DocType != testDocType || mso.DigestAlgorithm != DefaultDigestAlgorithm {
+		t.Error("unexpected mobile security object")
+	}
+	if len(mso.ValueDigests[testNameSpace]) != 6 || len(parsed.NameSpaces[testNameSpace]) != 6 {
+		t.Error("every data element needs a digest")
+	}
+	if _, err := mso.DevicePublicKey(); err != nil {
+		t.Error(err)
+	}
+
+	claims, err := parsed.Claims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements, _ := claims[testNameSpace].(map[string]interface{})
+	if elements["family_name"] != "Mustermann" || elements["age_over_18"] != true {
+		t.Error("unexpected claims")
+	}
+	if elements["birth_date"] != "1964-08-12" || elements["portrait"] != "_9g" {
+		t.Error("tags and byte strings should be converted")
+	}
+	privileges, _ := elements["driving_privileges"].([]interface{})
+	if len(privileges) != 1 || privileges[0].(map[string]interface{})["vehicle_category_code"] != "B" {
+		t.Error("nested maps should be converted")
+	}
+}
+
+func TestIssueRequiresDeviceKey(t *testing.T) {
+	issuer, _ := testIssuer(t)
+
+	document := testDocument(t)
+	document.DeviceKey = nil
+	if _, err := issuer.Issue(document); err == nil {
+		t.Error("device key is required")
+	}
+}
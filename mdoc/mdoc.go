@@ -0,0 +1,243 @@
+// https://www.iso.org/standard/69084.html (ISO/IEC 18013-5)
+package mdoc
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/veraison/go-cose"
+)
+
+const (
+	// Format is the credential format identifier of mdocs.
+	Format = "mso_mdoc"
+	// DefaultDigestAlgorithm of the value digests of the mobile security object.
+	DefaultDigestAlgorithm = "SHA-256"
+	// encodedCborTag marks a byte string which contains an embedded CBOR data item (#6.24).
+	encodedCborTag = 24
+)
+
+var ErrMalformed = errors.New("malformed mdoc")
+
+var (
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+)
+
+func init() {
+	options := cbor.CoreDetEncOptions()
+	// tdate of the validity info, ISO 18013-5 forbids fractional seconds
+	options.Time = cbor.TimeRFC3339
+	options.TimeTag = cbor.EncTagRequired
+
+	var err error
+	if encMode, err = options.EncMode(); err != nil {
+		panic(err)
+	}
+	if decMode, err = (cbor.DecOptions{}).DecMode(); err != nil {
+		panic(err)
+	}
+}
+
+// IssuerSigned holds the issuer signed data elements of an mdoc, it is the credential of the mso_mdoc format.
+type IssuerSigned struct {
+	// NameSpaces contains the IssuerSignedItemBytes, the raw encoding is kept as it is the input of the value digests.
+	NameSpaces map[string][]cbor.RawMessage `cbor:"nameSpaces,omitempty"`
+	IssuerAuth cose.UntaggedSign1Message    `cbor:"issuerAuth"`
+}
+
+// IssuerSignedItem is a single data element of a namespace.
+type IssuerSignedItem struct {
+	DigestID          uint64      `cbor:"digestID"`
+	Random            []byte      `cbor:"random"`
+	ElementIdentifier string      `cbor:"elementIdentifier"`
+	ElementValue      interface{} `cbor:"elementValue"`
+}
+
+// MobileSecurityObject is the payload of the issuer signature, it binds the data elements by their digests.
+type MobileSecurityObject struct {
+	Version         string                       `cbor:"version"`
+	DigestAlgorithm string                       `cbor:"digestAlgorithm"`
+	ValueDigests    map[string]map[uint64][]byte `cbor:"valueDigests"`
+	DeviceKeyInfo   DeviceKeyInfo                `cbor:"deviceKeyInfo"`
+	DocType         string                       `cbor:"docType"`
+	ValidityInfo    ValidityInfo                 `cbor:"validityInfo"`
+}
+
+type DeviceKeyInfo struct {
+	DeviceKey         cose.Key               `cbor:"deviceKey"`
+	KeyAuthorizations map[string]interface{} `cbor:"keyAuthorizations,omitempty"`
+	KeyInfo           map[int64]interface{}  `cbor:"keyInfo,omitempty"`
+}
+
+type ValidityInfo struct {
+	Signed         time.Time  `cbor:"signed"`
+	ValidFrom      time.Time  `cbor:"validFrom"`
+	ValidUntil     time.Time  `cbor:"validUntil"`
+	ExpectedUpdate *time.Time `cbor:"expectedUpdate,omitempty"`
+}
+
+// ParseIssuerSigned decodes the base64url encoded IssuerSigned of a credential response.
+func ParseIssuerSigned(encoded string) (*IssuerSigned, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return DecodeIssuerSigned(b)
+}
+
+// DecodeIssuerSigned decodes the CBOR encoded IssuerSigned structure.
+func DecodeIssuerSigned(b []byte) (*IssuerSigned, error) {
+	var issuerSigned IssuerSigned
+	if err := decMode.Unmarshal(b, &issuerSigned); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	return &issuerSigned, nil
+}
+
+// Encode returns the CBOR encoding.
+func (issuerSigned *IssuerSigned) Encode() ([]byte, error) {
+	return encMode.Marshal(issuerSigned)
+}
+
+// String returns the base64url encoding used in credential responses.
+func (issuerSigned *IssuerSigned) String() string {
+	b, err := issuerSigned.Encode()
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// MobileSecurityObject decodes the payload of the issuer signature without verifying it.
+func (issuerSigned *IssuerSigned) MobileSecurityObject() (*MobileSecurityObject, error) {
+	var mso MobileSecurityObject
+	if err := decodeEmbedded(issuerSigned.IssuerAuth.Payload, &mso); err != nil {
+		return nil, fmt.Errorf("%w: mobile security object: %w", ErrMalformed, err)
+	}
+	return &mso, nil
+}
+
+// Items decodes the data elements of all namespaces.
+func (issuerSigned *IssuerSigned) Items() (map[string][]*IssuerSignedItem, error) {
+	items := make(map[string][]*IssuerSignedItem, len(issuerSigned.NameSpaces))
+	for namespace, encoded := range issuerSigned.NameSpaces {
+		for _, raw := range encoded {
+			var item IssuerSignedItem
+			if err := decodeEmbedded(raw, &item); err != nil {
+				return nil, fmt.Errorf("%w: data element of %s: %w", ErrMalformed, namespace, err)
+			}
+			items[namespace] = append(items[namespace], &item)
+		}
+	}
+	return items, nil
+}
+
+/*
+Claims returns the data elements as {namespace: {element identifier: value}}. Values are converted into their json
+representation: byte strings become base64url strings, dates RFC 3339 strings and tags their content.
+*/
+func (issuerSigned *IssuerSigned) Claims() (map[string]interface{}, error) {
+	items, err := issuerSigned.Items()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := make(map[string]interface{}, len(items))
+	for namespace, elements := range items {
+		values := make(map[string]interface{}, len(elements))
+		for _, item := range elements {
+			values[item.ElementIdentifier] = jsonValue(item.ElementValue)
+		}
+		claims[namespace] = values
+	}
+	return claims, nil
+}
+
+// DevicePublicKey returns the public device key, which authenticates the holder in presentations.
+func (mso *MobileSecurityObject) DevicePublicKey() (crypto.PublicKey, error) {
+	key := mso.DeviceKeyInfo.DeviceKey
+	if _, _, _, d := key.EC2(); len(d) > 0 {
+		return nil, errors.New("device key contains a private key")
+	}
+	if _, _, d := key.OKP(); len(d) > 0 {
+		return nil, errors.New("device key contains a private key")
+	}
+	return key.PublicKey()
+}
+
+// Digest hashes the data with the digest algorithm identifier of the mobile security object.
+func Digest(algorithm string, data []byte) ([]byte, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "SHA-256":
+		h = sha256.New()
+	case "SHA-384":
+		h = sha512.New384()
+	case "SHA-512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %s", algorithm)
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// decodeEmbedded decodes a #6.24 tagged byte string into v.
+func decodeEmbedded(data []byte, v interface{}) error {
+	var tag cbor.RawTag
+	if err := decMode.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	if tag.Number != encodedCborTag {
+		return fmt.Errorf("unexpected tag %d", tag.Number)
+	}
+
+	var b []byte
+	if err := decMode.Unmarshal(tag.Content, &b); err != nil {
+		return err
+	}
+	return decMode.Unmarshal(b, v)
+}
+
+// encodeEmbedded encodes v as #6.24 tagged byte string.
+func encodeEmbedded(v interface{}) ([]byte, error) {
+	b, err := encMode.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(cbor.Tag{Number: encodedCborTag, Content: b})
+}
+
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			converted[fmt.Sprint(key)] = jsonValue(element)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, element := range v {
+			converted[i] = jsonValue(element)
+		}
+		return converted
+	case []byte:
+		return base64.RawURLEncoding.EncodeToString(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case cbor.Tag:
+		// e.g. full-date (1004) of birth_date
+		return jsonValue(v.Content)
+	}
+	return value
+}
@@ -0,0 +1,229 @@
+package mdoc
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/veraison/go-cose"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+)
+
+var (
+	ErrSignatureInvalid = errors.New("mdoc issuer signature invalid")
+	ErrDigestInvalid    = errors.New("mdoc value digest invalid")
+	ErrDeviceKeyInvalid = errors.New("mdoc device key invalid")
+	ErrExpired          = errors.New("mdoc expired")
+	ErrRootsMissing     = errors.New("no trusted issuing authority certificates")
+)
+
+// DocumentSignerKeyUsage is the extended key usage of mdoc document signer certificates (ISO 18013-5 Annex B).
+var DocumentSignerKeyUsage = asn1.ObjectIdentifier{1, 0, 18013, 5, 1, 2}
+
+/*
+Verifier checks the issuer signed part of mdocs: the document signer certificate chain and signature, the value
+digests of all data elements, the validity info and the device key.
+*/
+type Verifier struct {
+	// Roots are the trusted issuing authority certificates, they are required.
+	Roots *x509.CertPool
+	// Leeway for clock differences, defaults to config.DefaultLeeway
+	Leeway time.Duration
+}
+
+func NewVerifier(roots *x509.CertPool) *Verifier {
+	return &Verifier{Roots: roots}
+}
+
+// Verify checks the mdoc and returns its mobile security object.
+func (verifier *Verifier) Verify(issuerSigned *IssuerSigned) (*MobileSecurityObject, error) {
+	if err := verifier.VerifySignature(issuerSigned); err != nil {
+		return nil, err
+	}
+
+	mso, err := issuerSigned.MobileSecurityObject()
+	if err != nil {
+		return nil, err
+	}
+	if mso.DocType == "" {
+		return nil, fmt.Errorf("%w: doctype missing", ErrMalformed)
+	}
+
+	if err := verifier.verifyValidity(mso); err != nil {
+		return nil, err
+	}
+	if err := issuerSigned.VerifyDigests(mso); err != nil {
+		return nil, err
+	}
+	if _, err := mso.DevicePublicKey(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceKeyInvalid, err)
+	}
+	return mso, nil
+}
+
+/*
+VerifySignature checks the issuer auth with the leaf of the x5chain, the chain must be valid for the roots and the
+leaf must be a document signer certificate.
+*/
+func (verifier *Verifier) VerifySignature(issuerSigned *IssuerSigned) error {
+	if verifier.Roots == nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, ErrRootsMissing)
+	}
+
+	certificates, err := issuerSigned.Certificates()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	leaf := certificates[0]
+	if !hasKeyUsage(leaf, DocumentSignerKeyUsage) {
+		return fmt.Errorf("%w: certificate %s is no document signer", ErrSignatureInvalid, leaf.Subject)
+	}
+
+	// x509 can not check the document signer usage along the chain, it is checked on the leaf above
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         verifier.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: document signer certificate chain invalid: %w", ErrSignatureInvalid, err)
+	}
+
+	algorithm, err := issuerSigned.IssuerAuth.Headers.Protected.Algorithm()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	coseVerifier, err := cose.NewVerifier(algorithm, leaf.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	if err := issuerSigned.IssuerAuth.Verify(nil, coseVerifier); err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	return nil
+}
+
+func hasKeyUsage(certificate *x509.Certificate, usage asn1.ObjectIdentifier) bool {
+	for _, unknown := range certificate.UnknownExtKeyUsage {
+		if unknown.Equal(usage) {
+			return true
+		}
+	}
+	return false
+}
+
+func (verifier *Verifier) verifyValidity(mso *MobileSecurityObject) error {
+	leeway := verifier.Leeway
+	if leeway <= 0 {
+		leeway = config.DefaultLeeway
+	}
+
+	now := time.Now()
+	validity := mso.ValidityInfo
+	if validity.ValidUntil.IsZero() || now.After(validity.ValidUntil.Add(leeway)) {
+		return ErrExpired
+	}
+	if now.Add(leeway).Before(validity.ValidFrom) {
+		return fmt.Errorf("%w: not yet valid", ErrSignatureInvalid)
+	}
+	if validity.ValidFrom.Before(validity.Signed) {
+		return fmt.Errorf("%w: validFrom before signed", ErrSignatureInvalid)
+	}
+	return nil
+}
+
+// VerifyDigests checks that every data element is bound to the mobile security object by its digest.
+func (issuerSigned *IssuerSigned) VerifyDigests(mso *MobileSecurityObject) error {
+	for namespace, encoded := range issuerSigned.NameSpaces {
+		digests, ok := mso.ValueDigests[namespace]
+		if !ok {
+			return fmt.Errorf("%w: namespace %s not signed", ErrDigestInvalid, namespace)
+		}
+
+		for _, raw := range encoded {
+			var item IssuerSignedItem
+			if err := decodeEmbedded(raw, &item); err != nil {
+				return fmt.Errorf("%w: data element of %s: %w", ErrMalformed, namespace, err)
+			}
+
+			expected, ok := digests[item.DigestID]
+			if !ok {
+				return fmt.Errorf("%w: no digest %d for %s", ErrDigestInvalid, item.DigestID, item.ElementIdentifier)
+			}
+			digest, err := Digest(mso.DigestAlgorithm, raw)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrDigestInvalid, err)
+			}
+			if !bytes.Equal(expected, digest) {
+				return fmt.Errorf("%w: %s of %s", ErrDigestInvalid, item.ElementIdentifier, namespace)
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Certificates returns the document signer certificate chain (leaf first) of the x5chain header, which ISO 18013-5
+places in the unprotected header. The chain is not verified.
+*/
+func (issuerSigned *IssuerSigned) Certificates() ([]*x509.Certificate, error) {
+	headers := issuerSigned.IssuerAuth.Headers
+	value := header(headers.Unprotected, cose.HeaderLabelX5Chain)
+	if value == nil {
+		value = header(headers.Protected, cose.HeaderLabelX5Chain)
+	}
+
+	var encoded [][]byte
+	switch v := value.(type) {
+	case []byte:
+		encoded = [][]byte{v}
+	case []interface{}:
+		for _, e := range v {
+			der, ok := e.([]byte)
+			if !ok {
+				return nil, errors.New("invalid x5chain entry")
+			}
+			encoded = append(encoded, der)
+		}
+	}
+	if len(encoded) == 0 {
+		return nil, errors.New("x5chain header missing")
+	}
+
+	certificates := make([]*x509.Certificate, 0, len(encoded))
+	for _, der := range encoded {
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x5chain certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// header looks up an integer label, decoded labels are int64 or uint64.
+func header(headers map[interface{}]interface{}, label int64) interface{} {
+	for key, value := range headers {
+		switch k := key.(type) {
+		case int64:
+			if k == label {
+				return value
+			}
+		case uint64:
+			if label >= 0 && k == uint64(label) {
+				return value
+			}
+		}
+	}
+	return nil
+}
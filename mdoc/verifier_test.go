@@ -0,0 +1,102 @@
+package mdoc
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestVerify(t *testing.T) {
+	issuer, roots := testIssuer(t)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseIssuerSigned(issued.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mso, err := NewVerifier(roots).Verify(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mso.DocType != testDocType {
+		t.Error("unexpected doctype")
+	}
+}
+
+func TestVerifyUntrustedIssuer(t *testing.T) {
+	issuer, _ := testIssuer(t)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewVerifier(x509.NewCertPool()).Verify(issued); !errors.Is(err, ErrSignatureInvalid) {
+		t.Error("document signer is not trusted")
+	}
+
+	if _, err := NewVerifier(nil).Verify(issued); !errors.Is(err, ErrRootsMissing) {
+		t.Error("roots are required", err)
+	}
+}
+
+func TestVerifyNoDocumentSigner(t *testing.T) {
+	issuer, roots := testIssuerWithUsages(t, nil)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewVerifier(roots).Verify(issued); !errors.Is(err, ErrSignatureInvalid) {
+		t.Error("certificate without document signer usage accepted", err)
+	}
+}
+
+func TestVerifyTamperedElement(t *testing.T) {
+	issuer, roots := testIssuer(t)
+
+	issued, err := issuer.Issue(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := issued.Items()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := items[testNameSpace][0]
+	item.ElementValue = "tampered"
+	tampered, err := encodeEmbedded(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued.NameSpaces[testNameSpace][0] = cbor.RawMessage(tampered)
+
+	if _, err := NewVerifier(roots).Verify(issued); !errors.Is(err, ErrDigestInvalid) {
+		t.Error("tampered element should be detected", err)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	issuer, roots := testIssuer(t)
+
+	document := testDocument(t)
+	document.Signed = time.Now().Add(-48 * time.Hour)
+	document.ValidUntil = time.Now().Add(-24 * time.Hour)
+	issued, err := issuer.Issue(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewVerifier(roots).Verify(issued); !errors.Is(err, ErrExpired) {
+		t.Error("expired mdoc should be rejected", err)
+	}
+}
@@ -0,0 +1,663 @@
+package mock
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/sdjwt"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+type Endpoint string
+
+const (
+	AuthorizationEndpoint      Endpoint = "/authorize"
+	TokenEndpoint              Endpoint = "/token"
+	CredentialEndpoint         Endpoint = "/credential"
+	DeferredCredentialEndpoint Endpoint = "/deferred_credential"
+	NotificationEndpoint       Endpoint = "/notification"
+	JwksEndpoint               Endpoint = "/jwks"
+)
+
+const (
+	SdJwtConfiguration = "SdJwtCredential"
+	JwtConfiguration   = "JwtCredential"
+)
+
+type grant struct {
+	ids           []string
+	txCode        string
+	codeChallenge string
+	redirectUri   string
+}
+
+type session struct {
+	ids    []string
+	cNonce string
+}
+
+type failure struct {
+	errorCode string
+	count     int
+}
+
+/*
+Issuer is an in-process credential issuer and authorization server for tests. It supports the pre-authorized code
+and authorization code grant, issues SD-JWT and JWT VCs signed with an ephemeral P-256 key and can be instructed
+to fail, defer or slow down requests.
+*/
+type Issuer struct {
+	*httptest.Server
+	Signer signing.Signer
+
+	mutex          sync.Mutex
+	configurations map[string]credential.CredentialConfiguration
+	claims         map[string]map[string]interface{}
+	grants         map[string]*grant
+	states         map[string][]string
+	sessions       map[string]*session
+	transactions   map[string]string
+	failures       map[Endpoint]*failure
+	delays         map[Endpoint]time.Duration
+	pending        int
+	pendingPolls   map[string]int
+	notifications  []credential.NotificationRequest
+	invalidClaims  bool
+	batch          int
+}
+
+// NewIssuer starts an issuer which offers an SD-JWT and a JWT VC configuration. Close it after the test.
+func NewIssuer() (*Issuer, error) {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+	key.Set(jwk.KeyIDKey, uuid.NewString())
+
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &Issuer{
+		Signer:         signer,
+		configurations: make(map[string]credential.CredentialConfiguration),
+		claims:         make(map[string]map[string]interface{}),
+		grants:         make(map[string]*grant),
+		states:         make(map[string][]string),
+		sessions:       make(map[string]*session),
+		transactions:   make(map[string]string),
+		failures:       make(map[Endpoint]*failure),
+		delays:         make(map[Endpoint]time.Duration),
+		pendingPolls:   make(map[string]int),
+	}
+
+	proofTypes := map[credential.ProofVariant]credential.ProofType{
+		credential.ProofTypeJWT: {ProofSigningAlgValuesSupported: []string{"ES256", "ES384", "EdDSA", "PS256"}},
+	}
+
+	vct := "https://credentials.example.com/identity_credential"
+	issuer.AddConfiguration(SdJwtConfiguration, credential.CredentialConfiguration{
+		Format:                               "vc+sd-jwt",
+		Vct:                                  &vct,
+		CryptographicBindingMethodsSupported: []string{"jwk"},
+		CredentialSigningAlgValuesSupported:  []string{"ES256"},
+		ProofTypesSupported:                  proofTypes,
+	}, map[string]interface{}{
+		"given_name":  "Erika",
+		"family_name": "Mustermann",
+		"birthdate":   "1964-08-12",
+	})
+
+	issuer.AddConfiguration(JwtConfiguration, credential.CredentialConfiguration{
+		Format:                               "jwt_vc_json",
+		CryptographicBindingMethodsSupported: []string{"jwk"},
+		CredentialSigningAlgValuesSupported:  []string{"ES256"},
+		ProofTypesSupported:                  proofTypes,
+		CredentialDefinition: credential.CredentialDefinition{
+			Context: []string{"https://www.w3.org/2018/credentials/v1"},
+			Type:    []string{"VerifiableCredential", "UniversityDegreeCredential"},
+		},
+	}, map[string]interface{}{
+		"degree": map[string]interface{}{
+			"type": "BachelorDegree",
+			"name": "Bachelor of Science and Arts",
+		},
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-credential-issuer", issuer.handle("", issuer.metadata))
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.handle("", issuer.openIdConfiguration))
+	mux.HandleFunc(string(JwksEndpoint), issuer.handle(JwksEndpoint, issuer.jwks))
+	mux.HandleFunc(string(AuthorizationEndpoint), issuer.handle(AuthorizationEndpoint, issuer.authorize))
+	mux.HandleFunc(string(TokenEndpoint), issuer.handle(TokenEndpoint, issuer.token))
+	mux.HandleFunc(string(CredentialEndpoint), issuer.handle(CredentialEndpoint, issuer.credential))
+	mux.HandleFunc(string(DeferredCredentialEndpoint), issuer.handle(DeferredCredentialEndpoint, issuer.deferred))
+	mux.HandleFunc(string(NotificationEndpoint), issuer.handle(NotificationEndpoint, issuer.notification))
+	issuer.Server = httptest.NewServer(mux)
+
+	return issuer, nil
+}
+
+// AddConfiguration adds or replaces a credential configuration together with the claims which are issued for it.
+func (issuer *Issuer) AddConfiguration(id string, configuration credential.CredentialConfiguration, claims map[string]interface{}) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.configurations[id] = configuration
+	issuer.claims[id] = claims
+}
+
+// CreateOffer registers a pre-authorized code for the configurations. An empty txCode creates an offer without tx_code.
+func (issuer *Issuer) CreateOffer(txCode string, ids ...string) (*credential.CredentialOffer, error) {
+	code := uuid.NewString()
+
+	issuer.mutex.Lock()
+	issuer.grants[code] = &grant{ids: ids, txCode: txCode}
+	issuer.mutex.Unlock()
+
+	preAuthorized := credential.PreAuthorizedCode{
+		PreAuthorizationCode: code,
+	}
+
+	if txCode != "" {
+		preAuthorized.TxCode = &credential.TxCode{
+			InputMode: "numeric",
+			Length:    len(txCode),
+		}
+	}
+
+	params := credential.CredentialOfferParameters{
+		CredentialIssuer: issuer.URL,
+		Credentials:      ids,
+		Grants:           credential.Grants{PreAuthorizedCode: &preAuthorized},
+	}
+	return params.CreateOfferLink()
+}
+
+// CreateAuthorizationOffer creates an offer for the authorization code grant. The issuer state binds the configurations.
+func (issuer *Issuer) CreateAuthorizationOffer(ids ...string) (*credential.CredentialOffer, error) {
+	state := uuid.NewString()
+
+	issuer.mutex.Lock()
+	issuer.states[state] = ids
+	issuer.mutex.Unlock()
+
+	params := credential.CredentialOfferParameters{
+		CredentialIssuer: issuer.URL,
+		Credentials:      ids,
+		Grants:           credential.Grants{AuthorizationCode: &credential.AuthorizationCode{IssuerState: state}},
+	}
+	return params.CreateOfferLink()
+}
+
+// FailNext answers the next count requests of the endpoint with the given OAuth/OID4VCI error code.
+func (issuer *Issuer) FailNext(endpoint Endpoint, errorCode string, count int) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.failures[endpoint] = &failure{errorCode: errorCode, count: count}
+}
+
+// DeferNext lets the next credential request be deferred. The deferred endpoint reports issuance_pending polls times.
+func (issuer *Issuer) DeferNext(polls int) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.pending = polls + 1
+}
+
+// IssueInvalidClaims lets the issuer sign claims which violate the schema of their configuration.
+func (issuer *Issuer) IssueInvalidClaims() {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.invalidClaims = true
+}
+
+// IssueBatchNext lets the next credential response contain size credentials in the 1.0 credentials array.
+func (issuer *Issuer) IssueBatchNext(size int) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.batch = size
+}
+
+// Delay slows down all responses of the endpoint.
+func (issuer *Issuer) Delay(endpoint Endpoint, delay time.Duration) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.delays[endpoint] = delay
+}
+
+// Notifications returns the notification requests received so far.
+func (issuer *Issuer) Notifications() []credential.NotificationRequest {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	return append([]credential.NotificationRequest{}, issuer.notifications...)
+}
+
+func (issuer *Issuer) handle(endpoint Endpoint, handler func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		issuer.mutex.Lock()
+		delay := issuer.delays[endpoint]
+		var errorCode string
+		if f := issuer.failures[endpoint]; endpoint != "" && f != nil && f.count > 0 {
+			f.count--
+			errorCode = f.errorCode
+		}
+		issuer.mutex.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		if errorCode != "" {
+			response := credential.CredentialErrorResponse{ErrorMsg: errorCode}
+			if errorCode == credential.InvalidNonce || errorCode == credential.InvalidProof {
+				response.CNonce = issuer.renewNonce(r)
+			}
+			helper.WriteJson(w, http.StatusBadRequest, response)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func (issuer *Issuer) metadata(w http.ResponseWriter, r *http.Request) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+
+	deferred := issuer.URL + string(DeferredCredentialEndpoint)
+	notification := issuer.URL + string(NotificationEndpoint)
+
+	configurations := make(map[string]credential.CredentialConfiguration, len(issuer.configurations))
+	for id, c := range issuer.configurations {
+		configurations[id] = c
+	}
+
+	helper.WriteJson(w, http.StatusOK, credential.IssuerMetadata{
+		CredentialIssuer:                  issuer.URL,
+		AuthorizationServers:              []string{issuer.URL},
+		CredentialEndpoint:                issuer.URL + string(CredentialEndpoint),
+		DeferredCredentialEndpoint:        &deferred,
+		NotificationEndpoint:              &notification,
+		CredentialConfigurationsSupported: configurations,
+	})
+}
+
+func (issuer *Issuer) openIdConfiguration(w http.ResponseWriter, r *http.Request) {
+	helper.WriteJson(w, http.StatusOK, oauth.OpenIdConfiguration{
+		Issuer:                                issuer.URL,
+		Authorization_Endpoint:                issuer.URL + string(AuthorizationEndpoint),
+		Token_Endpoint:                        issuer.URL + string(TokenEndpoint),
+		Jwks_Uri:                              issuer.URL + string(JwksEndpoint),
+		Response_Types_Supported:              []string{"code"},
+		Grant_Types_Supported:                 []string{string(oauth.PreAuthorizedCodeGrant), string(oauth.AuthorizationCodeGrant)},
+		Token_Endpoint_Auth_Methods_Supported: []string{"none"},
+	})
+}
+
+func (issuer *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	set := jwk.NewSet()
+	set.AddKey(issuer.Signer.PublicKey())
+	helper.WriteJson(w, http.StatusOK, set)
+}
+
+// authorize approves every request immediately and redirects with a code.
+func (issuer *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectUri := query.Get("redirect_uri")
+
+	target, err := url.Parse(redirectUri)
+	if err != nil || redirectUri == "" || query.Get("response_type") != "code" {
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.InvalidRequest})
+		return
+	}
+
+	issuer.mutex.Lock()
+	ids := make([]string, 0)
+	if state := query.Get("issuer_state"); state != "" {
+		if offered, ok := issuer.states[state]; ok {
+			ids = append(ids, offered...)
+			delete(issuer.states, state)
+		}
+	}
+	if scope := query.Get("scope"); scope != "" {
+		for _, s := range strings.Fields(scope) {
+			if _, ok := issuer.configurations[s]; ok {
+				ids = append(ids, s)
+			}
+		}
+	}
+	code := uuid.NewString()
+	issuer.grants[code] = &grant{
+		ids:           ids,
+		codeChallenge: query.Get("code_challenge"),
+		redirectUri:   redirectUri,
+	}
+	issuer.mutex.Unlock()
+
+	values := target.Query()
+	values.Set("code", code)
+	if state := query.Get("state"); state != "" {
+		values.Set("state", state)
+	}
+	target.RawQuery = values.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (issuer *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.InvalidRequest})
+		return
+	}
+
+	var code string
+	switch oauth.GrantType(r.Form.Get("grant_type")) {
+	case oauth.PreAuthorizedCodeGrant:
+		code = r.Form.Get("pre-authorized_code")
+	case oauth.AuthorizationCodeGrant:
+		code = r.Form.Get("code")
+	default:
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.UnsupportedGrantType})
+		return
+	}
+
+	issuer.mutex.Lock()
+	g, ok := issuer.grants[code]
+	if ok {
+		delete(issuer.grants, code)
+	}
+	issuer.mutex.Unlock()
+
+	if !ok || g.txCode != r.Form.Get("tx_code") || !g.verify(r.Form.Get("code_verifier"), r.Form.Get("redirect_uri")) {
+		helper.WriteJson(w, http.StatusBadRequest, oauth.ErrorResponse{ErrorMsg: oauth.InvalidGrant})
+		return
+	}
+
+	accessToken := uuid.NewString()
+	s := &session{ids: g.ids, cNonce: uuid.NewString()}
+
+	issuer.mutex.Lock()
+	issuer.sessions[accessToken] = s
+	issuer.mutex.Unlock()
+
+	details := make([]oauth.AuthorizationDetails, 0, len(g.ids))
+	for _, id := range g.ids {
+		details = append(details, oauth.AuthorizationDetails{
+			Type:                      oauth.AuthorizationDetailsTypeOpenIdCredential,
+			CredentialConfigurationID: id,
+		})
+	}
+
+	helper.WriteJson(w, http.StatusOK, oauth.Token{
+		AccessToken:          accessToken,
+		TokenType:            "Bearer",
+		ExpiresIn:            int64(config.DefaultTokenExpiry.Seconds()),
+		CNonce:               s.cNonce,
+		CNonceExpiresIn:      int64(config.DefaultTokenExpiry.Seconds()),
+		AuthorizationDetails: details,
+	})
+}
+
+func (g *grant) verify(verifier string, redirectUri string) bool {
+	if g.redirectUri != "" && g.redirectUri != redirectUri {
+		return false
+	}
+	if g.codeChallenge == "" {
+		return true
+	}
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:]) == g.codeChallenge
+}
+
+func (issuer *Issuer) session(r *http.Request) *session {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return nil
+	}
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	return issuer.sessions[token]
+}
+
+func (issuer *Issuer) renewNonce(r *http.Request) string {
+	nonce := uuid.NewString()
+	if s := issuer.session(r); s != nil {
+		issuer.mutex.Lock()
+		s.cNonce = nonce
+		issuer.mutex.Unlock()
+	}
+	return nonce
+}
+
+func (issuer *Issuer) credential(w http.ResponseWriter, r *http.Request) {
+	s := issuer.session(r)
+	if s == nil {
+		helper.WriteJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	var request credential.CredentialRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, credential.ErrInvalidCredentialRequest)
+		return
+	}
+
+	id := request.CredentialConfigurationId
+	if id == "" {
+		id = request.CredentialIdentifier
+	}
+
+	issuer.mutex.Lock()
+	configuration, ok := issuer.configurations[id]
+	authorized := false
+	for _, a := range s.ids {
+		authorized = authorized || a == id
+	}
+	cNonce := s.cNonce
+	batch := issuer.batch
+	issuer.batch = 0
+	issuer.mutex.Unlock()
+
+	if !ok || !authorized {
+		helper.WriteJson(w, http.StatusBadRequest, credential.ErrUnsupportedCredentialType)
+		return
+	}
+
+	var holder jwk.Key
+	if len(configuration.ProofTypesSupported) > 0 {
+		if request.Proof == nil || request.Proof.CheckProof(issuer.URL, cNonce, configuration.ProofTypesSupported) != nil {
+			helper.WriteJson(w, http.StatusBadRequest, credential.CredentialErrorResponse{ErrorMsg: credential.InvalidProof, CNonce: issuer.renewNonce(r)})
+			return
+		}
+		holder = proofKey(*request.Proof)
+	}
+
+	issued, err := issuer.issue(id, configuration, holder)
+	if err != nil {
+		helper.WriteJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	credentials := []credential.CredentialObject{{Credential: issued}}
+	for len(credentials) < batch {
+		additional, err := issuer.issue(id, configuration, holder)
+		if err != nil {
+			helper.WriteJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+			return
+		}
+		credentials = append(credentials, credential.CredentialObject{Credential: additional})
+	}
+
+	response := credential.CredentialResponse{
+		Format:         configuration.Format,
+		CNonce:         issuer.renewNonce(r),
+		NotificationId: uuid.NewString(),
+	}
+
+	issuer.mutex.Lock()
+	if issuer.pending > 0 {
+		response.TransactionID = uuid.NewString()
+		issuer.transactions[response.TransactionID] = issued
+		issuer.pendingPolls[response.TransactionID] = issuer.pending - 1
+		issuer.pending = 0
+	} else if len(credentials) > 1 {
+		response.Credentials = credentials
+	} else {
+		response.Credential = issued
+	}
+	issuer.mutex.Unlock()
+
+	helper.WriteJson(w, http.StatusOK, response)
+}
+
+func (issuer *Issuer) deferred(w http.ResponseWriter, r *http.Request) {
+	if issuer.session(r) == nil {
+		helper.WriteJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	var request credential.CredentialDeferredRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, credential.ErrInvalidCredentialRequest)
+		return
+	}
+
+	issuer.mutex.Lock()
+	issued, ok := issuer.transactions[request.TransactionID]
+	polls := issuer.pendingPolls[request.TransactionID]
+	if ok && polls > 0 {
+		issuer.pendingPolls[request.TransactionID] = polls - 1
+	} else if ok {
+		delete(issuer.transactions, request.TransactionID)
+		delete(issuer.pendingPolls, request.TransactionID)
+	}
+	issuer.mutex.Unlock()
+
+	if !ok {
+		helper.WriteJson(w, http.StatusBadRequest, credential.CredentialErrorResponse{ErrorMsg: credential.InvalidTransactionId})
+		return
+	}
+
+	if polls > 0 {
+		helper.WriteJson(w, http.StatusBadRequest, credential.CredentialErrorResponse{ErrorMsg: credential.IssuancePending})
+		return
+	}
+
+	helper.WriteJson(w, http.StatusOK, credential.CredentialResponse{
+		Credential:     issued,
+		NotificationId: uuid.NewString(),
+	})
+}
+
+func (issuer *Issuer) notification(w http.ResponseWriter, r *http.Request) {
+	if issuer.session(r) == nil {
+		helper.WriteJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	var request credential.NotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.NotificationId == "" {
+		helper.WriteJson(w, http.StatusBadRequest, map[string]string{"error": credential.InvalidNotificationRequest})
+		return
+	}
+
+	issuer.mutex.Lock()
+	issuer.notifications = append(issuer.notifications, request)
+	issuer.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (issuer *Issuer) issue(id string, configuration credential.CredentialConfiguration, holder jwk.Key) (string, error) {
+	issuer.mutex.Lock()
+	claims := issuer.claims[id]
+	invalidClaims := issuer.invalidClaims
+	issuer.mutex.Unlock()
+
+	if !invalidClaims {
+		if err := configuration.ValidateClaims(claims); err != nil {
+			return "", err
+		}
+	}
+
+	now := time.Now()
+	if types.IsSdJwt(configuration.Format) {
+		vc := sdjwt.Credential{
+			Issuer:    issuer.URL,
+			IssuedAt:  now,
+			NotBefore: now,
+			ExpiresAt: now.Add(24 * time.Hour),
+			Holder:    holder,
+			Claims:    claims,
+		}
+		if configuration.Vct != nil {
+			vc.Vct = *configuration.Vct
+		}
+		sdJwtIssuer := sdjwt.NewIssuer(issuer.Signer)
+		sdJwtIssuer.Type = configuration.Format
+		return sdJwtIssuer.Issue(vc)
+	}
+
+	payload := map[string]interface{}{
+		"iss": issuer.URL,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(24 * time.Hour).Unix(),
+	}
+
+	if holder != nil {
+		payload["cnf"] = map[string]interface{}{"jwk": holder}
+	}
+
+	headers := map[string]interface{}{
+		"kid": issuer.Signer.KeyID(),
+		"typ": "JWT",
+	}
+
+	payload["jti"] = "urn:uuid:" + uuid.NewString()
+	payload["vc"] = map[string]interface{}{
+		"@context":          configuration.CredentialDefinition.Context,
+		"type":              configuration.CredentialDefinition.Type,
+		"issuer":            issuer.URL,
+		"issuanceDate":      now.UTC().Format(time.RFC3339),
+		"credentialSubject": claims,
+	}
+	return signing.SignJwt(issuer.Signer, headers, payload)
+}
+
+func proofKey(proof credential.Proof) jwk.Key {
+	if proof.Jwt == nil {
+		return nil
+	}
+	message, err := jws.ParseString(*proof.Jwt)
+	if err != nil || len(message.Signatures()) == 0 {
+		return nil
+	}
+	return message.Signatures()[0].ProtectedHeaders().JWK()
+}
@@ -0,0 +1,181 @@
+package mock
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+func newTestIssuer(t *testing.T) *Issuer {
+	issuer, err := NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func TestPreAuthorizedIssuance(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	offer, err := issuer.CreateOffer("1234", JwtConfiguration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := offer.GetOfferParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := params.GetIssuerMetadata()
+	if err != nil || len(metadata.CredentialConfigurationsSupported) != 2 {
+		t.Fatal(err)
+	}
+
+	config, err := metadata.FindFittingAuthorizationServer(oauth.PreAuthorizedCodeGrant)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = config.GetToken(oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: params.Grants.PreAuthorizedCode.PreAuthorizationCode,
+		TxCode:            "0000",
+	})
+
+	if err == nil {
+		t.Error("wrong tx_code must be rejected")
+	}
+
+	offer, _ = issuer.CreateOffer("1234", JwtConfiguration)
+	params, _ = offer.GetOfferParameters()
+
+	token, err := config.GetToken(oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: params.Grants.PreAuthorizedCode.PreAuthorizationCode,
+		TxCode:            "1234",
+	})
+
+	if err != nil || token.CNonce == "" {
+		t.Fatal(err)
+	}
+
+	response, err := metadata.CredentialRequest(credential.CredentialRequest{CredentialConfigurationId: JwtConfiguration}, *token)
+
+	if err == nil || response != nil {
+		t.Error("missing proof must be rejected")
+	}
+
+	errResponse, ok := credential.AsCredentialError(err)
+	if !ok || errResponse.ErrorMsg != credential.InvalidProof || errResponse.CNonce == "" {
+		t.Error(err)
+	}
+}
+
+func TestAuthorizationCodeGrant(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	offer, _ := issuer.CreateAuthorizationOffer(SdJwtConfiguration)
+	params, _ := offer.GetOfferParameters()
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	hash := sha256.Sum256([]byte(verifier))
+	redirectUri := "https://wallet.example.com/cb"
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"wallet"},
+		"redirect_uri":          {redirectUri},
+		"state":                 {"xyz"},
+		"issuer_state":          {params.Grants.AuthorizationCode.IssuerState},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(hash[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	client := http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(issuer.URL + string(AuthorizationEndpoint) + "?" + query.Encode())
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatal(err)
+	}
+
+	location, _ := url.Parse(resp.Header.Get("Location"))
+	if location.Query().Get("state") != "xyz" {
+		t.Error()
+	}
+
+	form := url.Values{
+		"grant_type":    {string(oauth.AuthorizationCodeGrant)},
+		"code":          {location.Query().Get("code")},
+		"redirect_uri":  {redirectUri},
+		"code_verifier": {verifier},
+	}
+
+	resp, err = http.Post(issuer.URL+string(TokenEndpoint), "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal(err)
+	}
+
+	var token oauth.Token
+	json.NewDecoder(resp.Body).Decode(&token)
+
+	if len(token.AuthorizationDetails) != 1 || token.AuthorizationDetails[0].CredentialConfigurationID != SdJwtConfiguration {
+		t.Error()
+	}
+}
+
+func TestIssuedCredentialSignature(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	issued, err := issuer.issue(SdJwtConfiguration, issuer.configurations[SdJwtConfiguration], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(issued, "~")
+	if len(parts) != 5 {
+		t.Error()
+	}
+
+	_, err = jws.Verify([]byte(parts[0]), jws.WithKey(jwa.ES256, issuer.Signer.PublicKey()))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestErrorInjection(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.FailNext(TokenEndpoint, "invalid_grant", 1)
+	issuer.Delay(TokenEndpoint, 50*time.Millisecond)
+
+	offer, _ := issuer.CreateOffer("", JwtConfiguration)
+	params, _ := offer.GetOfferParameters()
+	config := oauth.OpenIdConfiguration{Token_Endpoint: issuer.URL + string(TokenEndpoint)}
+	request := oauth.TokenRequest{GrantType: oauth.PreAuthorizedCodeGrant, PreAuthorizedCode: params.Grants.PreAuthorizedCode.PreAuthorizationCode}
+
+	start := time.Now()
+	_, err := config.GetToken(request)
+
+	if err == nil || time.Since(start) < 50*time.Millisecond {
+		t.Error()
+	}
+
+	_, err = config.GetToken(request)
+
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,571 @@
+package mock
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/ldp"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mdoc"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/presentation"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/sdjwt"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/oliveagle/jsonpath"
+)
+
+const (
+	RequestEndpoint  Endpoint = "/request/"
+	ResponseEndpoint Endpoint = "/response"
+	StateEndpoint    Endpoint = "/state/"
+)
+
+type AuthorizationRequest struct {
+	ID         string
+	RequestUri string
+	// Link is the openid4vp:// link which is usually rendered as QR code
+	Link    string
+	Request presentation.RequestObject
+}
+
+type verification struct {
+	request presentation.RequestObject
+	state   presentation.StateResponse
+}
+
+/*
+Verifier is an in-process OID4VP verifier for tests. Request objects are signed with an ephemeral key and served by
+request_uri, responses are accepted via direct_post and direct_post.jwt. The outcome of the verification is exposed
+as StateResponse. Holders, DID issuers and signed responses are verified with the DID resolver, other SD-JWT and JWT
+VC issuers with the keys registered with Trust. mdoc document signers must chain to a certificate registered with
+TrustCertificate. Credentials of issuers without trusted keys are rejected.
+*/
+type Verifier struct {
+	*httptest.Server
+	Signer signing.Signer
+
+	encryptionKey jwk.Key
+	mutex         sync.Mutex
+	verifications map[string]*verification
+	trusted       []jwk.Key
+	roots         *x509.CertPool
+	dids          did.Resolver
+}
+
+func NewVerifier() (*Verifier, error) {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+	key.Set(jwk.KeyIDKey, uuid.NewString())
+
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rawEnc, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, err := jwk.FromRaw(rawEnc)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey.Set(jwk.KeyIDKey, uuid.NewString())
+	encryptionKey.Set(jwk.KeyUsageKey, jwk.ForEncryption)
+	encryptionKey.Set(jwk.AlgorithmKey, jwa.ECDH_ES)
+
+	verifier := &Verifier{
+		Signer:        signer,
+		encryptionKey: encryptionKey,
+		verifications: make(map[string]*verification),
+		dids:          did.NewResolver(helper.NewHttpClient()),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+string(RequestEndpoint)+"{id}", verifier.requestObject)
+	mux.HandleFunc("POST "+string(ResponseEndpoint), verifier.response)
+	mux.HandleFunc("GET "+string(StateEndpoint)+"{id}", verifier.stateResponse)
+	verifier.Server = httptest.NewServer(mux)
+
+	return verifier, nil
+}
+
+// Trust registers a key of SD-JWT and JWT VC issuers which are no DID.
+func (verifier *Verifier) Trust(key jwk.Key) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+	verifier.trusted = append(verifier.trusted, key)
+}
+
+// TrustCertificate registers a root of mdoc document signers.
+func (verifier *Verifier) TrustCertificate(certificate *x509.Certificate) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+	if verifier.roots == nil {
+		verifier.roots = x509.NewCertPool()
+	}
+	verifier.roots.AddCert(certificate)
+}
+
+// EncryptionKey returns the public key which wallets use to encrypt direct_post.jwt responses.
+func (verifier *Verifier) EncryptionKey() jwk.Key {
+	key, _ := verifier.encryptionKey.PublicKey()
+	return key
+}
+
+// CreateRequest creates a request object for the definition and serves it by request_uri.
+func (verifier *Verifier) CreateRequest(definition presentation.PresentationDefinition, mode types.ResponseMode) (*AuthorizationRequest, error) {
+	if err := definition.CheckPresentationDefinition(); err != nil {
+		return nil, err
+	}
+
+	if mode != types.DirectPost && mode != types.DirectPostJwt {
+		return nil, fmt.Errorf("response mode %s not supported", mode)
+	}
+
+	id := uuid.NewString()
+	responseUri := verifier.URL + string(ResponseEndpoint)
+
+	request := presentation.RequestObject{
+		Client_Id_Scheme:       "redirect_uri",
+		ClientID:               responseUri,
+		PresentationDefinition: definition,
+		Nonce:                  uuid.NewString(),
+		ResponseType:           types.VpToken,
+		ResponseMode:           mode,
+		ResponseUri:            responseUri,
+		State:                  id,
+	}
+
+	if mode == types.DirectPostJwt {
+		set := jwk.NewSet()
+		set.AddKey(verifier.EncryptionKey())
+		b, err := json.Marshal(set)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys map[string]interface{}
+		if err := json.Unmarshal(b, &keys); err != nil {
+			return nil, err
+		}
+
+		request.ClientMetadata = &presentation.ClientMetadata{
+			Jwks:                              keys,
+			AuthorizationEncryptedResponseAlg: jwa.ECDH_ES.String(),
+			AuthorizationEncryptedResponseEnc: jwa.A256GCM.String(),
+		}
+	}
+
+	verifier.mutex.Lock()
+	verifier.verifications[id] = &verification{
+		request: request,
+		state:   presentation.StateResponse{ID: id, State: presentation.StatePending},
+	}
+	verifier.mutex.Unlock()
+
+	requestUri := verifier.URL + string(RequestEndpoint) + id
+
+	return &AuthorizationRequest{
+		ID:         id,
+		RequestUri: requestUri,
+		Link:       request.CreateRequestUriLink(requestUri),
+		Request:    request,
+	}, nil
+}
+
+// State returns the current verification state of a request.
+func (verifier *Verifier) State(id string) (*presentation.StateResponse, bool) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	v, ok := verifier.verifications[id]
+	if !ok {
+		return nil, false
+	}
+	state := v.state
+	return &state, true
+}
+
+func (verifier *Verifier) requestObject(w http.ResponseWriter, r *http.Request) {
+	verifier.mutex.Lock()
+	v, ok := verifier.verifications[r.PathValue("id")]
+	verifier.mutex.Unlock()
+
+	if !ok {
+		helper.WriteJson(w, http.StatusNotFound, map[string]string{"error": "invalid_request_uri"})
+		return
+	}
+
+	token, err := signing.SignJwt(verifier.Signer, map[string]interface{}{
+		"typ": "oauth-authz-req+jwt",
+		"kid": verifier.Signer.KeyID(),
+	}, v.request)
+
+	if err != nil {
+		helper.WriteJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/oauth-authz-req+jwt")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(token))
+}
+
+func (verifier *Verifier) stateResponse(w http.ResponseWriter, r *http.Request) {
+	state, ok := verifier.State(r.PathValue("id"))
+	if !ok {
+		helper.WriteJson(w, http.StatusNotFound, map[string]string{"error": "not_found"})
+		return
+	}
+	helper.WriteJson(w, http.StatusOK, state)
+}
+
+func (verifier *Verifier) response(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	parameters := map[string]interface{}{
+		"vp_token":                r.PostForm.Get("vp_token"),
+		"presentation_submission": r.PostForm.Get("presentation_submission"),
+		"state":                   r.PostForm.Get("state"),
+	}
+
+	var mdocGeneratedNonce string
+	var thumbprint []byte
+	if response := r.PostForm.Get("response"); response != "" {
+		var err error
+		parameters, mdocGeneratedNonce, err = verifier.decodeJarm(response)
+		if err != nil {
+			helper.WriteJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": err.Error()})
+			return
+		}
+		if strings.Count(response, ".") == 4 {
+			thumbprint, _ = verifier.encryptionKey.Thumbprint(crypto.SHA256)
+		}
+	}
+
+	state, _ := parameters["state"].(string)
+
+	// the state is claimed under the same lock, so a second response for the state is rejected
+	verifier.mutex.Lock()
+	v, ok := verifier.verifications[state]
+	pending := ok && v.state.State == presentation.StatePending
+	if pending {
+		v.state.State = presentation.StateInProgress
+	}
+	verifier.mutex.Unlock()
+
+	if !pending {
+		helper.WriteJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": "unknown state"})
+		return
+	}
+
+	attributes, err := verifier.verify(v.request, parameters, v.request.Handover(mdocGeneratedNonce, thumbprint))
+
+	verifier.mutex.Lock()
+	if err != nil {
+		v.state.State = presentation.StateRejected
+	} else {
+		v.state.State = presentation.StateAccepted
+		v.state.VerifiedAttributes = attributes
+	}
+	verifier.mutex.Unlock()
+
+	if err != nil {
+		helper.WriteJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	helper.WriteJson(w, http.StatusOK, map[string]string{})
+}
+
+/*
+decodeJarm extracts the response parameters of direct_post.jwt, which are either encrypted to the verifier or signed.
+Signed responses must name a DID as iss. The apu header of encrypted responses is returned as mdoc_generated_nonce.
+*/
+func (verifier *Verifier) decodeJarm(response string) (map[string]interface{}, string, error) {
+	var payload []byte
+	var apu []byte
+	var err error
+
+	switch strings.Count(response, ".") {
+	case 4:
+		message := jwe.NewMessage()
+		payload, err = jwe.Decrypt([]byte(response), jwe.WithKey(jwa.ECDH_ES, verifier.encryptionKey), jwe.WithMessage(message))
+		if err == nil {
+			apu = message.ProtectedHeaders().AgreementPartyUInfo()
+		}
+	case 2:
+		payload, err = verifier.verifyJarm(response)
+	default:
+		err = errors.New("response is neither JWE nor JWS")
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	var parameters map[string]interface{}
+	if err := json.Unmarshal(payload, &parameters); err != nil {
+		return nil, "", err
+	}
+	return parameters, string(apu), nil
+}
+
+// verifyJarm checks the signature of a signed response with the keys of its DID issuer and returns the payload.
+func (verifier *Verifier) verifyJarm(response string) ([]byte, error) {
+	message, err := jws.ParseString(response)
+	if err != nil {
+		return nil, err
+	}
+	if len(message.Signatures()) != 1 {
+		return nil, errors.New("response must have one signature")
+	}
+
+	b, err := json.Marshal(message.Signatures()[0].ProtectedHeaders())
+	if err != nil {
+		return nil, err
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(message.Payload(), &claims); err != nil {
+		return nil, err
+	}
+
+	alg := message.Signatures()[0].ProtectedHeaders().Algorithm()
+	switch alg {
+	case "", jwa.NoSignature, jwa.HS256, jwa.HS384, jwa.HS512:
+		return nil, fmt.Errorf("alg %s not allowed", alg)
+	}
+
+	keys, err := verifier.dids.IssuerKeys(claims.Iss, header)
+	if err != nil {
+		return nil, fmt.Errorf("response signature: %w", err)
+	}
+	for _, key := range keys {
+		if payload, err := jws.Verify([]byte(response), jws.WithKey(alg, key)); err == nil {
+			return payload, nil
+		}
+	}
+	return nil, errors.New("response signature invalid")
+}
+
+func (verifier *Verifier) verify(request presentation.RequestObject, parameters map[string]interface{}, handover presentation.Handover) (presentation.VerifiedAttributes, error) {
+	var submission presentation.PresentationSubmission
+	switch s := parameters["presentation_submission"].(type) {
+	case string:
+		if err := json.Unmarshal([]byte(s), &submission); err != nil {
+			return nil, fmt.Errorf("invalid presentation_submission: %w", err)
+		}
+	default:
+		b, _ := json.Marshal(s)
+		if err := json.Unmarshal(b, &submission); err != nil {
+			return nil, fmt.Errorf("invalid presentation_submission: %w", err)
+		}
+	}
+
+	if err := submission.CheckSubmissionData(); err != nil {
+		return nil, err
+	}
+
+	if submission.DefinitionId != request.PresentationDefinition.Id {
+		return nil, errors.New("presentation_submission does not match the presentation definition")
+	}
+
+	vpToken := parameters["vp_token"]
+	if s, ok := vpToken.(string); ok && (strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{")) {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, fmt.Errorf("invalid vp_token: %w", err)
+		}
+		vpToken = decoded
+	}
+
+	// a Data Integrity presentation is checked as a whole, the descriptors refer to its credentials
+	if vp, ok := vpToken.(map[string]interface{}); ok && vp["proof"] != nil {
+		if _, _, err := ldp.NewVerifier(ldp.DidKeys(verifier.dids)).VerifyPresentation(vp, request.Nonce, request.ClientID); err != nil {
+			return nil, err
+		}
+	}
+
+	attributes := make(presentation.VerifiedAttributes)
+	for _, descriptor := range submission.DescriptorMap {
+		var inputDescriptor *presentation.InputDescriptor
+		for i, d := range request.PresentationDefinition.InputDescriptors {
+			if d.Id == descriptor.Id {
+				inputDescriptor = &request.PresentationDefinition.InputDescriptors[i]
+			}
+		}
+		if inputDescriptor == nil {
+			return nil, fmt.Errorf("descriptor %s not requested", descriptor.Id)
+		}
+
+		raw, err := resolvePath(vpToken, descriptor.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		credential, err := verifier.verifyEntry(descriptor.Format, raw, request, handover)
+		if err != nil {
+			return nil, fmt.Errorf("descriptor %s: %w", descriptor.Id, err)
+		}
+
+		if descriptor.PathNested.Path != "" {
+			raw, err = resolvePath(credential.Json, descriptor.PathNested.Path)
+			if err != nil {
+				return nil, err
+			}
+			credential, err = verifier.verifyEntry(descriptor.PathNested.Format, raw, request, handover)
+			if err != nil {
+				return nil, fmt.Errorf("descriptor %s: %w", descriptor.Id, err)
+			}
+		}
+
+		ok, err := inputDescriptor.Filter(credential)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("descriptor %s: credential does not fulfill the constraints", descriptor.Id)
+		}
+
+		attributes[descriptor.Id] = credential.Json
+	}
+
+	return attributes, nil
+}
+
+func resolvePath(token interface{}, path string) (interface{}, error) {
+	if path == "" || path == "$" {
+		return token, nil
+	}
+	value, err := jsonpath.JsonPathLookup(token, path)
+	if err != nil {
+		return nil, fmt.Errorf("path %s not found in vp_token: %w", path, err)
+	}
+	return value, nil
+}
+
+func (verifier *Verifier) verifyEntry(format string, raw interface{}, request presentation.RequestObject, handover presentation.Handover) (*types.Credential, error) {
+	switch format {
+	case "vc+sd-jwt", "dc+sd-jwt":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("sd-jwt must be a string")
+		}
+		return verifier.verifySdJwt(types.CredentialFormat(format), s, request)
+	case string(types.MSOMDOC):
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("mdoc device response must be a string")
+		}
+		return verifier.verifyMdoc(s, handover)
+	case "jwt_vc", "jwt_vc_json", "jwt_vc_json-ld":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("jwt vc must be a string")
+		}
+		return types.NewJwtVerifier(verifier.jwtKeys).VerifyCredential(s)
+	case "jwt_vp", "jwt_vp_json":
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("jwt vp must be a string")
+		}
+		if _, _, err := types.NewJwtVerifier(verifier.jwtKeys).VerifyPresentation(s, request.ClientID, request.Nonce); err != nil {
+			return nil, err
+		}
+
+		// the claims of the verified token, path_nested refers to the embedded credentials
+		token, err := jwt.ParseInsecure([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		claims, err := token.AsMap(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return &types.Credential{Format: types.JWTVC, Json: claims}, nil
+	case "ldp_vp":
+		vp, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("ldp vp must be an object")
+		}
+		if _, _, err := ldp.NewVerifier(ldp.DidKeys(verifier.dids)).VerifyPresentation(vp, request.Nonce, request.ClientID); err != nil {
+			return nil, err
+		}
+		return &types.Credential{Format: types.CredentialFormat(types.LDPVP), Json: vp}, nil
+	case "ldp_vc":
+		vc, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("ldp vc must be an object")
+		}
+		return ldp.NewVerifier(ldp.DidKeys(verifier.dids)).VerifyCredential(vc)
+	}
+	return nil, fmt.Errorf("format %s not supported", format)
+}
+
+// jwtKeys resolves DID issuers and holders with the DID resolver and other issuers with the keys registered with Trust.
+func (verifier *Verifier) jwtKeys(issuer string, header map[string]interface{}) ([]jwk.Key, error) {
+	if strings.HasPrefix(issuer, "did:") {
+		return verifier.dids.IssuerKeys(issuer, header)
+	}
+
+	verifier.mutex.Lock()
+	trusted := append([]jwk.Key{}, verifier.trusted...)
+	verifier.mutex.Unlock()
+
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("issuer %s not trusted", issuer)
+	}
+	return trusted, nil
+}
+
+// verifySdJwt checks the issuer signature, the disclosures and the key binding jwt against the request.
+func (verifier *Verifier) verifySdJwt(format types.CredentialFormat, presentationToken string, request presentation.RequestObject) (*types.Credential, error) {
+	claims, err := sdjwt.NewVerifier(verifier.jwtKeys).VerifyPresentation(presentationToken, request.ClientID, request.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Credential{Format: format, Json: claims}, nil
+}
+
+// verifyMdoc checks the device response, the document signers must chain to a certificate registered with TrustCertificate.
+func (verifier *Verifier) verifyMdoc(vpToken string, handover presentation.Handover) (*types.Credential, error) {
+	verifier.mutex.Lock()
+	roots := verifier.roots
+	verifier.mutex.Unlock()
+
+	if roots == nil {
+		return nil, mdoc.ErrRootsMissing
+	}
+	return presentation.VerifyMdocResponse(vpToken, "", handover, mdoc.NewVerifier(roots))
+}
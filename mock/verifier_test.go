@@ -0,0 +1,603 @@
+package mock
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/ldp"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mdoc"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/presentation"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwe"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+var testDefinition = presentation.PresentationDefinition{
+	Description: presentation.Description{Id: "identity"},
+	InputDescriptors: []presentation.InputDescriptor{
+		{
+			Description: presentation.Description{Id: "pid"},
+			Constraints: presentation.Constraints{
+				Fields: []presentation.Field{{Path: []string{"$.family_name"}}},
+			},
+		},
+	},
+}
+
+func newTestVerifier(t *testing.T) *Verifier {
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(verifier.Close)
+	return verifier
+}
+
+func holderSigner(t *testing.T) signing.Signer {
+	raw, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	key, _ := jwk.FromRaw(raw)
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// presentSdJwt discloses everything and appends the key binding jwt.
+func presentSdJwt(t *testing.T, issuer *Issuer, holder signing.Signer, nonce string, audience string) string {
+	sdJwt, err := issuer.issue(SdJwtConfiguration, issuer.configurations[SdJwtConfiguration], holder.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := sha256.Sum256([]byte(sdJwt))
+	kbJwt, err := signing.SignJwt(holder, map[string]interface{}{"typ": "kb+jwt"}, map[string]interface{}{
+		"nonce":   nonce,
+		"aud":     audience,
+		"iat":     time.Now().Unix(),
+		"sd_hash": base64.RawURLEncoding.EncodeToString(hash[:]),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sdJwt + kbJwt
+}
+
+func fetchRequest(t *testing.T, verifier *Verifier, requestUri string) presentation.RequestObject {
+	resp, err := http.Get(requestUri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	token, err := jwt.Parse(b, jwt.WithKey(jwa.ES256, verifier.Signer.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, _ := token.AsMap(context.Background())
+	b, _ = json.Marshal(claims)
+	var request presentation.RequestObject
+	json.Unmarshal(b, &request)
+	return request
+}
+
+func TestVerifierDirectPost(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t)
+	verifier.Trust(issuer.Signer.PublicKey())
+
+	authorization, err := verifier.CreateRequest(testDefinition, types.DirectPost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := fetchRequest(t, verifier, authorization.RequestUri)
+	if request.Nonce != authorization.Request.Nonce || request.PresentationDefinition.Id != "identity" {
+		t.Fatal()
+	}
+
+	submission := presentation.PresentationSubmission{
+		Id:            "1",
+		DefinitionId:  request.PresentationDefinition.Id,
+		DescriptorMap: []presentation.Descriptor{{Id: "pid", Format: "vc+sd-jwt", Path: "$"}},
+	}
+	b, _ := json.Marshal(submission)
+
+	vpToken := presentSdJwt(t, issuer, holderSigner(t), request.Nonce, request.ClientID)
+
+	resp, err := http.PostForm(request.ResponseUri, url.Values{
+		"vp_token":                {vpToken},
+		"presentation_submission": {string(b)},
+		"state":                   {request.State},
+	})
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal(err)
+	}
+
+	state, ok := verifier.State(authorization.ID)
+	if !ok || state.State != presentation.StateAccepted {
+		t.Fatal()
+	}
+
+	claims := state.VerifiedAttributes["pid"].(map[string]interface{})
+	if claims["family_name"] != "Mustermann" {
+		t.Error()
+	}
+}
+
+func TestVerifierDirectPostJwt(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t)
+	verifier.Trust(issuer.Signer.PublicKey())
+
+	authorization, _ := verifier.CreateRequest(testDefinition, types.DirectPostJwt)
+	request := fetchRequest(t, verifier, authorization.RequestUri)
+
+	if request.ClientMetadata == nil || request.ClientMetadata.Jwks == nil {
+		t.Fatal()
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"vp_token": presentSdJwt(t, issuer, holderSigner(t), request.Nonce, request.ClientID),
+		"presentation_submission": presentation.PresentationSubmission{
+			Id:            "1",
+			DefinitionId:  request.PresentationDefinition.Id,
+			DescriptorMap: []presentation.Descriptor{{Id: "pid", Format: "vc+sd-jwt", Path: "$"}},
+		},
+		"state": request.State,
+	})
+
+	encrypted, err := jwe.Encrypt(payload, jwe.WithKey(jwa.ECDH_ES, verifier.EncryptionKey()), jwe.WithContentEncryption(jwa.A256GCM))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.PostForm(request.ResponseUri, url.Values{"response": {string(encrypted)}})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal(err)
+	}
+
+	resp, err = http.Get(verifier.URL + string(StateEndpoint) + authorization.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state presentation.StateResponse
+	json.NewDecoder(resp.Body).Decode(&state)
+
+	if state.State != presentation.StateAccepted {
+		t.Error()
+	}
+}
+
+func TestVerifierRejectsWrongNonce(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t)
+	verifier.Trust(issuer.Signer.PublicKey())
+
+	authorization, _ := verifier.CreateRequest(testDefinition, types.DirectPost)
+	request := authorization.Request
+
+	b, _ := json.Marshal(presentation.PresentationSubmission{
+		Id:            "1",
+		DefinitionId:  request.PresentationDefinition.Id,
+		DescriptorMap: []presentation.Descriptor{{Id: "pid", Format: "vc+sd-jwt", Path: "$"}},
+	})
+
+	resp, err := http.PostForm(request.ResponseUri, url.Values{
+		"vp_token":                {presentSdJwt(t, issuer, holderSigner(t), "other", request.ClientID)},
+		"presentation_submission": {string(b)},
+		"state":                   {request.State},
+	})
+
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Error()
+	}
+
+	state, _ := verifier.State(authorization.ID)
+	if state.State != presentation.StateRejected {
+		t.Error()
+	}
+}
+
+func TestVerifierUntrustedIssuer(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t)
+
+	authorization, _ := verifier.CreateRequest(testDefinition, types.DirectPost)
+	request := authorization.Request
+
+	b, _ := json.Marshal(presentation.PresentationSubmission{
+		Id:            "1",
+		DefinitionId:  request.PresentationDefinition.Id,
+		DescriptorMap: []presentation.Descriptor{{Id: "pid", Format: "vc+sd-jwt", Path: "$"}},
+	})
+
+	resp, err := http.PostForm(request.ResponseUri, url.Values{
+		"vp_token":                {presentSdJwt(t, issuer, holderSigner(t), request.Nonce, request.ClientID)},
+		"presentation_submission": {string(b)},
+		"state":                   {request.State},
+	})
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Error("credential of an untrusted issuer accepted")
+	}
+}
+
+// issueMdoc issues an mDL bound to the holder with a self signed document signer certificate.
+func issueMdoc(t *testing.T, holder signing.Signer) (string, *x509.Certificate) {
+	raw, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "document signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		UnknownExtKeyUsage:    []asn1.ObjectIdentifier{mdoc.DocumentSignerKeyUsage},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &raw.PublicKey, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+
+	key, _ := jwk.FromRaw(raw)
+	signer, _ := signing.NewJwkSigner(key)
+	issued, err := mdoc.NewIssuer(signer, certificate).Issue(mdoc.Document{
+		DocType:    "org.iso.18013.5.1.mDL",
+		NameSpaces: map[string]map[string]interface{}{"org.iso.18013.5.1": {"family_name": "Mustermann", "given_name": "Erika"}},
+		DeviceKey:  holder.PublicKey(),
+		ValidUntil: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issued.String(), certificate
+}
+
+func TestVerifierMdoc(t *testing.T) {
+	verifier := newTestVerifier(t)
+	holder := holderSigner(t)
+	credential, certificate := issueMdoc(t, holder)
+
+	definition := presentation.PresentationDefinition{
+		Description: presentation.Description{Id: "mdl"},
+		InputDescriptors: []presentation.InputDescriptor{
+			{
+				Description: presentation.Description{Id: "org.iso.18013.5.1.mDL"},
+				Format:      presentation.Format{MSOMDOC: &presentation.FormatSpecification{Alg: []presentation.Alg{presentation.ES256}}},
+				Constraints: presentation.Constraints{
+					Fields: []presentation.Field{{Path: []string{"$['org.iso.18013.5.1']['family_name']"}}},
+				},
+			},
+		},
+	}
+
+	authorization, _ := verifier.CreateRequest(definition, types.DirectPostJwt)
+	request := fetchRequest(t, verifier, authorization.RequestUri)
+
+	mdocGeneratedNonce := "mdoc-generated-nonce"
+	vpToken, err := request.PresentationDefinition.InputDescriptors[0].PresentMdoc(credential, holder, request.Handover(mdocGeneratedNonce, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifier.verifyMdoc(vpToken, request.Handover(mdocGeneratedNonce, nil)); !errors.Is(err, mdoc.ErrRootsMissing) {
+		t.Error("self signed document signer accepted", err)
+	}
+	verifier.TrustCertificate(certificate)
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"vp_token": vpToken,
+		"presentation_submission": presentation.CreateTokenSubmission(request.PresentationDefinition.Id, []presentation.Description{
+			{Id: "org.iso.18013.5.1.mDL", FormatType: string(types.MSOMDOC)},
+		}),
+		"state": request.State,
+	})
+
+	headers := jwe.NewHeaders()
+	headers.Set(jwe.AgreementPartyUInfoKey, []byte(mdocGeneratedNonce))
+	encrypted, err := jwe.Encrypt(payload, jwe.WithKey(jwa.ECDH_ES, verifier.EncryptionKey(), jwe.WithPerRecipientHeaders(headers)), jwe.WithContentEncryption(jwa.A256GCM))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.PostForm(request.ResponseUri, url.Values{"response": {string(encrypted)}})
+	if err != nil || resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatal(err, string(b))
+	}
+
+	state, _ := verifier.State(authorization.ID)
+	claims, _ := state.VerifiedAttributes["org.iso.18013.5.1.mDL"].(map[string]interface{})
+	elements, _ := claims["org.iso.18013.5.1"].(map[string]interface{})
+	if state.State != presentation.StateAccepted || elements["family_name"] != "Mustermann" || elements["given_name"] != nil {
+		t.Error(state)
+	}
+}
+
+// didJwk returns the did:jwk of the signer.
+func didJwk(t *testing.T, signer signing.Signer) string {
+	b, err := json.Marshal(signer.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "did:jwk:" + base64.RawURLEncoding.EncodeToString(b)
+}
+
+func postResponse(t *testing.T, request presentation.RequestObject, vpToken string, submission presentation.PresentationSubmission) int {
+	b, _ := json.Marshal(submission)
+	resp, err := http.PostForm(request.ResponseUri, url.Values{
+		"vp_token":                {vpToken},
+		"presentation_submission": {string(b)},
+		"state":                   {request.State},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func testLdpSigner(t *testing.T) *ldp.Signer {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := jwk.FromRaw(private)
+	public, _ := key.PublicKey()
+	multikey, err := did.EncodeMultikey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, _ := signing.NewJwkSigner(key)
+	ldpSigner, err := ldp.NewSigner(signer, "did:key:"+multikey+"#"+multikey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ldpSigner
+}
+
+var ldpDefinition = presentation.PresentationDefinition{
+	Description: presentation.Description{Id: "alumni"},
+	InputDescriptors: []presentation.InputDescriptor{
+		{
+			Description: presentation.Description{Id: "alumni_credential"},
+			Constraints: presentation.Constraints{
+				Fields: []presentation.Field{{Path: []string{"$.credentialSubject.alumniOf"}}},
+			},
+		},
+	},
+}
+
+func issueLdpCredential(t *testing.T, issuer *ldp.Signer, holder *ldp.Signer) map[string]interface{} {
+	credential, err := issuer.SignCredential(map[string]interface{}{
+		"@context":          []interface{}{types.ContextV2},
+		"type":              []interface{}{types.VerifiableCredentialType, "AlumniCredential"},
+		"issuer":            issuer.Holder(),
+		"credentialSubject": map[string]interface{}{"id": holder.Holder(), "alumniOf": "The School of Examples"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return credential
+}
+
+func TestVerifierLdpPresentation(t *testing.T) {
+	verifier := newTestVerifier(t)
+	issuer, holder := testLdpSigner(t), testLdpSigner(t)
+
+	credential := issueLdpCredential(t, issuer, holder)
+
+	submission := presentation.CreateSubmission("alumni", []presentation.Description{{Id: "alumni_credential", FormatType: "ldp_vc"}})
+
+	tampered := make(map[string]interface{}, len(credential))
+	for k, v := range credential {
+		tampered[k] = v
+	}
+	tampered["credentialSubject"] = map[string]interface{}{"id": holder.Holder(), "alumniOf": "The School of Forgery"}
+
+	for _, test := range []struct {
+		name       string
+		credential map[string]interface{}
+		nonce      string
+		status     int
+	}{
+		{"valid", credential, "", http.StatusOK},
+		{"replayed", credential, "other", http.StatusBadRequest},
+		{"tampered", tampered, "", http.StatusBadRequest},
+	} {
+		authorization, _ := verifier.CreateRequest(ldpDefinition, types.DirectPost)
+		request := authorization.Request
+		if test.nonce != "" {
+			request.Nonce = test.nonce
+		}
+
+		vp, err := request.PresentLdp([]map[string]interface{}{test.credential}, holder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vpToken, _ := json.Marshal(vp)
+
+		if status := postResponse(t, authorization.Request, string(vpToken), submission); status != test.status {
+			t.Error(test.name, status)
+		}
+	}
+}
+
+func TestVerifierJwtPresentation(t *testing.T) {
+	verifier := newTestVerifier(t)
+	issuer, holder, attacker := holderSigner(t), holderSigner(t), holderSigner(t)
+	issuerDid, holderDid := didJwk(t, issuer), didJwk(t, holder)
+
+	definition := presentation.PresentationDefinition{
+		Description: presentation.Description{Id: "identity"},
+		InputDescriptors: []presentation.InputDescriptor{
+			{
+				Description: presentation.Description{Id: "pid"},
+				Constraints: presentation.Constraints{
+					Fields: []presentation.Field{{Path: []string{"$.credentialSubject.family_name"}}},
+				},
+			},
+		},
+	}
+	submission := presentation.PresentationSubmission{
+		Id:           "1",
+		DefinitionId: "identity",
+		DescriptorMap: []presentation.Descriptor{{
+			Id:         "pid",
+			Format:     "jwt_vp_json",
+			Path:       "$",
+			PathNested: presentation.PathNested{Format: "jwt_vc_json", Path: "$.vp.verifiableCredential[0]"},
+		}},
+	}
+
+	document := map[string]interface{}{
+		"@context":          []interface{}{types.ContextV2},
+		"type":              []interface{}{types.VerifiableCredentialType},
+		"issuer":            issuerDid,
+		"validFrom":         "2024-01-01T00:00:00Z",
+		"credentialSubject": map[string]interface{}{"id": holderDid, "family_name": "Mustermann"},
+	}
+	credential, _ := signing.SignJwt(issuer, map[string]interface{}{"typ": types.MediaTypeVcJwt, "kid": issuerDid + "#0"}, document)
+	// the attacker names the own key for the issuer
+	forged, _ := signing.SignJwt(attacker, map[string]interface{}{"typ": types.MediaTypeVcJwt, "kid": didJwk(t, attacker) + "#0"}, document)
+
+	for _, test := range []struct {
+		name       string
+		credential string
+		status     int
+	}{
+		{"valid", credential, http.StatusOK},
+		{"forged", forged, http.StatusBadRequest},
+	} {
+		authorization, _ := verifier.CreateRequest(definition, types.DirectPost)
+		request := authorization.Request
+
+		vpToken, err := signing.SignJwt(holder, map[string]interface{}{"typ": "JWT", "kid": holderDid + "#0"}, map[string]interface{}{
+			"iss":   holderDid,
+			"aud":   request.ClientID,
+			"nonce": request.Nonce,
+			"vp": map[string]interface{}{
+				"@context":             []interface{}{types.ContextV1},
+				"type":                 []interface{}{types.VerifiablePresentationType},
+				"verifiableCredential": []interface{}{test.credential},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status := postResponse(t, request, vpToken, submission); status != test.status {
+			t.Error(test.name, status)
+		}
+	}
+}
+
+func TestVerifierSignedResponse(t *testing.T) {
+	issuer := newTestIssuer(t)
+	verifier := newTestVerifier(t)
+	verifier.Trust(issuer.Signer.PublicKey())
+	holder := holderSigner(t)
+	holderDid := didJwk(t, holder)
+
+	for _, test := range []struct {
+		name   string
+		iss    string
+		status int
+	}{
+		{"valid", holderDid, http.StatusOK},
+		{"forged", "did:web:wallet.example.com", http.StatusBadRequest},
+	} {
+		authorization, _ := verifier.CreateRequest(testDefinition, types.DirectPostJwt)
+		request := authorization.Request
+
+		response, err := signing.SignJwt(holder, map[string]interface{}{"kid": holderDid + "#0"}, map[string]interface{}{
+			"iss":      test.iss,
+			"aud":      request.ClientID,
+			"vp_token": presentSdJwt(t, issuer, holder, request.Nonce, request.ClientID),
+			"presentation_submission": presentation.PresentationSubmission{
+				Id:            "1",
+				DefinitionId:  request.PresentationDefinition.Id,
+				DescriptorMap: []presentation.Descriptor{{Id: "pid", Format: "vc+sd-jwt", Path: "$"}},
+			},
+			"state": request.State,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.PostForm(request.ResponseUri, url.Values{"response": {response}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Error(test.name, resp.StatusCode)
+		}
+	}
+}
+
+func TestVerifierConcurrentResponses(t *testing.T) {
+	verifier := newTestVerifier(t)
+	issuer, holder := testLdpSigner(t), testLdpSigner(t)
+
+	authorization, _ := verifier.CreateRequest(ldpDefinition, types.DirectPost)
+	request := authorization.Request
+	vp, err := request.PresentLdp([]map[string]interface{}{issueLdpCredential(t, issuer, holder)}, holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpToken, _ := json.Marshal(vp)
+	submission, _ := json.Marshal(presentation.CreateSubmission("alumni", []presentation.Description{{Id: "alumni_credential", FormatType: "ldp_vc"}}))
+	form := url.Values{"vp_token": {string(vpToken)}, "presentation_submission": {string(submission)}, "state": {request.State}}.Encode()
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest(http.MethodPost, string(ResponseEndpoint), strings.NewReader(form))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			<-start
+			verifier.response(w, r)
+			if w.Code == http.StatusOK {
+				accepted.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if accepted.Load() != 1 {
+		t.Error("the response of a state must be accepted once", accepted.Load())
+	}
+}
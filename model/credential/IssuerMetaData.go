@@ -3,6 +3,7 @@ package credential
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	"golang.org/x/exp/slices"
@@ -23,6 +24,8 @@ type IssuerMetadata struct {
 	CredentialIdentifiersSupported    bool                               `json:"credential_identifiers_supported,omitempty"`
 	SignedMetadata                    *string                            `json:"signed_metadata,omitempty"`
 	CredentialConfigurationsSupported map[string]CredentialConfiguration `json:"credential_configurations_supported"`
+	// Version is the detected specification version of the issuer, see ParseIssuerMetadata
+	Version Version `json:"-"`
 }
 
 type CredentialRespEnc struct {
@@ -45,6 +48,7 @@ type CredentialConfiguration struct {
 	ProofTypesSupported                  map[ProofVariant]ProofType `json:"proof_types_supported,omitempty"`
 	Display                              []LocalizedCredential      `json:"display,omitempty"`
 	Vct                                  *string                    `json:"vct,omitempty"`
+	Doctype                              *string                    `json:"doctype,omitempty"`
 	Order                                []string                   `json:"order,omitempty"`
 	Claims                               []MetadataClaim            `json:"claims,omitempty"`
 	CredentialMetadata                   *CredentialMetadata        `json:"credential_metadata,omitempty"`
@@ -57,7 +61,7 @@ type CredentialConfiguration struct {
 }
 
 type CredentialMetadata struct {
-	Claims  []oauth.Claim         `json:"claims,omitempty"`
+	Claims  []MetadataClaim       `json:"claims,omitempty"`
 	Display []LocalizedCredential `json:"display,omitempty"`
 }
 
@@ -69,7 +73,8 @@ type CredentialDefinition struct {
 
 type MetadataClaim struct {
 	oauth.Claim
-	Display Display `json:"display,omitempty"`
+	Display   []Display `json:"display,omitempty"`
+	ValueType string    `json:"value_type,omitempty"`
 }
 
 type CredentialSubject struct {
@@ -94,11 +99,13 @@ var ProofVariants = []ProofVariant{
 }
 
 type LocalizedCredential struct {
-	Name            string         `json:"name"`
-	Locale          string         `json:"locale"`
-	Logo            DescriptiveURL `json:"logo,omitempty"`
-	BackgroundColor string         `json:"background_color,omitempty"`
-	TextColor       string         `json:"text_color,omitempty"`
+	Name            string          `json:"name"`
+	Locale          string          `json:"locale"`
+	Logo            DescriptiveURL  `json:"logo,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	BackgroundImage *DescriptiveURL `json:"background_image,omitempty"`
+	TextColor       string          `json:"text_color,omitempty"`
 }
 
 type DescriptiveURL struct {
@@ -106,9 +113,32 @@ type DescriptiveURL struct {
 	AlternativeText string `json:"alt_text"`
 }
 
+// UnmarshalJSON accepts uri as well, which is used by OID4VCI 1.0 instead of url.
+func (descriptiveURL *DescriptiveURL) UnmarshalJSON(b []byte) error {
+	var u struct {
+		URL             string `json:"url"`
+		URI             string `json:"uri"`
+		AlternativeText string `json:"alt_text"`
+	}
+	if err := json.Unmarshal(b, &u); err != nil {
+		return err
+	}
+
+	descriptiveURL.URL = u.URL
+	if descriptiveURL.URL == "" {
+		descriptiveURL.URL = u.URI
+	}
+	descriptiveURL.AlternativeText = u.AlternativeText
+	return nil
+}
+
+/*
+Sends the credential request in the shape of the issuer version. Draft 13 issuers get the 1.0 request together with
+the Draft 13 description of the credential, so that issuers in between both versions understand it.
+*/
 func (metadata *IssuerMetadata) CredentialRequest(request CredentialRequest, token oauth.Token) (*CredentialResponse, error) {
 
-	b, err := json.Marshal(request)
+	b, err := metadata.marshalCredentialRequest(request)
 
 	if err != nil {
 		return nil, err
@@ -120,17 +150,82 @@ func (metadata *IssuerMetadata) CredentialRequest(request CredentialRequest, tok
 		return nil, err
 	}
 
-	var response CredentialResponse
-	err = json.Unmarshal(b, &response)
+	return ParseCredentialResponse(b)
+}
+
+func (metadata *IssuerMetadata) marshalCredentialRequest(request CredentialRequest) ([]byte, error) {
+	switch metadata.Version {
+	case VersionDraft11:
+		draft, err := request.ToDraft11(*metadata)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(draft)
+	case VersionDraft13:
+		draft, err := request.ToDraft13(*metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Format = draft.Format
+		request.Vct = draft.Vct
+		request.Doctype = draft.Doctype
+		return json.Marshal(struct {
+			CredentialRequest
+			CredentialDefinition *CredentialDefinitionDraft `json:"credential_definition,omitempty"`
+			Claims               json.RawMessage            `json:"claims,omitempty"`
+		}{request, draft.CredentialDefinition, draft.Claims})
+	}
+	return json.Marshal(request)
+}
+
+func (metadata *IssuerMetadata) DeferredCredentialRequest(request CredentialDeferredRequest, token oauth.Token) (*CredentialResponse, error) {
+
+	if metadata.DeferredCredentialEndpoint == nil || *metadata.DeferredCredentialEndpoint == "" {
+		return nil, errors.New("issuer has no deferred credential endpoint")
+	}
+
+	b, err := json.Marshal(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = helper.Post(*metadata.DeferredCredentialEndpoint, b, helper.ApplicationJson, &token.AccessToken)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &response, nil
+	return ParseCredentialResponse(b)
+}
+
+func (metadata *IssuerMetadata) Notify(request NotificationRequest, token oauth.Token) error {
+
+	if metadata.NotificationEndpoint == nil || *metadata.NotificationEndpoint == "" {
+		return errors.New("issuer has no notification endpoint")
+	}
+
+	b, err := json.Marshal(request)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = helper.Post(*metadata.NotificationEndpoint, b, helper.ApplicationJson, &token.AccessToken)
+
+	return err
 }
 
 func (metadata *IssuerMetadata) FindFittingAuthorizationServer(grant oauth.GrantType) (*oauth.OpenIdConfiguration, error) {
+	return metadata.FindAuthorizationServer(grant, "")
+}
+
+/*
+Finds the authorization server supporting the grant. If the offer contains an authorization_server hint, only this
+server is used and it must be listed in the issuer metadata.
+*/
+func (metadata *IssuerMetadata) FindAuthorizationServer(grant oauth.GrantType, hint string) (*oauth.OpenIdConfiguration, error) {
 
 	if metadata.AuthorizationServers == nil || len(metadata.AuthorizationServers) == 0 {
 		if metadata.AuthorizationServers != nil {
@@ -139,7 +234,15 @@ func (metadata *IssuerMetadata) FindFittingAuthorizationServer(grant oauth.Grant
 		metadata.AuthorizationServers = append(metadata.AuthorizationServers, metadata.CredentialIssuer)
 	}
 
-	for _, server := range metadata.AuthorizationServers {
+	servers := metadata.AuthorizationServers
+	if hint != "" {
+		if !slices.Contains(servers, hint) {
+			return nil, fmt.Errorf("authorization server %s is not listed in issuer metadata", hint)
+		}
+		servers = []string{hint}
+	}
+
+	for _, server := range servers {
 		b, err := helper.Get(strings.Join([]string{server, ".well-known", "openid-configuration"}, "/"))
 
 		if err == nil {
@@ -0,0 +1,198 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const schemaLocation = "urn:oid4vci:credential-configuration:schema"
+
+var ErrSchemaInvalid = errors.New("invalid claim schema")
+
+// claims of the jwt and sd-jwt envelope which are not part of the credential claims
+var registeredClaims = []string{"iss", "iat", "nbf", "exp", "jti", "cnf", "vct", "vct#integrity", "status", "_sd_alg"}
+
+// ClaimIssue is a single schema violation. Path points to the offending claim, for missing claims to the claim itself.
+type ClaimIssue struct {
+	Path    oauth.ClaimPath `json:"path"`
+	Message string          `json:"message"`
+}
+
+// ClaimValidationError collects all schema violations of the claims.
+type ClaimValidationError struct {
+	Issues []ClaimIssue `json:"issues"`
+}
+
+func (e *ClaimValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, fmt.Sprintf("%s: %s", issue.Path, issue.Message))
+	}
+	return "claims do not match schema: " + strings.Join(messages, "; ")
+}
+
+/*
+ValidateClaims applies the schema of the configuration to the claims before they are issued. The claims are the
+credentialSubject for W3C formats, the reported paths are nevertheless 1.0 claim paths of the credential
+(prefixed with credentialSubject). Returns a *ClaimValidationError on violations, nil if no schema is configured.
+*/
+func (configuration *CredentialConfiguration) ValidateClaims(claims map[string]interface{}) error {
+	if configuration.Schema == nil {
+		return nil
+	}
+
+	var prefix oauth.ClaimPath
+	if isW3cFormat(configuration.Format) {
+		prefix = credentialSubjectPath
+	}
+	return validateSchema(configuration.Schema, claims, prefix)
+}
+
+/*
+ValidateCredential validates the claims of a decoded credential, e.g. the disclosed claims of an SD-JWT or the
+payload of a jwt vc. Envelope claims like iss or cnf are not validated.
+*/
+func (configuration *CredentialConfiguration) ValidateCredential(credential map[string]interface{}) error {
+	if isW3cFormat(configuration.Format) {
+		root := credential
+		if vc, ok := credential["vc"].(map[string]interface{}); ok {
+			root = vc
+		}
+		subject, ok := root["credentialSubject"].(map[string]interface{})
+		if !ok {
+			return &ClaimValidationError{Issues: []ClaimIssue{{Path: credentialSubjectPath, Message: "missing"}}}
+		}
+		return configuration.ValidateClaims(subject)
+	}
+
+	claims := make(map[string]interface{}, len(credential))
+	for name, value := range credential {
+		claims[name] = value
+	}
+	for _, name := range registeredClaims {
+		delete(claims, name)
+	}
+	return configuration.ValidateClaims(claims)
+}
+
+// ValidateSchema applies a JSON schema (e.g. of SD-JWT VC type metadata) to the claims.
+func ValidateSchema(schema map[string]interface{}, claims interface{}) error {
+	return validateSchema(schema, claims, nil)
+}
+
+func validateSchema(schema map[string]interface{}, claims interface{}, prefix oauth.ClaimPath) error {
+	compiled, err := compileSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	instance, err := normalize(claims)
+	if err != nil {
+		return fmt.Errorf("can not read claims: %w", err)
+	}
+
+	err = compiled.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	var validation ClaimValidationError
+	printer := message.NewPrinter(language.English)
+	for _, leaf := range leafErrors(validationErr) {
+		path := append(append(oauth.ClaimPath{}, prefix...), instancePath(instance, leaf.InstanceLocation)...)
+
+		switch k := leaf.ErrorKind.(type) {
+		case *kind.Required:
+			for _, missing := range k.Missing {
+				validation.Issues = append(validation.Issues, ClaimIssue{Path: append(append(oauth.ClaimPath{}, path...), missing), Message: "missing"})
+			}
+		case *kind.AdditionalProperties:
+			for _, property := range k.Properties {
+				validation.Issues = append(validation.Issues, ClaimIssue{Path: append(append(oauth.ClaimPath{}, path...), property), Message: "not allowed"})
+			}
+		default:
+			validation.Issues = append(validation.Issues, ClaimIssue{Path: path, Message: leaf.ErrorKind.LocalizedString(printer)})
+		}
+	}
+	return &validation
+}
+
+func compileSchema(schema map[string]interface{}) (*jsonschema.Schema, error) {
+	doc, err := normalize(schema)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchemaInvalid, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	if err = compiler.AddResource(schemaLocation, doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchemaInvalid, err)
+	}
+
+	compiled, err := compiler.Compile(schemaLocation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchemaInvalid, err)
+	}
+	return compiled, nil
+}
+
+// normalize converts v into the generic json representation of the validator (json.Number instead of float64 etc.).
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(b))
+}
+
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	leafs := make([]*jsonschema.ValidationError, 0, len(err.Causes))
+	for _, cause := range err.Causes {
+		leafs = append(leafs, leafErrors(cause)...)
+	}
+	return leafs
+}
+
+// instancePath converts the json pointer tokens of the validator into a claim path, array indices become integers.
+func instancePath(instance interface{}, location []string) oauth.ClaimPath {
+	path := make(oauth.ClaimPath, 0, len(location))
+	current := instance
+	for _, token := range location {
+		switch value := current.(type) {
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err == nil {
+				path = append(path, index)
+				current = nil
+				if index >= 0 && index < len(value) {
+					current = value[index]
+				}
+				continue
+			}
+		case map[string]interface{}:
+			current = value[token]
+		}
+		path = append(path, token)
+	}
+	return path
+}
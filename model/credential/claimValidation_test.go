@@ -0,0 +1,81 @@
+package credential
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const claimSchema = `{
+	"type": "object",
+	"required": ["given_name", "address"],
+	"properties": {
+		"given_name": {"type": "string"},
+		"address": {
+			"type": "object",
+			"required": ["country"],
+			"properties": {"country": {"type": "string", "minLength": 2}}
+		},
+		"nationalities": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func TestValidateClaims(t *testing.T) {
+	var schema map[string]interface{}
+	json.Unmarshal([]byte(claimSchema), &schema)
+	configuration := CredentialConfiguration{Format: "vc+sd-jwt", Schema: schema}
+
+	if err := configuration.ValidateClaims(map[string]interface{}{"given_name": "Erika", "address": map[string]interface{}{"country": "DE"}}); err != nil {
+		t.Error(err)
+	}
+
+	err := configuration.ValidateClaims(map[string]interface{}{
+		"address":       map[string]interface{}{"country": "D"},
+		"nationalities": []interface{}{"DE", 1},
+	})
+
+	var validation *ClaimValidationError
+	if !errors.As(err, &validation) {
+		t.Fatal(err)
+	}
+
+	expected := []oauth.ClaimPath{{"given_name"}, {"address", "country"}, {"nationalities", 1}}
+	if len(validation.Issues) != len(expected) {
+		t.Error(validation.Issues)
+	}
+	for _, path := range expected {
+		found := false
+		for _, issue := range validation.Issues {
+			found = found || reflect.DeepEqual(issue.Path, path)
+		}
+		if !found {
+			t.Error("missing issue for", path)
+		}
+	}
+}
+
+func TestValidateCredential(t *testing.T) {
+	var schema map[string]interface{}
+	json.Unmarshal([]byte(`{"type": "object", "additionalProperties": false, "properties": {"degree": {"type": "string"}}}`), &schema)
+
+	w3c := CredentialConfiguration{Format: "jwt_vc_json", Schema: schema}
+	err := w3c.ValidateCredential(map[string]interface{}{"iss": "https://issuer.example.com", "vc": map[string]interface{}{"credentialSubject": map[string]interface{}{"degree": "BSc", "gpa": 3.8}}})
+
+	var validation *ClaimValidationError
+	if !errors.As(err, &validation) || len(validation.Issues) != 1 || !reflect.DeepEqual(validation.Issues[0].Path, oauth.ClaimPath{"credentialSubject", "gpa"}) {
+		t.Error(err)
+	}
+
+	sdJwt := CredentialConfiguration{Format: "vc+sd-jwt", Schema: schema}
+	if err := sdJwt.ValidateCredential(map[string]interface{}{"iss": "https://issuer.example.com", "vct": "test", "degree": "BSc"}); err != nil {
+		t.Error(err)
+	}
+
+	invalid := CredentialConfiguration{Format: "vc+sd-jwt", Schema: map[string]interface{}{"type": 5}}
+	if err := invalid.ValidateClaims(map[string]interface{}{}); !errors.Is(err, ErrSchemaInvalid) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,92 @@
+package credential
+
+import (
+	"fmt"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+// DescribedClaim is a claim of a credential together with its description from the issuer metadata.
+type DescribedClaim struct {
+	MetadataClaim
+	Values []interface{}
+}
+
+// ClaimDescriptions returns the claims of the configuration, OID4VCI 1.0 moved them into credential_metadata.
+func (configuration *CredentialConfiguration) ClaimDescriptions() []MetadataClaim {
+	if len(configuration.Claims) == 0 && configuration.CredentialMetadata != nil {
+		return configuration.CredentialMetadata.Claims
+	}
+	return configuration.Claims
+}
+
+/*
+DescribeClaims resolves every described claim in the decoded credential json (e.g. the disclosed claims of an SD-JWT
+or the claims of a jwt). JWT VCs are resolved within their vc claim. Claims missing in the credential are skipped.
+*/
+func (configuration *CredentialConfiguration) DescribeClaims(credential map[string]interface{}) []DescribedClaim {
+	root := credential
+	if vc, ok := credential["vc"].(map[string]interface{}); ok && isW3cFormat(configuration.Format) {
+		root = vc
+	}
+
+	described := make([]DescribedClaim, 0)
+	for _, claim := range configuration.ClaimDescriptions() {
+		values, err := claim.Path.Resolve(root)
+		if err != nil {
+			continue
+		}
+		described = append(described, DescribedClaim{MetadataClaim: claim, Values: values})
+	}
+	return described
+}
+
+/*
+AuthorizationDetails creates the openid_credential authorization details for the configuration which request only
+the given claims. Every path must be described in the metadata, if the configuration describes claims at all.
+*/
+func (configuration *CredentialConfiguration) AuthorizationDetails(id string, claims ...oauth.ClaimPath) (*oauth.AuthorizationDetails, error) {
+	details := oauth.AuthorizationDetails{
+		Type:                      oauth.AuthorizationDetailsTypeOpenIdCredential,
+		CredentialConfigurationID: id,
+	}
+
+	descriptions := configuration.ClaimDescriptions()
+	for _, path := range claims {
+		if len(descriptions) > 0 && !describes(descriptions, path) {
+			return nil, fmt.Errorf("claim %s not described by credential configuration %s", path, id)
+		}
+		details.Claims = append(details.Claims, oauth.Claim{Path: path})
+	}
+	return &details, nil
+}
+
+/*
+SelectClaims restricts the claims to issue to the claims requested in authorization details. Mandatory claims of the
+configuration are always kept, without requested claims everything is issued.
+*/
+func (configuration *CredentialConfiguration) SelectClaims(data map[string]interface{}, requested []oauth.Claim) map[string]interface{} {
+	if len(requested) == 0 {
+		return data
+	}
+
+	paths := make([]oauth.ClaimPath, 0, len(requested))
+	for _, claim := range requested {
+		paths = append(paths, claim.Path)
+	}
+	for _, claim := range configuration.ClaimDescriptions() {
+		if claim.Mandatory {
+			paths = append(paths, claim.Path)
+		}
+	}
+	return oauth.Select(data, paths...)
+}
+
+func describes(descriptions []MetadataClaim, path oauth.ClaimPath) bool {
+	for _, description := range descriptions {
+		if description.Path.HasPrefix(path) || path.HasPrefix(description.Path) || description.Path.Matches(path) || path.Matches(description.Path) {
+			return true
+		}
+	}
+	return false
+}
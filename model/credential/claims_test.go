@@ -0,0 +1,48 @@
+package credential
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const v1Configuration = `{
+	"format": "dc+sd-jwt",
+	"vct": "https://credentials.example.com/identity_credential",
+	"credential_metadata": {
+		"claims": [
+			{"path": ["given_name"], "mandatory": true, "display": [{"name": "Given Name", "locale": "en-US"}, {"name": "Vorname", "locale": "de-DE"}]},
+			{"path": ["address", "street_address"]},
+			{"path": ["nationalities", null]}
+		]
+	}
+}`
+
+func TestDescribeClaims(t *testing.T) {
+	var configuration CredentialConfiguration
+	if err := json.Unmarshal([]byte(v1Configuration), &configuration); err != nil {
+		t.Fatal(err)
+	}
+
+	var data map[string]interface{}
+	json.Unmarshal([]byte(`{"given_name": "Erika", "nationalities": ["DE", "FR"]}`), &data)
+
+	described := configuration.DescribeClaims(data)
+	if len(described) != 2 || described[0].Values[0] != "Erika" || len(described[0].Display) != 2 || len(described[1].Values) != 2 {
+		t.Error(described)
+	}
+
+	if _, err := configuration.AuthorizationDetails("id", oauth.ClaimPath{"address"}, oauth.ClaimPath{"nationalities", 0}); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := configuration.AuthorizationDetails("id", oauth.ClaimPath{"birthdate"}); err == nil {
+		t.Error("undescribed claim requested")
+	}
+
+	selected := configuration.SelectClaims(data, []oauth.Claim{{Path: oauth.ClaimPath{"nationalities", 1}}})
+	if len(selected) != 2 || selected["given_name"] != "Erika" || len(selected["nationalities"].([]interface{})) != 1 {
+		t.Error(selected)
+	}
+}
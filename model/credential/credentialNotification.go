@@ -0,0 +1,20 @@
+package credential
+
+type NotificationEvent string
+
+const (
+	CredentialAccepted NotificationEvent = "credential_accepted"
+	CredentialFailure  NotificationEvent = "credential_failure"
+	CredentialDeleted  NotificationEvent = "credential_deleted"
+)
+
+const (
+	InvalidNotificationId      = "invalid_notification_id"
+	InvalidNotificationRequest = "invalid_notification_request"
+)
+
+type NotificationRequest struct {
+	NotificationId   string            `json:"notification_id"`
+	Event            NotificationEvent `json:"event"`
+	EventDescription string            `json:"event_description,omitempty"`
+}
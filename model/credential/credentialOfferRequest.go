@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
 )
@@ -21,6 +22,31 @@ type TxCode struct {
 	Description string `json:"description,omitempty"`
 }
 
+var ErrTxCodeInvalid = errors.New("tx_code does not match the offer")
+
+/*
+Validate checks a user entered transaction code against the length and input mode announced in the offer, so that
+typos do not burn one of the limited attempts at the token endpoint.
+*/
+func (txCode *TxCode) Validate(value string) error {
+	if value == "" {
+		return fmt.Errorf("%w: tx_code missing", ErrTxCodeInvalid)
+	}
+
+	if txCode.Length > 0 && utf8.RuneCountInString(value) != txCode.Length {
+		return fmt.Errorf("%w: expected %d characters", ErrTxCodeInvalid, txCode.Length)
+	}
+
+	if txCode.InputMode == "" || txCode.InputMode == "numeric" {
+		for _, c := range value {
+			if c < '0' || c > '9' {
+				return fmt.Errorf("%w: only digits allowed", ErrTxCodeInvalid)
+			}
+		}
+	}
+	return nil
+}
+
 type PreAuthorizedCode struct {
 	PreAuthorizationCode    string  `json:"pre-authorized_code"`
 	TxCode                  *TxCode `json:"tx_code,omitempty"`
@@ -29,7 +55,8 @@ type PreAuthorizedCode struct {
 }
 
 type AuthorizationCode struct {
-	IssuerState string `json:"issuer_state"`
+	IssuerState             string `json:"issuer_state"`
+	AuthorizationServerHint string `json:"authorization_server,omitempty"`
 }
 
 type CredentialOfferParameters struct {
@@ -57,41 +84,140 @@ func (offerParameter *CredentialOfferParameters) CreateOfferLink() (*CredentialO
 }
 
 /*
-Extracts the Parameters of the offering link
+Creates an offer link which references the offer parameters hosted by the issuer under offerUri.
+Keeps QR codes small for offers with many configurations.
+*/
+func CreateOfferUriLink(offerUri string) (*CredentialOffer, error) {
+	u, err := url.Parse(offerUri)
+	if err != nil || !u.IsAbs() || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("credential_offer_uri must be an absolute http(s) url: %s", offerUri)
+	}
+
+	return &CredentialOffer{
+		CredentialOfferUri: fmt.Sprintf("openid-credential-offer://?credential_offer_uri=%s", url.QueryEscape(offerUri)),
+	}, nil
+}
+
+var (
+	ErrOfferLinkInvalid   = errors.New("invalid credential offer link")
+	ErrOfferLinkMissing   = errors.New("credential offer link contains neither credential_offer nor credential_offer_uri")
+	ErrOfferLinkAmbiguous = errors.New("credential offer link contains both credential_offer and credential_offer_uri")
+	ErrOfferUriInvalid    = errors.New("credential_offer_uri must be an absolute http(s) url")
+	ErrOfferInvalid       = errors.New("invalid credential offer")
+)
+
+// OfferLinkError reports which link failed to parse, Err is one of the ErrOffer* errors.
+type OfferLinkError struct {
+	Link   string
+	Err    error
+	Reason string
+}
+
+func (e *OfferLinkError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s: %s", e.Err.Error(), e.Reason)
+	}
+	return e.Err.Error()
+}
+
+func (e *OfferLinkError) Unwrap() error {
+	return e.Err
+}
+
+/*
+ParseOfferLink extracts the credential_offer or credential_offer_uri parameter of an offer link. Any scheme is
+accepted (openid-credential-offer://, haip://, custom schemes and https universal links). Exactly one of the
+returned values is set.
+*/
+func ParseOfferLink(link string) (offer string, offerUri string, err error) {
+	link = strings.TrimSpace(link)
+
+	// the offer object itself instead of a link
+	if strings.HasPrefix(link, "{") {
+		return link, "", nil
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkInvalid, Reason: err.Error()}
+	}
+
+	if u.Scheme == "" {
+		return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkInvalid, Reason: "scheme missing"}
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil && len(query) == 0 {
+		return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkInvalid, Reason: err.Error()}
+	}
+
+	_, hasOffer := query["credential_offer"]
+	_, hasOfferUri := query["credential_offer_uri"]
+
+	switch {
+	case hasOffer && hasOfferUri:
+		return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkAmbiguous}
+	case hasOffer:
+		offer = query.Get("credential_offer")
+		if offer == "" {
+			return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkInvalid, Reason: "credential_offer empty"}
+		}
+		return offer, "", nil
+	case hasOfferUri:
+		offerUri = query.Get("credential_offer_uri")
+		ref, err := url.Parse(offerUri)
+		if err != nil || !ref.IsAbs() || (ref.Scheme != "https" && ref.Scheme != "http") || ref.Host == "" {
+			return "", "", &OfferLinkError{Link: link, Err: ErrOfferUriInvalid, Reason: offerUri}
+		}
+		return "", offerUri, nil
+	}
+	return "", "", &OfferLinkError{Link: link, Err: ErrOfferLinkMissing}
+}
+
+/*
+Extracts the Parameters of the offering link. Offers passed by reference are fetched from the credential_offer_uri.
+Draft 11 offers with credential objects return a *MetadataRequiredError, which resolves them with the issuer metadata.
 */
 func (offering *CredentialOffer) GetOfferParameters() (*CredentialOfferParameters, error) {
-	var newCredentialOfferObject CredentialOfferParameters
 	var rawObject []byte
 
-	if offering.CredentialOffer != "" {
-		slice := strings.Split(offering.CredentialOffer, "credential_offer=")
-		unescaped, err := url.QueryUnescape(slice[len(slice)-1])
-		if err != nil {
-			return nil, err
-		}
-		rawObject = []byte(unescaped)
+	if offering.CredentialOffer != "" && offering.CredentialOfferUri != "" {
+		return nil, &OfferLinkError{Err: ErrOfferLinkAmbiguous}
+	}
 
-	} else if offering.CredentialOfferUri != "" {
-		slice := strings.Split(offering.CredentialOfferUri, "credential_offer_uri=")
-		unescape, err := url.QueryUnescape(slice[len(slice)-1])
-		if err != nil {
-			return nil, err
-		}
+	link := offering.CredentialOffer
+	if link == "" {
+		link = offering.CredentialOfferUri
+	}
+
+	if link == "" {
+		return nil, &OfferLinkError{Err: ErrOfferLinkMissing}
+	}
+
+	offer, offerUri, err := ParseOfferLink(link)
+	if err != nil {
+		return nil, err
+	}
 
-		rawObject, err = helper.Get(unescape)
+	if offerUri != "" {
+		rawObject, err = helper.Get(offerUri)
 		if err != nil {
 			return nil, err
 		}
-
 	} else {
-		return nil, fmt.Errorf("no valid credentialOffer: %v", offering)
+		rawObject = []byte(offer)
+	}
+
+	params, _, err := ParseOfferParameters(rawObject, nil)
+	var metadataRequired *MetadataRequiredError
+	if errors.As(err, &metadataRequired) {
+		return nil, &OfferLinkError{Link: link, Err: metadataRequired}
 	}
-	err := json.Unmarshal(rawObject, &newCredentialOfferObject)
 	if err != nil {
-		return nil, fmt.Errorf("error occured while unmarshal credentialOffer: %w", err)
+		return nil, &OfferLinkError{Link: link, Err: ErrOfferInvalid, Reason: err.Error()}
 	}
 
-	return &newCredentialOfferObject, err
+	return params, nil
 }
 
 func (offerParameter *CredentialOfferParameters) GetIssuerMetadata() (*IssuerMetadata, error) {
@@ -103,13 +229,7 @@ func (offerParameter *CredentialOfferParameters) GetIssuerMetadata() (*IssuerMet
 		if err != nil {
 			return nil, err
 		}
-		var metadata IssuerMetadata
-		err = json.Unmarshal(b, &metadata)
-
-		if err != nil {
-			return nil, err
-		}
-		return &metadata, nil
+		return ParseIssuerMetadata(b)
 	}
 	return nil, errors.New("no issuer metadata found")
 }
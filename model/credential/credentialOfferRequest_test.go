@@ -2,8 +2,10 @@ package credential
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -114,3 +116,65 @@ func Test_GetIssuerMetadata(t *testing.T) {
 	}
 
 }
+
+func Test_ParseOfferLink(t *testing.T) {
+	const offer = `{"credential_issuer":"https://issuer.example.com","credential_configuration_ids":["A"]}`
+	escaped := url.QueryEscape(offer)
+
+	for _, link := range []string{
+		"openid-credential-offer://?credential_offer=" + escaped,
+		"haip://?credential_offer=" + escaped,
+		"custom-wallet://offer?foo=bar&credential_offer=" + escaped + "&baz=1",
+		"https://wallet.example.com/offer?credential_offer=" + escaped,
+	} {
+		off, err := (&CredentialOffer{CredentialOffer: link}).GetOfferParameters()
+		if err != nil || off.CredentialIssuer != "https://issuer.example.com" {
+			t.Error(link, err)
+		}
+	}
+
+	offerUri := "https://issuer.example.com/offer?x=credential_offer=1"
+	_, uri, err := ParseOfferLink("openid-credential-offer://?credential_offer_uri=" + url.QueryEscape(offerUri))
+	if err != nil || uri != offerUri {
+		t.Error(err)
+	}
+
+	_, _, err = ParseOfferLink("openid-credential-offer://?credential_offer=" + escaped + "&credential_offer_uri=" + url.QueryEscape(offerUri))
+	if !errors.Is(err, ErrOfferLinkAmbiguous) {
+		t.Error(err)
+	}
+
+	_, _, err = ParseOfferLink("openid-credential-offer://?foo=bar")
+	if !errors.Is(err, ErrOfferLinkMissing) {
+		t.Error(err)
+	}
+
+	_, _, err = ParseOfferLink("openid-credential-offer://?credential_offer_uri=file%3A%2F%2Fetc")
+	if !errors.Is(err, ErrOfferUriInvalid) {
+		t.Error(err)
+	}
+
+	var linkErr *OfferLinkError
+	_, err = (&CredentialOffer{CredentialOffer: "openid-credential-offer://?credential_offer=%7Bbroken"}).GetOfferParameters()
+	if !errors.As(err, &linkErr) || !errors.Is(err, ErrOfferInvalid) {
+		t.Error(err)
+	}
+}
+
+func Test_TxCodeValidate(t *testing.T) {
+	numeric := TxCode{InputMode: "numeric", Length: 4}
+
+	if numeric.Validate("1234") != nil {
+		t.Error()
+	}
+
+	if !errors.Is(numeric.Validate("123"), ErrTxCodeInvalid) || numeric.Validate("12a4") == nil || numeric.Validate("") == nil {
+		t.Error()
+	}
+
+	text := TxCode{InputMode: "text", Length: 5}
+
+	if text.Validate("aB3xZ") != nil || text.Validate("aB3x") == nil {
+		t.Error()
+	}
+}
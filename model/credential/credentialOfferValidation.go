@@ -0,0 +1,97 @@
+package credential
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// OfferIssue is a single problem of a credential offer. Field is the json name of the offending offer parameter.
+type OfferIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// OfferValidationError collects all issues of a credential offer, so that they can be shown at once.
+type OfferValidationError struct {
+	Issues []OfferIssue `json:"issues"`
+}
+
+func (e *OfferValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+	}
+	return "invalid credential offer: " + strings.Join(messages, "; ")
+}
+
+func (e *OfferValidationError) add(field string, format string, args ...interface{}) {
+	e.Issues = append(e.Issues, OfferIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+/*
+Validate checks the offer against the metadata of its issuer: every credential configuration id must be supported and
+the grants must be well-formed. All issues are reported in one *OfferValidationError.
+*/
+func (offerParameter *CredentialOfferParameters) Validate(metadata IssuerMetadata) error {
+	var validation OfferValidationError
+
+	if offerParameter.CredentialIssuer == "" {
+		validation.add("credential_issuer", "missing")
+	} else if metadata.CredentialIssuer != "" && strings.TrimSuffix(offerParameter.CredentialIssuer, "/") != strings.TrimSuffix(metadata.CredentialIssuer, "/") {
+		validation.add("credential_issuer", "%s does not match issuer metadata %s", offerParameter.CredentialIssuer, metadata.CredentialIssuer)
+	}
+
+	if len(offerParameter.Credentials) == 0 {
+		validation.add("credential_configuration_ids", "no credential configuration offered")
+	}
+
+	for i, id := range offerParameter.Credentials {
+		if slices.Index(offerParameter.Credentials, id) != i {
+			validation.add("credential_configuration_ids", "%s offered more than once", id)
+			continue
+		}
+		if _, ok := metadata.CredentialConfigurationsSupported[id]; !ok {
+			validation.add("credential_configuration_ids", "%s not supported by issuer", id)
+		}
+	}
+
+	if grant := offerParameter.Grants.PreAuthorizedCode; grant != nil {
+		const field = "grants.urn:ietf:params:oauth:grant-type:pre-authorized_code"
+
+		if grant.PreAuthorizationCode == "" {
+			validation.add(field+".pre-authorized_code", "missing")
+		}
+
+		if grant.TxCode != nil {
+			if grant.TxCode.Length < 0 {
+				validation.add(field+".tx_code.length", "must not be negative")
+			}
+			if mode := grant.TxCode.InputMode; mode != "" && mode != "numeric" && mode != "text" {
+				validation.add(field+".tx_code.input_mode", "unsupported input mode %s", mode)
+			}
+		}
+
+		validateAuthorizationServerHint(&validation, field+".authorization_server", grant.AuthorizationServerHint, metadata)
+	}
+
+	if grant := offerParameter.Grants.AuthorizationCode; grant != nil {
+		validateAuthorizationServerHint(&validation, "grants.authorization_code.authorization_server", grant.AuthorizationServerHint, metadata)
+	}
+
+	if len(validation.Issues) > 0 {
+		return &validation
+	}
+	return nil
+}
+
+func validateAuthorizationServerHint(validation *OfferValidationError, field string, hint string, metadata IssuerMetadata) {
+	if hint == "" {
+		return
+	}
+
+	if !slices.Contains(metadata.AuthorizationServers, hint) {
+		validation.add(field, "%s is not an authorization server of the issuer", hint)
+	}
+}
@@ -0,0 +1,45 @@
+package credential
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOfferValidation(t *testing.T) {
+	metadata := IssuerMetadata{
+		CredentialIssuer:     "https://issuer.example.com",
+		AuthorizationServers: []string{"https://auth.example.com"},
+		CredentialConfigurationsSupported: map[string]CredentialConfiguration{
+			"A": {Format: "vc+sd-jwt"},
+		},
+	}
+
+	offer := CredentialOfferParameters{
+		CredentialIssuer: "https://issuer.example.com/",
+		Credentials:      []string{"A"},
+		Grants: Grants{
+			PreAuthorizedCode: &PreAuthorizedCode{
+				PreAuthorizationCode:    "code",
+				AuthorizationServerHint: "https://auth.example.com",
+			},
+		},
+	}
+
+	if err := offer.Validate(metadata); err != nil {
+		t.Error(err)
+	}
+
+	offer.Credentials = []string{"A", "B"}
+	offer.Grants.PreAuthorizedCode = &PreAuthorizedCode{AuthorizationServerHint: "https://other.example.com"}
+
+	err := offer.Validate(metadata)
+
+	var validation *OfferValidationError
+	if !errors.As(err, &validation) {
+		t.Fatal("validation error expected")
+	}
+
+	if len(validation.Issues) != 3 {
+		t.Error(validation.Issues)
+	}
+}
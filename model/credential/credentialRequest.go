@@ -3,9 +3,13 @@ package credential
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
 	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
 	jwtext "github.com/eclipse-xfsc/ssi-jwt/v2"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/sirupsen/logrus"
@@ -17,6 +21,8 @@ const (
 	ProofTypeLDPvP = "ldp_vp"
 )
 
+const ProofJwtType = "openid4vci-proof+jwt"
+
 type CredentialRequest struct {
 	///OID 1.0
 	CredentialConfigurationId string `json:"credential_configuration_id,omitempty"`
@@ -26,6 +32,7 @@ type CredentialRequest struct {
 	Format               string        `json:"format,omitempty"`
 	CredentialIdentifier string        `json:"credential_identifier,omitempty"`
 	Vct                  *string       `json:"vct,omitempty"`
+	Doctype              *string       `json:"doctype,omitempty"`
 	Claims               []oauth.Claim `json:"claims,omitempty"`
 	Order                []string      `json:"order,omitempty"`
 }
@@ -44,6 +51,45 @@ type JwtKeyProofType struct {
 	IssuedAt string `json:"iat"`
 }
 
+/*
+Creates a jwt key proof for the credential request. Keys with a DID based key id are referenced by kid,
+all others are embedded as jwk header.
+*/
+func CreateJwtProof(signer signing.Signer, audience string, cNonce string, issuer string) (*Proof, error) {
+	headers := map[string]interface{}{
+		"typ": ProofJwtType,
+	}
+
+	if kid := signer.KeyID(); strings.HasPrefix(kid, "did:") {
+		headers["kid"] = kid
+	} else {
+		headers["jwk"] = signer.PublicKey()
+	}
+
+	claims := map[string]interface{}{
+		"aud": audience,
+		"iat": time.Now().Unix(),
+	}
+
+	if cNonce != "" {
+		claims["nonce"] = cNonce
+	}
+
+	if issuer != "" {
+		claims["iss"] = issuer
+	}
+
+	token, err := signing.SignJwt(signer, headers, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Proof{
+		ProofType: ProofTypeJWT,
+		Jwt:       &token,
+	}, nil
+}
+
 func (proof *Proof) GetProof() *string {
 	if proof.ProofType == ProofTypeJWT {
 		return proof.Jwt
@@ -136,11 +182,14 @@ func (request *CredentialRequest) CheckRequestValid(audience string, cNonce stri
 		}
 
 		if request.Format != "" {
-			if request.Format == "vc+sd-jwt" {
+			if types.IsSdJwt(request.Format) {
 				if request.Vct == nil {
 					return false, errors.New("requested format has missing vct")
 				}
 			}
+			if request.Format == string(types.MSOMDOC) && request.Doctype == nil {
+				return false, errors.New("requested format has missing doctype")
+			}
 		}
 	}
 
@@ -1,5 +1,12 @@
 package credential
 
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+)
+
 var (
 	ErrInvalidCredentialRequest    = CredentialErrorResponse{ErrorMsg: InvalidCredentialRequest}
 	ErrUnsupportedCredentialType   = CredentialErrorResponse{ErrorMsg: UnsupportedCredentialType}
@@ -13,8 +20,26 @@ func (e CredentialErrorResponse) Error() string {
 }
 
 type CredentialErrorResponse struct {
-	ErrorMsg  string  `json:"error"`
-	ErrorDesc *string `json:"error_description,omitempty"`
+	ErrorMsg        string  `json:"error"`
+	ErrorDesc       *string `json:"error_description,omitempty"`
+	CNonce          string  `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int     `json:"c_nonce_expires_in,omitempty"`
+}
+
+/*
+Extracts the error response of the issuer from a failed credential, deferred or notification request.
+*/
+func AsCredentialError(err error) (*CredentialErrorResponse, bool) {
+	var requestErr *helper.RequestError
+	if !errors.As(err, &requestErr) {
+		return nil, false
+	}
+
+	var response CredentialErrorResponse
+	if json.Unmarshal(requestErr.Body, &response) != nil || response.ErrorMsg == "" {
+		return nil, false
+	}
+	return &response, true
 }
 
 const (
@@ -24,15 +49,33 @@ const (
 	UnsupportedCredentialFormat = "unsupported_credential_format"
 	InvalidProof                = "invalid_proof"
 	InvalidEncryptionParameters = "invalid_encryption_parameters"
+	InvalidNonce                = "invalid_nonce"
 )
 
 type CredentialResponse struct {
-	Format          string      `json:"format"`
-	Credential      interface{} `json:"credential,omitempty"`
-	TransactionID   string      `json:"transaction_id,omitempty"`
-	CNonce          string      `json:"c_nonce,omitempty"`
-	CNonceExpiresIn int         `json:"c_nonce_expires_in,omitempty"`
-	NotificationId  string      `json:"notification_id,omitempty"`
+	Format          string             `json:"format"`
+	Credential      interface{}        `json:"credential,omitempty"`
+	Credentials     []CredentialObject `json:"credentials,omitempty"`
+	TransactionID   string             `json:"transaction_id,omitempty"`
+	CNonce          string             `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int                `json:"c_nonce_expires_in,omitempty"`
+	NotificationId  string             `json:"notification_id,omitempty"`
+	Interval        int                `json:"interval,omitempty"`
+}
+
+// CredentialObject is an entry of the credentials array of 1.0 credential responses.
+type CredentialObject struct {
+	Credential interface{} `json:"credential"`
+}
+
+// normalize mirrors the first credential of the 1.0 credentials array into Credential and vice versa.
+func (response *CredentialResponse) normalize() {
+	if response.Credential == nil && len(response.Credentials) > 0 {
+		response.Credential = response.Credentials[0].Credential
+	}
+	if len(response.Credentials) == 0 && response.Credential != nil {
+		response.Credentials = []CredentialObject{{Credential: response.Credential}}
+	}
 }
 
 type CredentialResponseError struct {
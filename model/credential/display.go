@@ -0,0 +1,144 @@
+package credential
+
+import (
+	"strings"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"golang.org/x/text/language"
+)
+
+// ClaimDisplay is the resolved display of a described claim.
+type ClaimDisplay struct {
+	Path      oauth.ClaimPath
+	Mandatory bool
+	Display   *Display
+}
+
+/*
+DisplayResolver picks the display entries of metadata for the preferred languages of the user. Entries are matched by
+their BCP-47 locale, if no locale matches the first entry without locale is used and otherwise the first entry.
+*/
+type DisplayResolver struct {
+	preferences []language.Tag
+}
+
+/*
+NewDisplayResolver creates a resolver for BCP-47 language preferences in descending priority. An Accept-Language
+header value is accepted as well, invalid tags are ignored.
+*/
+func NewDisplayResolver(preferences ...string) *DisplayResolver {
+	resolver := DisplayResolver{}
+	for _, preference := range preferences {
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil {
+			continue
+		}
+		resolver.preferences = append(resolver.preferences, tags...)
+	}
+	return &resolver
+}
+
+// Issuer returns the display of the credential issuer or nil if the metadata has none.
+func (resolver *DisplayResolver) Issuer(metadata *IssuerMetadata) *LocalizedCredential {
+	return resolver.localizedCredential(metadata.Display)
+}
+
+// Credential returns the display of a credential configuration or nil if the configuration has none.
+func (resolver *DisplayResolver) Credential(configuration *CredentialConfiguration) *LocalizedCredential {
+	display := configuration.Display
+	if len(display) == 0 && configuration.CredentialMetadata != nil {
+		display = configuration.CredentialMetadata.Display
+	}
+	return resolver.localizedCredential(display)
+}
+
+// Claim returns the display of a claim or nil if the claim has none.
+func (resolver *DisplayResolver) Claim(claim MetadataClaim) *Display {
+	return resolver.display(claim.Display)
+}
+
+// Claims returns the display of every described claim of the configuration in the order of the metadata.
+func (resolver *DisplayResolver) Claims(configuration *CredentialConfiguration) []ClaimDisplay {
+	descriptions := configuration.ClaimDescriptions()
+
+	claims := make([]ClaimDisplay, 0, len(descriptions))
+	for _, claim := range descriptions {
+		claims = append(claims, ClaimDisplay{
+			Path:      claim.Path,
+			Mandatory: claim.Mandatory,
+			Display:   resolver.Claim(claim),
+		})
+	}
+	return claims
+}
+
+// CredentialSubject returns the display of the credential subject claims of Draft 13 credential definitions.
+func (resolver *DisplayResolver) CredentialSubject(definition CredentialDefinition) map[string]Display {
+	displays := make(map[string]Display, len(definition.CredentialSubject))
+	for name, subject := range definition.CredentialSubject {
+		if display := resolver.display(subject.Display); display != nil {
+			displays[name] = *display
+		}
+	}
+	return displays
+}
+
+func (resolver *DisplayResolver) localizedCredential(entries []LocalizedCredential) *LocalizedCredential {
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.Locale)
+	}
+
+	i := resolver.Match(locales)
+	if i < 0 {
+		return nil
+	}
+	return &entries[i]
+}
+
+func (resolver *DisplayResolver) display(entries []Display) *Display {
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.Locale)
+	}
+
+	i := resolver.Match(locales)
+	if i < 0 {
+		return nil
+	}
+	return &entries[i]
+}
+
+// Match returns the index of the best locale for the preferences, -1 if locales is empty.
+func (resolver *DisplayResolver) Match(locales []string) int {
+	if len(locales) == 0 {
+		return -1
+	}
+
+	supported := make([]language.Tag, 0, len(locales))
+	indices := make([]int, 0, len(locales))
+	fallback := -1
+	for i, locale := range locales {
+		tag, err := language.Parse(strings.TrimSpace(locale))
+		if locale == "" || err != nil {
+			if fallback < 0 {
+				fallback = i
+			}
+			continue
+		}
+		supported = append(supported, tag)
+		indices = append(indices, i)
+	}
+
+	if len(supported) > 0 && len(resolver.preferences) > 0 {
+		_, i, confidence := language.NewMatcher(supported).Match(resolver.preferences...)
+		if confidence != language.No {
+			return indices[i]
+		}
+	}
+
+	if fallback >= 0 {
+		return fallback
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package credential
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDisplayResolver(t *testing.T) {
+	metadata, err := ParseIssuerMetadata([]byte(exampleIssuerMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := NewDisplayResolver("fr-CH", "en")
+	if display := resolver.Issuer(metadata); display == nil || display.Name != "Example Université" {
+		t.Error(display)
+	}
+
+	configuration := metadata.CredentialConfigurationsSupported["SD_JWT_VC_example_in_OpenID4VCI"]
+
+	claims := NewDisplayResolver("de-AT,de;q=0.9,en;q=0.5").Claims(&configuration)
+	if claims[0].Display.Name != "Vorname" || claims[2].Display != nil {
+		t.Error(claims)
+	}
+
+	degree := metadata.CredentialConfigurationsSupported["UniversityDegreeCredential"]
+	display := NewDisplayResolver("ja").Credential(&degree)
+	if display == nil || display.Logo.AlternativeText != "a square logo of a university" || display.BackgroundColor != "#12107c" {
+		t.Error(display)
+	}
+
+	if NewDisplayResolver("ja").CredentialSubject(degree.CredentialDefinition)["gpa"].Name != "GPA" {
+		t.Error()
+	}
+}
+
+func TestDisplayFallback(t *testing.T) {
+	var entries []Display
+	json.Unmarshal([]byte(`[{"name": "English", "locale": "en"}, {"name": "Default"}, {"name": "Deutsch", "locale": "de"}]`), &entries)
+
+	resolver := NewDisplayResolver("es")
+	if d := resolver.display(entries); d.Name != "Default" {
+		t.Error(d)
+	}
+
+	if d := NewDisplayResolver().display(entries[:1]); d.Name != "English" {
+		t.Error(d)
+	}
+
+	if NewDisplayResolver("en").display(nil) != nil {
+		t.Error()
+	}
+
+	var logo DescriptiveURL
+	json.Unmarshal([]byte(`{"uri": "https://example.com/logo.png", "alt_text": "logo"}`), &logo)
+	if logo.URL != "https://example.com/logo.png" {
+		t.Error()
+	}
+}
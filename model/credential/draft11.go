@@ -0,0 +1,374 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CredentialOfferDraft11 lists the offered credentials as ids of credentials_supported or as credential objects.
+type CredentialOfferDraft11 struct {
+	CredentialIssuer string        `json:"credential_issuer"`
+	Credentials      []interface{} `json:"credentials"`
+	Grants           GrantsDraft11 `json:"grants,omitempty"`
+}
+
+type GrantsDraft11 struct {
+	AuthorizationCode *AuthorizationCode        `json:"authorization_code,omitempty"`
+	PreAuthorizedCode *PreAuthorizedCodeDraft11 `json:"urn:ietf:params:oauth:grant-type:pre-authorized_code,omitempty"`
+}
+
+type PreAuthorizedCodeDraft11 struct {
+	PreAuthorizationCode string `json:"pre-authorized_code"`
+	UserPinRequired      bool   `json:"user_pin_required,omitempty"`
+	Interval             int    `json:"interval,omitempty"`
+}
+
+type IssuerMetadataDraft11 struct {
+	CredentialIssuer                               string                      `json:"credential_issuer"`
+	AuthorizationServer                            string                      `json:"authorization_server,omitempty"`
+	CredentialEndpoint                             string                      `json:"credential_endpoint"`
+	BatchCredentialEndpoint                        *string                     `json:"batch_credential_endpoint,omitempty"`
+	DeferredCredentialEndpoint                     *string                     `json:"deferred_credential_endpoint,omitempty"`
+	CredentialResponseEncryptionAlgValuesSupported []string                    `json:"credential_response_encryption_alg_values_supported,omitempty"`
+	CredentialResponseEncryptionEncValuesSupported []string                    `json:"credential_response_encryption_enc_values_supported,omitempty"`
+	RequireCredentialResponseEncryption            bool                        `json:"require_credential_response_encryption,omitempty"`
+	CredentialsSupported                           CredentialsSupportedDraft11 `json:"credentials_supported"`
+	Display                                        []LocalizedCredential       `json:"display,omitempty"`
+}
+
+// CredentialsSupportedDraft11 is a list in Draft 11 and an object keyed by the credential id in Draft 12.
+type CredentialsSupportedDraft11 []CredentialSupportedDraft11
+
+type CredentialSupportedDraft11 struct {
+	Id                                   string                `json:"id,omitempty"`
+	Format                               string                `json:"format"`
+	Scope                                string                `json:"scope,omitempty"`
+	CryptographicBindingMethodsSupported []string              `json:"cryptographic_binding_methods_supported,omitempty"`
+	CryptographicSuitesSupported         []string              `json:"cryptographic_suites_supported,omitempty"`
+	CredentialSigningAlgValuesSupported  []string              `json:"credential_signing_alg_values_supported,omitempty"`
+	ProofTypesSupported                  json.RawMessage       `json:"proof_types_supported,omitempty"`
+	Context                              []string              `json:"@context,omitempty"`
+	Types                                []string              `json:"types,omitempty"`
+	CredentialSubject                    json.RawMessage       `json:"credentialSubject,omitempty"`
+	CredentialDefinition                 json.RawMessage       `json:"credential_definition,omitempty"`
+	Vct                                  *string               `json:"vct,omitempty"`
+	Doctype                              *string               `json:"doctype,omitempty"`
+	Claims                               json.RawMessage       `json:"claims,omitempty"`
+	Display                              []LocalizedCredential `json:"display,omitempty"`
+	Order                                []string              `json:"order,omitempty"`
+}
+
+type CredentialRequestDraft11 struct {
+	Format            string          `json:"format"`
+	Context           []string        `json:"@context,omitempty"`
+	Types             []string        `json:"types,omitempty"`
+	CredentialSubject json.RawMessage `json:"credentialSubject,omitempty"`
+	Vct               *string         `json:"vct,omitempty"`
+	Doctype           *string         `json:"doctype,omitempty"`
+	Claims            json.RawMessage `json:"claims,omitempty"`
+	Proof             *Proof          `json:"proof,omitempty"`
+}
+
+func (supported *CredentialsSupportedDraft11) UnmarshalJSON(b []byte) error {
+	if !isObject(b) {
+		return json.Unmarshal(b, (*[]CredentialSupportedDraft11)(supported))
+	}
+
+	keys, values, err := orderedObject(b)
+	if err != nil {
+		return err
+	}
+
+	*supported = make(CredentialsSupportedDraft11, 0, len(keys))
+	for i, key := range keys {
+		var credential CredentialSupportedDraft11
+		if err := json.Unmarshal(values[i], &credential); err != nil {
+			return err
+		}
+		credential.Id = key
+		*supported = append(*supported, credential)
+	}
+	return nil
+}
+
+func (offer *CredentialOfferDraft11) onlyIds() bool {
+	for _, c := range offer.Credentials {
+		if _, ok := c.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+ToV1 converts the offer into 1.0 offer parameters. Credential objects are resolved to configuration ids by format,
+vct and types, therefore metadata is required when the offer contains objects.
+*/
+func (offer *CredentialOfferDraft11) ToV1(metadata *IssuerMetadata) (*CredentialOfferParameters, error) {
+	params := CredentialOfferParameters{
+		CredentialIssuer: offer.CredentialIssuer,
+		Credentials:      make([]string, 0, len(offer.Credentials)),
+		Grants: Grants{
+			AuthorizationCode: offer.Grants.AuthorizationCode,
+		},
+	}
+
+	for _, c := range offer.Credentials {
+		if id, ok := c.(string); ok {
+			params.Credentials = append(params.Credentials, id)
+			continue
+		}
+
+		if metadata == nil {
+			return nil, fmt.Errorf("issuer metadata required to resolve offered credential %v", c)
+		}
+
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+
+		var described CredentialSupportedDraft11
+		if err := json.Unmarshal(b, &described); err != nil {
+			return nil, fmt.Errorf("invalid offered credential: %w", err)
+		}
+
+		id, ok := metadata.findConfigurationId(described.Format, described.Vct, described.Doctype, described.types())
+		if !ok {
+			return nil, fmt.Errorf("offered credential %s not supported by issuer", string(b))
+		}
+		params.Credentials = append(params.Credentials, id)
+	}
+
+	if grant := offer.Grants.PreAuthorizedCode; grant != nil {
+		params.Grants.PreAuthorizedCode = &PreAuthorizedCode{
+			PreAuthorizationCode: grant.PreAuthorizationCode,
+			Interval:             grant.Interval,
+		}
+		if grant.UserPinRequired {
+			params.Grants.PreAuthorizedCode.TxCode = &TxCode{InputMode: "numeric"}
+		}
+	}
+	return &params, nil
+}
+
+// ToDraft11 converts the offer parameters into a Draft 11 offer, a tx_code is announced as user_pin_required.
+func (offerParameter *CredentialOfferParameters) ToDraft11() *CredentialOfferDraft11 {
+	offer := CredentialOfferDraft11{
+		CredentialIssuer: offerParameter.CredentialIssuer,
+		Credentials:      make([]interface{}, 0, len(offerParameter.Credentials)),
+		Grants: GrantsDraft11{
+			AuthorizationCode: offerParameter.Grants.AuthorizationCode,
+		},
+	}
+
+	for _, id := range offerParameter.Credentials {
+		offer.Credentials = append(offer.Credentials, id)
+	}
+
+	if grant := offerParameter.Grants.PreAuthorizedCode; grant != nil {
+		offer.Grants.PreAuthorizedCode = &PreAuthorizedCodeDraft11{
+			PreAuthorizationCode: grant.PreAuthorizationCode,
+			UserPinRequired:      grant.TxCode != nil,
+			Interval:             grant.Interval,
+		}
+	}
+	return &offer
+}
+
+func (supported *CredentialSupportedDraft11) types() []string {
+	if len(supported.Types) > 0 {
+		return supported.Types
+	}
+
+	var definition CredentialDefinition
+	if len(supported.CredentialDefinition) > 0 && json.Unmarshal(supported.CredentialDefinition, &definition) == nil {
+		return definition.Type
+	}
+	return nil
+}
+
+// ToV1 converts Draft 11 (and 12) metadata into the 1.0 model. Credentials without id get an id of their types or vct.
+func (metadata *IssuerMetadataDraft11) ToV1() *IssuerMetadata {
+	converted := IssuerMetadata{
+		CredentialIssuer:                  metadata.CredentialIssuer,
+		CredentialEndpoint:                metadata.CredentialEndpoint,
+		BatchCredentialEndpoint:           metadata.BatchCredentialEndpoint,
+		DeferredCredentialEndpoint:        metadata.DeferredCredentialEndpoint,
+		Display:                           metadata.Display,
+		CredentialConfigurationsSupported: make(map[string]CredentialConfiguration, len(metadata.CredentialsSupported)),
+		Version:                           VersionDraft11,
+	}
+
+	if metadata.AuthorizationServer != "" {
+		converted.AuthorizationServers = []string{metadata.AuthorizationServer}
+	}
+
+	if len(metadata.CredentialResponseEncryptionAlgValuesSupported) > 0 {
+		converted.CredentialResponseEncryption = &CredentialRespEnc{
+			AlgValuesSupported: metadata.CredentialResponseEncryptionAlgValuesSupported,
+			EncValuesSupported: metadata.CredentialResponseEncryptionEncValuesSupported,
+			EncryptionRequired: metadata.RequireCredentialResponseEncryption,
+		}
+	}
+
+	for _, supported := range metadata.CredentialsSupported {
+		converted.CredentialConfigurationsSupported[supported.id()] = supported.toConfiguration()
+	}
+	return &converted
+}
+
+func (supported *CredentialSupportedDraft11) id() string {
+	switch {
+	case supported.Id != "":
+		return supported.Id
+	case supported.Vct != nil:
+		return *supported.Vct
+	case supported.Doctype != nil:
+		return *supported.Doctype
+	case len(supported.types()) > 0:
+		return strings.Join(supported.types(), "_")
+	}
+	return supported.Format
+}
+
+func (supported *CredentialSupportedDraft11) toConfiguration() CredentialConfiguration {
+	// the draft 12 shape is accepted by the tolerant unmarshalling of the configuration
+	b, _ := json.Marshal(supported)
+
+	var configuration CredentialConfiguration
+	if err := json.Unmarshal(b, &configuration); err != nil {
+		configuration = CredentialConfiguration{Format: supported.Format}
+	}
+
+	if len(supported.Types) > 0 {
+		configuration.CredentialDefinition.Type = supported.Types
+	}
+	if len(supported.Context) > 0 {
+		configuration.CredentialDefinition.Context = supported.Context
+	}
+
+	if isObject(supported.CredentialSubject) {
+		json.Unmarshal(supported.CredentialSubject, &configuration.CredentialDefinition.CredentialSubject)
+		subjectClaims, err := claimsFromDraft(credentialSubjectPath, supported.CredentialSubject)
+		if err == nil {
+			configuration.Claims = append(configuration.Claims, subjectClaims...)
+		}
+	}
+	return configuration
+}
+
+// ToDraft11 converts the metadata into the Draft 11 shape, credentials_supported is ordered by id.
+func (metadata *IssuerMetadata) ToDraft11() *IssuerMetadataDraft11 {
+	converted := IssuerMetadataDraft11{
+		CredentialIssuer:           metadata.CredentialIssuer,
+		CredentialEndpoint:         metadata.CredentialEndpoint,
+		BatchCredentialEndpoint:    metadata.BatchCredentialEndpoint,
+		DeferredCredentialEndpoint: metadata.DeferredCredentialEndpoint,
+		Display:                    metadata.Display,
+		CredentialsSupported:       make(CredentialsSupportedDraft11, 0, len(metadata.CredentialConfigurationsSupported)),
+	}
+
+	if len(metadata.AuthorizationServers) > 0 {
+		converted.AuthorizationServer = metadata.AuthorizationServers[0]
+	}
+
+	if encryption := metadata.CredentialResponseEncryption; encryption != nil {
+		converted.CredentialResponseEncryptionAlgValuesSupported = encryption.AlgValuesSupported
+		converted.CredentialResponseEncryptionEncValuesSupported = encryption.EncValuesSupported
+		converted.RequireCredentialResponseEncryption = encryption.EncryptionRequired
+	}
+
+	ids := make([]string, 0, len(metadata.CredentialConfigurationsSupported))
+	for id := range metadata.CredentialConfigurationsSupported {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		configuration := metadata.CredentialConfigurationsSupported[id]
+		claims, credentialSubject := splitDraftClaims(configuration)
+
+		supported := CredentialSupportedDraft11{
+			Id:                                   id,
+			Format:                               draftFormat(configuration.Format),
+			Scope:                                configuration.Scope,
+			CryptographicBindingMethodsSupported: configuration.CryptographicBindingMethodsSupported,
+			CryptographicSuitesSupported:         configuration.CredentialSigningAlgValuesSupported,
+			Context:                              configuration.CredentialDefinition.Context,
+			Types:                                configuration.CredentialDefinition.Type,
+			CredentialSubject:                    credentialSubject,
+			Vct:                                  configuration.Vct,
+			Doctype:                              configuration.Doctype,
+			Claims:                               claims,
+			Display:                              configuration.Display,
+			Order:                                configuration.Order,
+		}
+
+		if len(configuration.ProofTypesSupported) > 0 {
+			variants := make([]string, 0, len(configuration.ProofTypesSupported))
+			for variant := range configuration.ProofTypesSupported {
+				variants = append(variants, string(variant))
+			}
+			sort.Strings(variants)
+			supported.ProofTypesSupported, _ = json.Marshal(variants)
+		}
+
+		converted.CredentialsSupported = append(converted.CredentialsSupported, supported)
+	}
+	return &converted
+}
+
+// ToDraft11 converts the credential request into the Draft 11 shape, the credential is described by its configuration.
+func (request *CredentialRequest) ToDraft11(metadata IssuerMetadata) (*CredentialRequestDraft11, error) {
+	configuration, err := request.configuration(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := CredentialRequestDraft11{
+		Format: draftFormat(configuration.Format),
+		Proof:  request.Proof,
+	}
+
+	claims := requestClaims(request.Claims)
+	if isW3cFormat(configuration.Format) {
+		draft.Context = configuration.CredentialDefinition.Context
+		draft.Types = configuration.CredentialDefinition.Type
+		draft.CredentialSubject = claimsToDraft(credentialSubjectPath, claims)
+	} else {
+		draft.Vct = configuration.Vct
+		draft.Doctype = configuration.Doctype
+		draft.Claims = claimsToDraft(nil, claims)
+	}
+	return &draft, nil
+}
+
+// ToV1 resolves the configuration id of a Draft 11 credential request.
+func (request *CredentialRequestDraft11) ToV1(metadata IssuerMetadata) (*CredentialRequest, error) {
+	id, ok := metadata.findConfigurationId(request.Format, request.Vct, request.Doctype, request.Types)
+	if !ok {
+		return nil, ErrUnsupportedCredentialType
+	}
+
+	converted := CredentialRequest{
+		CredentialConfigurationId: id,
+		Proof:                     request.Proof,
+	}
+
+	claims, err := claimsFromDraft(nil, request.Claims)
+	if err != nil {
+		return nil, err
+	}
+	subjectClaims, err := claimsFromDraft(credentialSubjectPath, request.CredentialSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range append(claims, subjectClaims...) {
+		converted.Claims = append(converted.Claims, claim.Claim)
+	}
+	return &converted, nil
+}
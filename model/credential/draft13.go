@@ -0,0 +1,250 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+type IssuerMetadataDraft13 struct {
+	CredentialIssuer                  string                                    `json:"credential_issuer"`
+	AuthorizationServers              []string                                  `json:"authorization_servers,omitempty"`
+	CredentialEndpoint                string                                    `json:"credential_endpoint"`
+	BatchCredentialEndpoint           *string                                   `json:"batch_credential_endpoint,omitempty"`
+	DeferredCredentialEndpoint        *string                                   `json:"deferred_credential_endpoint,omitempty"`
+	NotificationEndpoint              *string                                   `json:"notification_endpoint,omitempty"`
+	CredentialResponseEncryption      *CredentialRespEnc                        `json:"credential_response_encryption,omitempty"`
+	Display                           []LocalizedCredential                     `json:"display,omitempty"`
+	CredentialIdentifiersSupported    bool                                      `json:"credential_identifiers_supported,omitempty"`
+	SignedMetadata                    *string                                   `json:"signed_metadata,omitempty"`
+	CredentialConfigurationsSupported map[string]CredentialConfigurationDraft13 `json:"credential_configurations_supported"`
+}
+
+// CredentialConfigurationDraft13 describes claims as nested objects, for w3c formats within credential_definition.
+type CredentialConfigurationDraft13 struct {
+	Format                               string                     `json:"format"`
+	Scope                                string                     `json:"scope,omitempty"`
+	CryptographicBindingMethodsSupported []string                   `json:"cryptographic_binding_methods_supported,omitempty"`
+	CredentialSigningAlgValuesSupported  []string                   `json:"credential_signing_alg_values_supported,omitempty"`
+	CredentialDefinition                 *CredentialDefinitionDraft `json:"credential_definition,omitempty"`
+	ProofTypesSupported                  map[ProofVariant]ProofType `json:"proof_types_supported,omitempty"`
+	Display                              []LocalizedCredential      `json:"display,omitempty"`
+	Vct                                  *string                    `json:"vct,omitempty"`
+	Doctype                              *string                    `json:"doctype,omitempty"`
+	Claims                               json.RawMessage            `json:"claims,omitempty"`
+	Order                                []string                   `json:"order,omitempty"`
+}
+
+type CredentialDefinitionDraft struct {
+	Context           []string        `json:"@context,omitempty"`
+	Type              []string        `json:"type,omitempty"`
+	CredentialSubject json.RawMessage `json:"credentialSubject,omitempty"`
+}
+
+// CredentialRequestDraft13 describes the requested credential by format or by credential_identifier.
+type CredentialRequestDraft13 struct {
+	Format               string                     `json:"format,omitempty"`
+	CredentialIdentifier string                     `json:"credential_identifier,omitempty"`
+	Vct                  *string                    `json:"vct,omitempty"`
+	Doctype              *string                    `json:"doctype,omitempty"`
+	CredentialDefinition *CredentialDefinitionDraft `json:"credential_definition,omitempty"`
+	Claims               json.RawMessage            `json:"claims,omitempty"`
+	Proof                *Proof                     `json:"proof,omitempty"`
+}
+
+type CredentialResponseDraft13 struct {
+	Format          string      `json:"format,omitempty"`
+	Credential      interface{} `json:"credential,omitempty"`
+	TransactionID   string      `json:"transaction_id,omitempty"`
+	CNonce          string      `json:"c_nonce,omitempty"`
+	CNonceExpiresIn int         `json:"c_nonce_expires_in,omitempty"`
+	NotificationId  string      `json:"notification_id,omitempty"`
+}
+
+// ToDraft13 converts the metadata into the Draft 13 shape with nested claims objects.
+func (metadata *IssuerMetadata) ToDraft13() *IssuerMetadataDraft13 {
+	converted := IssuerMetadataDraft13{
+		CredentialIssuer:                  metadata.CredentialIssuer,
+		AuthorizationServers:              metadata.AuthorizationServers,
+		CredentialEndpoint:                metadata.CredentialEndpoint,
+		BatchCredentialEndpoint:           metadata.BatchCredentialEndpoint,
+		DeferredCredentialEndpoint:        metadata.DeferredCredentialEndpoint,
+		NotificationEndpoint:              metadata.NotificationEndpoint,
+		CredentialResponseEncryption:      metadata.CredentialResponseEncryption,
+		Display:                           metadata.Display,
+		CredentialIdentifiersSupported:    metadata.CredentialIdentifiersSupported,
+		SignedMetadata:                    metadata.SignedMetadata,
+		CredentialConfigurationsSupported: make(map[string]CredentialConfigurationDraft13, len(metadata.CredentialConfigurationsSupported)),
+	}
+
+	for id, configuration := range metadata.CredentialConfigurationsSupported {
+		claims, credentialSubject := splitDraftClaims(configuration)
+
+		draft := CredentialConfigurationDraft13{
+			Format:                               draftFormat(configuration.Format),
+			Scope:                                configuration.Scope,
+			CryptographicBindingMethodsSupported: configuration.CryptographicBindingMethodsSupported,
+			CredentialSigningAlgValuesSupported:  configuration.CredentialSigningAlgValuesSupported,
+			ProofTypesSupported:                  configuration.ProofTypesSupported,
+			Display:                              configuration.Display,
+			Vct:                                  configuration.Vct,
+			Doctype:                              configuration.Doctype,
+			Claims:                               claims,
+			Order:                                configuration.Order,
+		}
+
+		definition := configuration.CredentialDefinition
+		if len(definition.Context) > 0 || len(definition.Type) > 0 || credentialSubject != nil {
+			draft.CredentialDefinition = &CredentialDefinitionDraft{
+				Context:           definition.Context,
+				Type:              definition.Type,
+				CredentialSubject: credentialSubject,
+			}
+		}
+
+		converted.CredentialConfigurationsSupported[id] = draft
+	}
+	return &converted
+}
+
+// ToV1 converts Draft 13 metadata into the 1.0 model.
+func (metadata *IssuerMetadataDraft13) ToV1() (*IssuerMetadata, error) {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var converted IssuerMetadata
+	if err := json.Unmarshal(b, &converted); err != nil {
+		return nil, err
+	}
+	converted.Version = VersionDraft13
+	return &converted, nil
+}
+
+// ToDraft13 converts the credential request into the Draft 13 shape, which describes the credential by format.
+func (request *CredentialRequest) ToDraft13(metadata IssuerMetadata) (*CredentialRequestDraft13, error) {
+	if request.CredentialIdentifier != "" {
+		return &CredentialRequestDraft13{
+			CredentialIdentifier: request.CredentialIdentifier,
+			Proof:                request.Proof,
+		}, nil
+	}
+
+	configuration, err := request.configuration(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := CredentialRequestDraft13{
+		Format: draftFormat(configuration.Format),
+		Proof:  request.Proof,
+	}
+
+	claims := requestClaims(request.Claims)
+	if isW3cFormat(configuration.Format) {
+		draft.CredentialDefinition = &CredentialDefinitionDraft{
+			Context:           configuration.CredentialDefinition.Context,
+			Type:              configuration.CredentialDefinition.Type,
+			CredentialSubject: claimsToDraft(credentialSubjectPath, claims),
+		}
+	} else {
+		draft.Vct = configuration.Vct
+		draft.Doctype = configuration.Doctype
+		draft.Claims = claimsToDraft(nil, claims)
+	}
+	return &draft, nil
+}
+
+// ToV1 resolves the configuration id of a Draft 13 credential request.
+func (request *CredentialRequestDraft13) ToV1(metadata IssuerMetadata) (*CredentialRequest, error) {
+	converted := CredentialRequest{
+		CredentialIdentifier: request.CredentialIdentifier,
+		Proof:                request.Proof,
+	}
+
+	if request.CredentialIdentifier != "" {
+		return &converted, nil
+	}
+
+	var types []string
+	var credentialSubject json.RawMessage
+	if request.CredentialDefinition != nil {
+		types = request.CredentialDefinition.Type
+		credentialSubject = request.CredentialDefinition.CredentialSubject
+	}
+
+	id, ok := metadata.findConfigurationId(request.Format, request.Vct, request.Doctype, types)
+	if !ok {
+		return nil, ErrUnsupportedCredentialType
+	}
+	converted.CredentialConfigurationId = id
+
+	claims, err := claimsFromDraft(nil, request.Claims)
+	if err != nil {
+		return nil, err
+	}
+	subjectClaims, err := claimsFromDraft(credentialSubjectPath, credentialSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, claim := range append(claims, subjectClaims...) {
+		converted.Claims = append(converted.Claims, claim.Claim)
+	}
+	return &converted, nil
+}
+
+// ToDraft13 returns the draft response with the first credential.
+func (response *CredentialResponse) ToDraft13() *CredentialResponseDraft13 {
+	draft := CredentialResponseDraft13{
+		Format:          response.Format,
+		Credential:      response.Credential,
+		TransactionID:   response.TransactionID,
+		CNonce:          response.CNonce,
+		CNonceExpiresIn: response.CNonceExpiresIn,
+		NotificationId:  response.NotificationId,
+	}
+
+	if draft.Credential == nil && len(response.Credentials) > 0 {
+		draft.Credential = response.Credentials[0].Credential
+	}
+	return &draft
+}
+
+// ToV1 converts the draft response, the credential becomes the single entry of the credentials array.
+func (response *CredentialResponseDraft13) ToV1() *CredentialResponse {
+	converted := CredentialResponse{
+		Format:          response.Format,
+		Credential:      response.Credential,
+		TransactionID:   response.TransactionID,
+		CNonce:          response.CNonce,
+		CNonceExpiresIn: response.CNonceExpiresIn,
+		NotificationId:  response.NotificationId,
+	}
+	converted.normalize()
+	return &converted
+}
+
+// configuration returns the configuration of the request, legacy requests are resolved by their format and vct.
+func (request *CredentialRequest) configuration(metadata IssuerMetadata) (*CredentialConfiguration, error) {
+	id := request.CredentialConfigurationId
+	if id == "" && request.Format != "" {
+		id, _ = metadata.findConfigurationId(request.Format, request.Vct, request.Doctype, nil)
+	}
+
+	configuration, ok := metadata.CredentialConfigurationsSupported[id]
+	if !ok {
+		return nil, fmt.Errorf("credential configuration %s not supported by issuer", id)
+	}
+	return &configuration, nil
+}
+
+func requestClaims(claims []oauth.Claim) []MetadataClaim {
+	converted := make([]MetadataClaim, 0, len(claims))
+	for _, claim := range claims {
+		converted = append(converted, MetadataClaim{Claim: claim})
+	}
+	return converted
+}
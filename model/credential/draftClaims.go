@@ -0,0 +1,189 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+/*
+Draft 11 and 13 describe claims as nested objects, where the keys mandatory, value_type and display are parameters of
+the claim and all other keys are nested claims. 1.0 describes them as list with path arrays.
+*/
+
+// claimsFromDraft converts a nested draft claims object into path based claims, preserving the order of the document.
+func claimsFromDraft(prefix oauth.ClaimPath, raw json.RawMessage) ([]MetadataClaim, error) {
+	claims := make([]MetadataClaim, 0)
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return claims, nil
+	}
+
+	keys, values, err := orderedObject(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		value := bytes.TrimSpace(values[i])
+		if len(value) == 0 || value[0] != '{' {
+			continue
+		}
+
+		path := append(append(oauth.ClaimPath{}, prefix...), key)
+		claim := MetadataClaim{Claim: oauth.Claim{Path: path}}
+
+		childKeys, childValues, err := orderedObject(value)
+		if err != nil {
+			return nil, err
+		}
+
+		described := false
+		children := make([]json.RawMessage, 0)
+		var childNames []string
+		for j, childKey := range childKeys {
+			childValue := bytes.TrimSpace(childValues[j])
+			switch {
+			case childKey == "mandatory" && json.Unmarshal(childValue, &claim.Mandatory) == nil:
+				described = true
+			case childKey == "value_type" && json.Unmarshal(childValue, &claim.ValueType) == nil:
+				described = true
+			case childKey == "display" && len(childValue) > 0 && childValue[0] == '[' && json.Unmarshal(childValue, &claim.Display) == nil:
+				described = true
+			default:
+				childNames = append(childNames, childKey)
+				children = append(children, childValue)
+			}
+		}
+
+		if described || len(children) == 0 {
+			claims = append(claims, claim)
+		}
+
+		for j, childName := range childNames {
+			nested, err := claimsFromDraft(path, mustObject(childName, children[j]))
+			if err != nil {
+				return nil, err
+			}
+			claims = append(claims, nested...)
+		}
+	}
+	return claims, nil
+}
+
+// claimsToDraft builds the nested draft claims object of all claims below prefix. Array components can not be expressed in drafts, such paths are cut before the first non string component.
+func claimsToDraft(prefix oauth.ClaimPath, claims []MetadataClaim) json.RawMessage {
+	root := &draftClaimNode{}
+
+	for _, claim := range claims {
+		if !claim.Path.HasPrefix(prefix) {
+			continue
+		}
+
+		node := root
+		for _, component := range claim.Path[len(prefix):] {
+			key, ok := component.(string)
+			if !ok {
+				break
+			}
+			node = node.child(key)
+		}
+
+		if node == root {
+			continue
+		}
+		node.claim = claim
+	}
+
+	if len(root.keys) == 0 {
+		return nil
+	}
+	return root.marshal()
+}
+
+type draftClaimNode struct {
+	claim    MetadataClaim
+	keys     []string
+	children map[string]*draftClaimNode
+}
+
+func (node *draftClaimNode) child(key string) *draftClaimNode {
+	if node.children == nil {
+		node.children = make(map[string]*draftClaimNode)
+	}
+	child, ok := node.children[key]
+	if !ok {
+		child = &draftClaimNode{}
+		node.children[key] = child
+		node.keys = append(node.keys, key)
+	}
+	return child
+}
+
+func (node *draftClaimNode) marshal() json.RawMessage {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+
+	write := func(key string, value interface{}) {
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buffer.Write(k)
+		buffer.WriteByte(':')
+		if raw, ok := value.(json.RawMessage); ok {
+			buffer.Write(raw)
+			return
+		}
+		v, _ := json.Marshal(value)
+		buffer.Write(v)
+	}
+
+	if node.claim.Mandatory {
+		write("mandatory", true)
+	}
+	if node.claim.ValueType != "" {
+		write("value_type", node.claim.ValueType)
+	}
+	if len(node.claim.Display) > 0 {
+		write("display", node.claim.Display)
+	}
+	for _, key := range node.keys {
+		write(key, node.children[key].marshal())
+	}
+
+	buffer.WriteByte('}')
+	return buffer.Bytes()
+}
+
+// orderedObject returns the keys and raw values of a json object in document order.
+func orderedObject(raw json.RawMessage) ([]string, []json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+
+	keys := make([]string, 0)
+	values := make([]json.RawMessage, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, token.(string))
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
+
+func mustObject(key string, value json.RawMessage) json.RawMessage {
+	k, _ := json.Marshal(key)
+	return json.RawMessage(append(append(append([]byte{'{'}, k...), ':'), append(value, '}')...))
+}
@@ -235,3 +235,36 @@ func TestMarshalling(t *testing.T) {
 		return
 	}
 }
+
+func TestAuthorizationServerHint(t *testing.T) {
+	var servers []*httptest.Server
+	for _, grants := range [][]string{{"authorization_code"}, {string(oauth.PreAuthorizedCodeGrant)}, {string(oauth.PreAuthorizedCodeGrant)}} {
+		grants := grants
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := json.Marshal(oauth.OpenIdConfiguration{Issuer: "http://" + r.Host, Grant_Types_Supported: grants})
+			w.Write(b)
+		}))
+		defer srv.Close()
+		servers = append(servers, srv)
+	}
+
+	metadata := IssuerMetadata{AuthorizationServers: []string{servers[0].URL, servers[1].URL, servers[2].URL}}
+
+	config, err := metadata.FindFittingAuthorizationServer(oauth.PreAuthorizedCodeGrant)
+	if err != nil || config.Issuer != servers[1].URL {
+		t.Error(err)
+	}
+
+	config, err = metadata.FindAuthorizationServer(oauth.PreAuthorizedCodeGrant, servers[2].URL)
+	if err != nil || config.Issuer != servers[2].URL {
+		t.Error(err)
+	}
+
+	if _, err = metadata.FindAuthorizationServer(oauth.PreAuthorizedCodeGrant, servers[0].URL); err == nil {
+		t.Error("hinted server does not support the grant")
+	}
+
+	if _, err = metadata.FindAuthorizationServer(oauth.PreAuthorizedCodeGrant, "https://unknown.example.com"); err == nil {
+		t.Error("unknown hint must be rejected")
+	}
+}
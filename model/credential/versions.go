@@ -0,0 +1,349 @@
+package credential
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+)
+
+// Version of the OID4VCI specification an issuer implements.
+type Version string
+
+const (
+	VersionDraft11 Version = "draft-11"
+	VersionDraft13 Version = "draft-13"
+	Version1       Version = "1.0"
+)
+
+var ErrUnknownVersion = errors.New("can not detect OID4VCI version")
+
+/*
+MetadataRequiredError is returned for Draft 11 offers which describe credentials as objects. They are mapped to
+credential configuration ids with the issuer metadata, which the caller fetches and passes to Resolve.
+*/
+type MetadataRequiredError struct {
+	CredentialIssuer string
+	offer            CredentialOfferDraft11
+}
+
+func (e *MetadataRequiredError) Error() string {
+	return fmt.Sprintf("issuer metadata of %s required to map the draft 11 credential offer", e.CredentialIssuer)
+}
+
+// Resolve maps the offer to the 1.0 model with the metadata of the issuer.
+func (e *MetadataRequiredError) Resolve(metadata *IssuerMetadata) (*CredentialOfferParameters, error) {
+	return e.offer.ToV1(metadata)
+}
+
+// w3c formats describe the credential subject within credential_definition in drafts
+var w3cFormats = []string{"jwt_vc_json", "jwt_vc_json-ld", "ldp_vc", "jwt_vc"}
+
+var credentialSubjectPath = oauth.ClaimPath{"credentialSubject"}
+
+/*
+DetectOfferVersion detects the version of credential offer parameters. Draft 11 offers list credentials, Draft 13 and
+1.0 offers use credential_configuration_ids and have the same shape, they are reported as Version1.
+*/
+func DetectOfferVersion(raw []byte) (Version, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", err
+	}
+
+	if _, ok := fields["credential_configuration_ids"]; ok {
+		return Version1, nil
+	}
+	if _, ok := fields["credentials"]; ok {
+		return VersionDraft11, nil
+	}
+	return "", ErrUnknownVersion
+}
+
+/*
+DetectMetadataVersion detects the version of issuer metadata. Draft 11 (and 12) metadata has credentials_supported,
+Draft 13 metadata has nested claims objects. Everything else, including metadata without credentials, is treated as
+Version1.
+*/
+func DetectMetadataVersion(raw []byte) (Version, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return "", err
+	}
+
+	if _, ok := fields["credentials_supported"]; ok {
+		return VersionDraft11, nil
+	}
+
+	configurations, ok := fields["credential_configurations_supported"]
+	if !ok {
+		return Version1, nil
+	}
+
+	var supported map[string]struct {
+		Claims               json.RawMessage `json:"claims"`
+		CredentialDefinition struct {
+			CredentialSubject json.RawMessage `json:"credentialSubject"`
+		} `json:"credential_definition"`
+	}
+	if err := json.Unmarshal(configurations, &supported); err != nil {
+		return "", err
+	}
+
+	for _, configuration := range supported {
+		if isObject(configuration.Claims) || isObject(configuration.CredentialDefinition.CredentialSubject) {
+			return VersionDraft13, nil
+		}
+	}
+	return Version1, nil
+}
+
+/*
+ParseOfferParameters parses credential offer parameters of any version into the 1.0 model. Draft 11 offers which
+describe credentials as objects are matched against metadata, without metadata a *MetadataRequiredError is returned.
+*/
+func ParseOfferParameters(raw []byte, metadata *IssuerMetadata) (*CredentialOfferParameters, Version, error) {
+	version, err := DetectOfferVersion(raw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if version == VersionDraft11 {
+		var draft CredentialOfferDraft11
+		if err := json.Unmarshal(raw, &draft); err != nil {
+			return nil, version, err
+		}
+
+		if !draft.onlyIds() && metadata == nil {
+			return nil, version, &MetadataRequiredError{CredentialIssuer: draft.CredentialIssuer, offer: draft}
+		}
+
+		offer, err := draft.ToV1(metadata)
+		return offer, version, err
+	}
+
+	var offer CredentialOfferParameters
+	if err := json.Unmarshal(raw, &offer); err != nil {
+		return nil, version, err
+	}
+	return &offer, version, nil
+}
+
+// ParseIssuerMetadata parses issuer metadata of any version into the 1.0 model, the detected version is kept in Version.
+func ParseIssuerMetadata(raw []byte) (*IssuerMetadata, error) {
+	version, err := DetectMetadataVersion(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata *IssuerMetadata
+	if version == VersionDraft11 {
+		var draft IssuerMetadataDraft11
+		if err := json.Unmarshal(raw, &draft); err != nil {
+			return nil, err
+		}
+		metadata = draft.ToV1()
+	} else {
+		metadata = &IssuerMetadata{}
+		if err := json.Unmarshal(raw, metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata.Version = version
+	return metadata, nil
+}
+
+/*
+ParseCredentialResponse parses credential and deferred credential responses of any version. The first entry of the
+1.0 credentials array is mirrored into Credential and a single draft credential into Credentials, the Draft 11
+acceptance_token is mapped to TransactionID.
+*/
+func ParseCredentialResponse(raw []byte) (*CredentialResponse, error) {
+	var response struct {
+		CredentialResponse
+		AcceptanceToken string `json:"acceptance_token,omitempty"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+
+	if response.TransactionID == "" {
+		response.TransactionID = response.AcceptanceToken
+	}
+	response.CredentialResponse.normalize()
+	return &response.CredentialResponse, nil
+}
+
+/*
+UnmarshalJSON accepts the Draft 13 shapes of a credential configuration as well: nested claims objects,
+credential_definition.credentialSubject, proof_types_supported as list and cryptographic_suites_supported.
+They are converted into 1.0 claims and proof types.
+*/
+func (configuration *CredentialConfiguration) UnmarshalJSON(b []byte) error {
+	type alias CredentialConfiguration
+	var c struct {
+		alias
+		Claims                       json.RawMessage `json:"claims,omitempty"`
+		ProofTypesSupported          json.RawMessage `json:"proof_types_supported,omitempty"`
+		CryptographicSuitesSupported []string        `json:"cryptographic_suites_supported,omitempty"`
+		CredentialDefinition         json.RawMessage `json:"credential_definition"`
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return err
+	}
+
+	*configuration = CredentialConfiguration(c.alias)
+
+	if len(configuration.CredentialSigningAlgValuesSupported) == 0 {
+		configuration.CredentialSigningAlgValuesSupported = c.CryptographicSuitesSupported
+	}
+
+	proofTypes, err := proofTypesFromDraft(c.ProofTypesSupported)
+	if err != nil {
+		return err
+	}
+	configuration.ProofTypesSupported = proofTypes
+
+	if isObject(c.Claims) {
+		configuration.Claims, err = claimsFromDraft(nil, c.Claims)
+	} else if len(c.Claims) > 0 {
+		err = json.Unmarshal(c.Claims, &configuration.Claims)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid claims: %w", err)
+	}
+
+	if len(c.CredentialDefinition) > 0 {
+		var definition struct {
+			CredentialDefinition
+			CredentialSubject json.RawMessage `json:"credentialSubject"`
+		}
+		if err := json.Unmarshal(c.CredentialDefinition, &definition); err != nil {
+			return err
+		}
+
+		configuration.CredentialDefinition = definition.CredentialDefinition
+		if isObject(definition.CredentialSubject) {
+			if err := json.Unmarshal(definition.CredentialSubject, &configuration.CredentialDefinition.CredentialSubject); err != nil {
+				return err
+			}
+
+			subjectClaims, err := claimsFromDraft(credentialSubjectPath, definition.CredentialSubject)
+			if err != nil {
+				return fmt.Errorf("invalid credentialSubject: %w", err)
+			}
+			configuration.Claims = append(configuration.Claims, subjectClaims...)
+		}
+	}
+	return nil
+}
+
+func proofTypesFromDraft(raw json.RawMessage) (map[ProofVariant]ProofType, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if raw[0] == '[' {
+		var variants []ProofVariant
+		if err := json.Unmarshal(raw, &variants); err != nil {
+			return nil, err
+		}
+		proofTypes := make(map[ProofVariant]ProofType, len(variants))
+		for _, variant := range variants {
+			proofTypes[variant] = ProofType{ProofSigningAlgValuesSupported: []string{}}
+		}
+		return proofTypes, nil
+	}
+
+	var proofTypes map[ProofVariant]ProofType
+	err := json.Unmarshal(raw, &proofTypes)
+	return proofTypes, err
+}
+
+// findConfigurationId returns the id of the configuration matching the draft description of a credential.
+func (metadata *IssuerMetadata) findConfigurationId(format string, vct *string, doctype *string, credentialTypes []string) (string, bool) {
+	ids := make([]string, 0, len(metadata.CredentialConfigurationsSupported))
+	for id := range metadata.CredentialConfigurationsSupported {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		configuration := metadata.CredentialConfigurationsSupported[id]
+		if !types.SameFormat(configuration.Format, format) {
+			continue
+		}
+		if vct != nil && (configuration.Vct == nil || *configuration.Vct != *vct) {
+			continue
+		}
+		if doctype != nil && (configuration.Doctype == nil || *configuration.Doctype != *doctype) {
+			continue
+		}
+		if len(credentialTypes) > 0 && !sameTypes(configuration.CredentialDefinition.Type, credentialTypes) {
+			continue
+		}
+		return id, true
+	}
+	return "", false
+}
+
+func sameTypes(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, t := range a {
+		counts[t]++
+	}
+	for _, t := range b {
+		counts[t]--
+		if counts[t] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func isObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// draftFormat returns the identifier of the drafts, which know SD-JWT VCs only as vc+sd-jwt.
+func draftFormat(format string) string {
+	if types.IsSdJwt(format) {
+		return string(types.SDJWT)
+	}
+	return format
+}
+
+func isW3cFormat(format string) bool {
+	for _, f := range w3cFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// splitDraftClaims returns the draft claims and credentialSubject objects of a configuration.
+func splitDraftClaims(configuration CredentialConfiguration) (claims json.RawMessage, credentialSubject json.RawMessage) {
+	if !isW3cFormat(configuration.Format) {
+		return claimsToDraft(nil, configuration.Claims), nil
+	}
+
+	other := make([]MetadataClaim, 0, len(configuration.Claims))
+	for _, claim := range configuration.Claims {
+		if !claim.Path.HasPrefix(credentialSubjectPath) {
+			other = append(other, claim)
+		}
+	}
+	return claimsToDraft(nil, other), claimsToDraft(credentialSubjectPath, configuration.Claims)
+}
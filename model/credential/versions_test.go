@@ -0,0 +1,234 @@
+package credential
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const draft11Metadata = `{
+    "credential_issuer": "https://issuer.example.com",
+    "authorization_server": "https://auth.example.com",
+    "credential_endpoint": "https://issuer.example.com/credential",
+    "credentials_supported": [
+        {
+            "format": "jwt_vc_json",
+            "id": "UniversityDegree_JWT",
+            "types": ["VerifiableCredential", "UniversityDegreeCredential"],
+            "cryptographic_binding_methods_supported": ["did:example"],
+            "cryptographic_suites_supported": ["ES256K"],
+            "credentialSubject": {
+                "given_name": {"display": [{"name": "Given Name", "locale": "en-US"}]},
+                "degree": {},
+                "gpa": {"display": [{"name": "GPA"}]}
+            }
+        }
+    ]
+}`
+
+func TestDraft11Metadata(t *testing.T) {
+	metadata, err := ParseIssuerMetadata([]byte(draft11Metadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Version != VersionDraft11 || metadata.AuthorizationServers[0] != "https://auth.example.com" {
+		t.Error()
+	}
+
+	configuration, ok := metadata.CredentialConfigurationsSupported["UniversityDegree_JWT"]
+	if !ok || configuration.CredentialSigningAlgValuesSupported[0] != "ES256K" || configuration.CredentialDefinition.Type[1] != "UniversityDegreeCredential" {
+		t.Fatal(configuration)
+	}
+
+	if len(configuration.Claims) != 3 || !reflect.DeepEqual(configuration.Claims[0].Path, oauthPath("credentialSubject", "given_name")) || configuration.Claims[0].Display[0].Name != "Given Name" {
+		t.Error(configuration.Claims)
+	}
+
+	back := metadata.ToDraft11()
+	if back.CredentialsSupported[0].Id != "UniversityDegree_JWT" || !isObject(back.CredentialsSupported[0].CredentialSubject) {
+		t.Error()
+	}
+}
+
+func TestDraft11Offer(t *testing.T) {
+	metadata, _ := ParseIssuerMetadata([]byte(draft11Metadata))
+
+	raw := `{"credential_issuer":"https://issuer.example.com","credentials":["UniversityDegree_JWT",{"format":"jwt_vc_json","types":["UniversityDegreeCredential","VerifiableCredential"]}],
+		"grants":{"urn:ietf:params:oauth:grant-type:pre-authorized_code":{"pre-authorized_code":"abc","user_pin_required":true}}}`
+
+	if version, _ := DetectOfferVersion([]byte(raw)); version != VersionDraft11 {
+		t.Error(version)
+	}
+
+	var draft CredentialOfferDraft11
+	json.Unmarshal([]byte(raw), &draft)
+
+	if _, err := draft.ToV1(nil); err == nil {
+		t.Error("objects can not be resolved without metadata")
+	}
+
+	var required *MetadataRequiredError
+	if _, _, err := ParseOfferParameters([]byte(raw), nil); !errors.As(err, &required) || required.CredentialIssuer != "https://issuer.example.com" {
+		t.Fatal(err)
+	}
+
+	offer, err := required.Resolve(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed, _, err := ParseOfferParameters([]byte(raw), metadata); err != nil || !reflect.DeepEqual(parsed, offer) {
+		t.Error(parsed, err)
+	}
+
+	if len(offer.Credentials) != 2 || offer.Credentials[1] != "UniversityDegree_JWT" || offer.Grants.PreAuthorizedCode.TxCode == nil {
+		t.Error(offer)
+	}
+
+	if !offer.ToDraft11().Grants.PreAuthorizedCode.UserPinRequired {
+		t.Error()
+	}
+}
+
+func TestDetectMetadataVersionWithoutConfigurations(t *testing.T) {
+	version, err := DetectMetadataVersion([]byte(`{"credential_issuer":"https://issuer.example.com","credential_endpoint":"https://issuer.example.com/credential"}`))
+	if err != nil || version != Version1 {
+		t.Error(version, err)
+	}
+}
+
+func TestDraft13ClaimsRoundTrip(t *testing.T) {
+	metadata, err := ParseIssuerMetadata([]byte(exampleIssuerMetadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if metadata.Version != VersionDraft13 {
+		t.Error(metadata.Version)
+	}
+
+	sdJwt := metadata.CredentialConfigurationsSupported["SD_JWT_VC_example_in_OpenID4VCI"]
+	if !reflect.DeepEqual(sdJwt.Claims[0].Path, oauthPath("given_name")) || len(sdJwt.Claims[0].Display) != 2 {
+		t.Error(sdJwt.Claims)
+	}
+
+	var street bool
+	for _, claim := range sdJwt.Claims {
+		street = street || reflect.DeepEqual(claim.Path, oauthPath("address", "street_address"))
+	}
+	if !street {
+		t.Error("nested claim missing")
+	}
+
+	draft := metadata.ToDraft13()
+	b, _ := json.Marshal(draft)
+
+	again, err := ParseIssuerMetadata(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, configuration := range metadata.CredentialConfigurationsSupported {
+		if !reflect.DeepEqual(configuration.Claims, again.CredentialConfigurationsSupported[id].Claims) {
+			t.Error("claims changed by round trip of " + id)
+		}
+	}
+}
+
+func TestCredentialRequestConversion(t *testing.T) {
+	metadata, _ := ParseIssuerMetadata([]byte(exampleIssuerMetadata))
+
+	request := CredentialRequest{CredentialConfigurationId: "UniversityDegreeCredential"}
+
+	draft, err := request.ToDraft13(*metadata)
+	if err != nil || draft.Format != "jwt_vc_json" || draft.CredentialDefinition.Type[1] != "UniversityDegreeCredential" {
+		t.Fatal(err)
+	}
+
+	back, err := draft.ToV1(*metadata)
+	if err != nil || back.CredentialConfigurationId != request.CredentialConfigurationId {
+		t.Error(err)
+	}
+
+	request = CredentialRequest{CredentialConfigurationId: "SD_JWT_VC_example_in_OpenID4VCI"}
+	draft, _ = request.ToDraft13(*metadata)
+	if draft.Format != "vc+sd-jwt" || *draft.Vct != "SD_JWT_VC_example_in_OpenID4VCI" {
+		t.Error()
+	}
+
+	b, _ := metadata.marshalCredentialRequest(request)
+	var hybrid map[string]interface{}
+	json.Unmarshal(b, &hybrid)
+	if hybrid["credential_configuration_id"] != request.CredentialConfigurationId || hybrid["format"] != "vc+sd-jwt" {
+		t.Error(string(b))
+	}
+}
+
+func TestParseCredentialResponse(t *testing.T) {
+	response, err := ParseCredentialResponse([]byte(`{"credentials":[{"credential":"a"},{"credential":"b"}],"notification_id":"n"}`))
+	if err != nil || response.Credential != "a" || len(response.Credentials) != 2 {
+		t.Error(err)
+	}
+
+	response, err = ParseCredentialResponse([]byte(`{"format":"jwt_vc_json","credential":"a"}`))
+	if err != nil || len(response.Credentials) != 1 || response.ToDraft13().Credential != "a" {
+		t.Error(err)
+	}
+
+	response, _ = ParseCredentialResponse([]byte(`{"acceptance_token":"t"}`))
+	if response.TransactionID != "t" {
+		t.Error()
+	}
+}
+
+func oauthPath(components ...interface{}) oauth.ClaimPath {
+	return components
+}
+
+func TestSdJwtFormatAliases(t *testing.T) {
+	vct := "https://credentials.example.com/identity_credential"
+	metadata := IssuerMetadata{CredentialConfigurationsSupported: map[string]CredentialConfiguration{
+		"identity": {Format: "dc+sd-jwt", Vct: &vct},
+	}}
+
+	draft, err := (&CredentialRequest{CredentialConfigurationId: "identity"}).ToDraft13(metadata)
+	if err != nil || draft.Format != "vc+sd-jwt" {
+		t.Fatal(err)
+	}
+
+	back, err := draft.ToV1(metadata)
+	if err != nil || back.CredentialConfigurationId != "identity" {
+		t.Error(err)
+	}
+
+	if metadata.ToDraft11().CredentialsSupported[0].Format != "vc+sd-jwt" {
+		t.Error()
+	}
+}
+
+func TestMdocDoctype(t *testing.T) {
+	mdl := "org.iso.18013.5.1.mDL"
+	pid := "eu.europa.ec.eudi.pid.1"
+	metadata := IssuerMetadata{CredentialConfigurationsSupported: map[string]CredentialConfiguration{
+		"mdl": {Format: "mso_mdoc", Doctype: &mdl},
+		"pid": {Format: "mso_mdoc", Doctype: &pid},
+	}}
+
+	draft, err := (&CredentialRequest{CredentialConfigurationId: "pid"}).ToDraft13(metadata)
+	if err != nil || draft.Format != "mso_mdoc" || draft.Doctype == nil || *draft.Doctype != pid {
+		t.Fatal(err)
+	}
+
+	back, err := draft.ToV1(metadata)
+	if err != nil || back.CredentialConfigurationId != "pid" {
+		t.Error(err)
+	}
+
+	if *metadata.ToDraft13().CredentialConfigurationsSupported["mdl"].Doctype != mdl {
+		t.Error()
+	}
+}
@@ -3,9 +3,8 @@ package oauth
 import (
 	"encoding/json"
 	"errors"
-	"io"
+	"fmt"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
@@ -28,8 +27,8 @@ type Token struct {
 }
 
 type Claim struct {
-	Path      string `json:"path"`
-	Mandatory bool   `json:"mandatory"`
+	Path      ClaimPath `json:"path"`
+	Mandatory bool      `json:"mandatory,omitempty"`
 }
 
 type AuthorizationDetails struct {
@@ -59,57 +58,61 @@ type OpenIdConfiguration struct {
 	Request_Uri_Parameter_Supported                  bool     `json:"request_uri_parameter_supported"`
 }
 
-func (config *OpenIdConfiguration) GetToken(grantType GrantType, options map[string]interface{}) (*Token, error) {
-
-	if grantType == PreAuthorizedCodeGrant {
-
-		interval, ok := options["interval"].(int)
+/*
+TokenRequest holds the parameters of a token request. Interval is the polling interval from the credential offer,
+it is honored for values up to MaxTokenRequestInterval seconds only.
+*/
+type TokenRequest struct {
+	GrantType         GrantType
+	PreAuthorizedCode string
+	TxCode            string
+	Interval          int
+	ClientId          string
+}
 
-		if ok {
-			if interval > 0 && interval < 10 { //be carefull with intervals from outside, can DDOS the system via link
-				time.Sleep(time.Second * time.Duration(interval))
-			} else {
-				if interval > 10 {
-					interval = 5
-				}
-			}
-		}
+// MaxTokenRequestInterval limits the waiting time taken from offers, links from outside must not block the wallet
+const MaxTokenRequestInterval = 9
 
-		tx_code, ok := options["tx_code"].(string)
+func (config *OpenIdConfiguration) GetToken(request TokenRequest) (*Token, error) {
 
-		formData := url.Values{
-			"grant_type":          {string(grantType)},
-			"pre-authorized_code": {options["code"].(string)},
-		}
+	if request.GrantType != PreAuthorizedCodeGrant {
+		return nil, fmt.Errorf("unsupported grant type %s", request.GrantType)
+	}
 
-		if ok {
-			formData.Add("tx_code", tx_code)
-		}
+	if request.PreAuthorizedCode == "" {
+		return nil, errors.New("pre-authorized_code missing")
+	}
 
-		reader := strings.NewReader(formData.Encode())
+	if request.Interval > 0 && request.Interval <= MaxTokenRequestInterval {
+		time.Sleep(time.Second * time.Duration(request.Interval))
+	}
 
-		b, err := io.ReadAll(reader)
+	formData := url.Values{
+		"grant_type":          {string(request.GrantType)},
+		"pre-authorized_code": {request.PreAuthorizedCode},
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	if request.TxCode != "" {
+		formData.Add("tx_code", request.TxCode)
+	}
 
-		b, err = helper.Post(config.Token_Endpoint, b, helper.ApplicationUrlForm, nil)
+	if request.ClientId != "" {
+		formData.Add("client_id", request.ClientId)
+	}
 
-		if err != nil {
-			return nil, err
-		}
+	b, err := helper.Post(config.Token_Endpoint, []byte(formData.Encode()), helper.ApplicationUrlForm, nil)
 
-		var tokenReply Token
+	if err != nil {
+		return nil, err
+	}
 
-		err = json.Unmarshal(b, &tokenReply)
+	var tokenReply Token
 
-		if err != nil {
-			return nil, err
-		}
+	err = json.Unmarshal(b, &tokenReply)
 
-		return &tokenReply, nil
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("token request failed")
+	return &tokenReply, nil
 }
@@ -0,0 +1,331 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrClaimNotFound = errors.New("claim not found")
+
+// ClaimPath selects a claim, the components are strings (object keys), integers (array indices) or null (all array elements).
+type ClaimPath []interface{}
+
+func (path *ClaimPath) UnmarshalJSON(b []byte) error {
+	var components []interface{}
+	if err := json.Unmarshal(b, &components); err != nil {
+		return err
+	}
+
+	if len(components) == 0 {
+		return errors.New("claim path must not be empty")
+	}
+
+	for i, component := range components {
+		switch c := component.(type) {
+		case string, nil:
+		case float64:
+			if c < 0 || c != math.Trunc(c) {
+				return fmt.Errorf("claim path component %v is no array index", c)
+			}
+			components[i] = int(c)
+		default:
+			return fmt.Errorf("invalid claim path component %v", c)
+		}
+	}
+
+	*path = components
+	return nil
+}
+
+func (path ClaimPath) String() string {
+	parts := make([]string, 0, len(path))
+	for _, component := range path {
+		switch c := component.(type) {
+		case nil:
+			parts = append(parts, "[*]")
+		case string:
+			parts = append(parts, c)
+		default:
+			parts = append(parts, fmt.Sprintf("[%v]", c))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// HasPrefix reports if path starts with all components of prefix.
+func (path ClaimPath) HasPrefix(prefix ClaimPath) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if !sameComponent(path[i], prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports if path selects the claim at concrete, a null component of path matches every index.
+func (path ClaimPath) Matches(concrete ClaimPath) bool {
+	if len(path) != len(concrete) {
+		return false
+	}
+	for i := range path {
+		if path[i] == nil {
+			if _, ok := index(concrete[i]); !ok && concrete[i] != nil {
+				return false
+			}
+			continue
+		}
+		if !sameComponent(path[i], concrete[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Resolve selects the values of the path in decoded credential json. Strings select object keys, integers array
+elements and null all elements of an array. It fails with ErrClaimNotFound if a component can not be applied.
+*/
+func (path ClaimPath) Resolve(data interface{}) ([]interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("claim path must not be empty")
+	}
+
+	selected := []interface{}{data}
+	for _, component := range path {
+		next := make([]interface{}, 0)
+		for _, value := range selected {
+			switch c := component.(type) {
+			case string:
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%w: %s is no object at %s", ErrClaimNotFound, path, c)
+				}
+				child, ok := object[c]
+				if !ok {
+					return nil, fmt.Errorf("%w: %s", ErrClaimNotFound, path)
+				}
+				next = append(next, child)
+			case nil:
+				array, ok := value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("%w: %s is no array", ErrClaimNotFound, path)
+				}
+				next = append(next, array...)
+			default:
+				i, ok := index(c)
+				array, isArray := value.([]interface{})
+				if !ok || !isArray || i >= len(array) {
+					return nil, fmt.Errorf("%w: %s", ErrClaimNotFound, path)
+				}
+				next = append(next, array[i])
+			}
+		}
+		selected = next
+	}
+	return selected, nil
+}
+
+/*
+Select returns a copy of the credential json which contains only the claims selected by the paths. Arrays keep the
+selected elements in their order. Paths which do not resolve are skipped.
+*/
+func Select(data map[string]interface{}, paths ...ClaimPath) map[string]interface{} {
+	root := &selection{}
+	for _, path := range paths {
+		if _, err := path.Resolve(data); err != nil {
+			continue
+		}
+		root.add(path)
+	}
+
+	selected, _ := root.apply(data).(map[string]interface{})
+	if selected == nil {
+		selected = map[string]interface{}{}
+	}
+	return selected
+}
+
+// selection is the tree of all selected paths
+type selection struct {
+	all     bool
+	keys    map[string]*selection
+	indices map[int]*selection
+	each    *selection
+}
+
+func (s *selection) add(path ClaimPath) {
+	if s.all {
+		return
+	}
+	if len(path) == 0 {
+		s.all = true
+		return
+	}
+	s.child(path[0]).add(path[1:])
+}
+
+func (s *selection) child(component interface{}) *selection {
+	switch c := component.(type) {
+	case string:
+		if s.keys == nil {
+			s.keys = make(map[string]*selection)
+		}
+		if s.keys[c] == nil {
+			s.keys[c] = &selection{}
+		}
+		return s.keys[c]
+	case nil:
+		if s.each == nil {
+			s.each = &selection{}
+		}
+		return s.each
+	default:
+		i, _ := index(c)
+		if s.indices == nil {
+			s.indices = make(map[int]*selection)
+		}
+		if s.indices[i] == nil {
+			s.indices[i] = &selection{}
+		}
+		return s.indices[i]
+	}
+}
+
+func (s *selection) merge(other *selection) {
+	if other.all {
+		s.all = true
+		return
+	}
+	for key, child := range other.keys {
+		s.child(key).merge(child)
+	}
+	for i, child := range other.indices {
+		s.child(i).merge(child)
+	}
+	if other.each != nil {
+		s.child(nil).merge(other.each)
+	}
+}
+
+func (s *selection) apply(value interface{}) interface{} {
+	if s.all {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, child := range s.keys {
+			if element, ok := v[key]; ok {
+				result[key] = child.apply(element)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0)
+		for i, element := range v {
+			child, ok := s.indices[i]
+			if s.each != nil {
+				merged := &selection{}
+				merged.merge(s.each)
+				if ok {
+					merged.merge(child)
+				}
+				child, ok = merged, true
+			}
+			if ok {
+				result = append(result, child.apply(element))
+			}
+		}
+		return result
+	}
+	return value
+}
+
+func index(component interface{}) (int, bool) {
+	switch c := component.(type) {
+	case int:
+		return c, c >= 0
+	case float64:
+		return int(c), c >= 0 && c == math.Trunc(c)
+	}
+	return 0, false
+}
+
+func sameComponent(a interface{}, b interface{}) bool {
+	if i, ok := index(a); ok {
+		j, ok := index(b)
+		return ok && i == j
+	}
+	return a == b
+}
+
+/*
+ParseJsonPath converts the simple JSONPath expressions of presentation definitions ($.a.b, $['a'], $.a[0], $.a[*])
+into a claim path. Filters, slices and recursive descent can not be expressed as claim path and are rejected.
+*/
+func ParseJsonPath(jsonPath string) (ClaimPath, error) {
+	rest := strings.TrimSpace(jsonPath)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("json path %s must start with $", jsonPath)
+	}
+	rest = rest[1:]
+
+	path := ClaimPath{}
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("recursive descent of json path %s not supported", jsonPath)
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			if name == "" {
+				return nil, fmt.Errorf("empty member in json path %s", jsonPath)
+			}
+			if name == "*" {
+				return nil, fmt.Errorf("member wildcard of json path %s not supported", jsonPath)
+			}
+			path = append(path, name)
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket in json path %s", jsonPath)
+			}
+			component := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(component) >= 2 && (component[0] == '\'' || component[0] == '"') && component[len(component)-1] == component[0] {
+				path = append(path, component[1:len(component)-1])
+				continue
+			}
+			if component == "*" {
+				path = append(path, nil)
+				continue
+			}
+			i, err := strconv.Atoi(component)
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("unsupported json path component [%s] in %s", component, jsonPath)
+			}
+			path = append(path, i)
+		default:
+			return nil, fmt.Errorf("invalid json path %s", jsonPath)
+		}
+	}
+
+	if len(path) == 0 {
+		return nil, errors.New("claim path must not be empty")
+	}
+	return path, nil
+}
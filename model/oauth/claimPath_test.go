@@ -0,0 +1,94 @@
+package oauth
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const credentialJson = `{
+	"name": "Erika",
+	"address": {"street": "Heidestrasse 17", "locality": "Köln"},
+	"degrees": [
+		{"type": "Bachelor", "university": "A"},
+		{"type": "Master", "university": "B"}
+	]
+}`
+
+func TestClaimPathUnmarshal(t *testing.T) {
+	var path ClaimPath
+	if err := json.Unmarshal([]byte(`["degrees", null, 1, "type"]`), &path); err != nil {
+		t.Fatal(err)
+	}
+
+	if path[1] != nil || path[2] != 1 {
+		t.Error(path)
+	}
+
+	for _, invalid := range []string{`[]`, `["a", 1.5]`, `["a", -1]`, `[true]`, `[{"a":1}]`} {
+		if json.Unmarshal([]byte(invalid), &path) == nil {
+			t.Error("path accepted: " + invalid)
+		}
+	}
+}
+
+func TestClaimPathResolve(t *testing.T) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(credentialJson), &data)
+
+	values, err := ClaimPath{"degrees", nil, "type"}.Resolve(data)
+	if err != nil || !reflect.DeepEqual(values, []interface{}{"Bachelor", "Master"}) {
+		t.Error(values, err)
+	}
+
+	values, err = ClaimPath{"degrees", 1, "university"}.Resolve(data)
+	if err != nil || values[0] != "B" {
+		t.Error(values, err)
+	}
+
+	if _, err = (ClaimPath{"address", "country"}).Resolve(data); err == nil {
+		t.Error("missing claim resolved")
+	}
+
+	if _, err = (ClaimPath{"name", 0}).Resolve(data); err == nil {
+		t.Error("index on string resolved")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var data map[string]interface{}
+	json.Unmarshal([]byte(credentialJson), &data)
+
+	selected := Select(data, ClaimPath{"address", "street"}, ClaimPath{"degrees", nil, "type"}, ClaimPath{"degrees", 1}, ClaimPath{"unknown"})
+
+	var expected map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"address": {"street": "Heidestrasse 17"},
+		"degrees": [{"type": "Bachelor"}, {"type": "Master", "university": "B"}]
+	}`), &expected)
+
+	if !reflect.DeepEqual(selected, expected) {
+		t.Error(selected)
+	}
+}
+
+func TestParseJsonPath(t *testing.T) {
+	for jsonPath, expected := range map[string]ClaimPath{
+		"$.given_name":                       {"given_name"},
+		"$.address.street_address":           {"address", "street_address"},
+		"$['vc']['credentialSubject'].email": {"vc", "credentialSubject", "email"},
+		"$.nationalities[0]":                 {"nationalities", 0},
+		"$.nationalities[*]":                 {"nationalities", nil},
+	} {
+		path, err := ParseJsonPath(jsonPath)
+		if err != nil || !reflect.DeepEqual(path, expected) {
+			t.Error(jsonPath, path, err)
+		}
+	}
+
+	for _, jsonPath := range []string{"given_name", "$", "$..name", "$.a[?(@.b)]", "$.a[", "$.*"} {
+		if _, err := ParseJsonPath(jsonPath); err == nil {
+			t.Error(jsonPath, "accepted")
+		}
+	}
+}
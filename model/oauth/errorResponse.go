@@ -0,0 +1,26 @@
+package oauth
+
+const (
+	//	Token Error Options (RFC 6749 5.2)
+	InvalidRequest       = "invalid_request"
+	InvalidClient        = "invalid_client"
+	InvalidGrant         = "invalid_grant"
+	UnauthorizedClient   = "unauthorized_client"
+	UnsupportedGrantType = "unsupported_grant_type"
+	InvalidScope         = "invalid_scope"
+	SlowDown             = "slow_down"
+)
+
+const AuthorizationDetailsTypeOpenIdCredential = "openid_credential"
+
+type ErrorResponse struct {
+	ErrorMsg         string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func (e ErrorResponse) Error() string {
+	if e.ErrorDescription != "" {
+		return e.ErrorMsg + ": " + e.ErrorDescription
+	}
+	return e.ErrorMsg
+}
@@ -0,0 +1,43 @@
+package presentation
+
+import (
+	"errors"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/ldp"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+)
+
+/*
+PresentLdp creates the ldp_vp vp_token for the request: a VerifiablePresentation of the credentials, secured by the
+holder with the nonce as challenge and the client_id as domain. The credentials must be in the order of the selection
+passed to CreateSubmission, which references them by $.verifiableCredential[i].
+*/
+func (request *RequestObject) PresentLdp(credentials []map[string]interface{}, signer *ldp.Signer) (map[string]interface{}, error) {
+	if signer == nil {
+		return nil, errors.New("signer is nil")
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("no credentials to present")
+	}
+
+	// the presentation uses the data model of the first credential
+	context := types.ContextV2
+	if contexts, ok := credentials[0]["@context"].([]interface{}); ok && len(contexts) > 0 && contexts[0] == types.ContextV1 {
+		context = types.ContextV1
+	}
+
+	embedded := make([]interface{}, 0, len(credentials))
+	for _, credential := range credentials {
+		if _, ok := credential["proof"]; !ok {
+			return nil, errors.New("credential without proof")
+		}
+		embedded = append(embedded, credential)
+	}
+
+	return signer.SignPresentation(map[string]interface{}{
+		"@context":             []interface{}{context},
+		"type":                 []interface{}{types.VerifiablePresentationType},
+		"holder":               signer.Holder(),
+		"verifiableCredential": embedded,
+	}, request.Nonce, request.ClientID)
+}
@@ -0,0 +1,70 @@
+package presentation
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/did"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/ldp"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func testLdpSigner(t *testing.T) *ldp.Signer {
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := jwk.FromRaw(private)
+	public, _ := key.PublicKey()
+	multikey, err := did.EncodeMultikey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwkSigner, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ldp.NewSigner(jwkSigner, "did:key:"+multikey+"#"+multikey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestLdpPresentation(t *testing.T) {
+	issuer, holder := testLdpSigner(t), testLdpSigner(t)
+
+	credential, err := issuer.SignCredential(map[string]interface{}{
+		"@context":          []interface{}{"https://www.w3.org/ns/credentials/v2"},
+		"type":              []interface{}{"VerifiableCredential", "AlumniCredential"},
+		"issuer":            issuer.Holder(),
+		"credentialSubject": map[string]interface{}{"id": holder.Holder(), "alumniOf": "The School of Examples"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := RequestObject{Nonce: "n-0S6_WzA2Mj", ClientID: "x509_san_dns:verifier.example.org"}
+	vpToken, err := request.PresentLdp([]map[string]interface{}{credential}, holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	submission := CreateSubmission("alumni", []Description{{Id: "alumni_credential", FormatType: "ldp_vc"}})
+	if submission.DescriptorMap[0].Path != "$.verifiableCredential[0]" {
+		t.Error(submission.DescriptorMap)
+	}
+
+	vp, credentials, err := ldp.NewVerifier(nil).VerifyPresentation(vpToken, request.Nonce, request.ClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vp.Holder != holder.Holder() || len(credentials) != 1 || credentials[0].Subject != holder.Holder() {
+		t.Error(vp, credentials)
+	}
+
+	if _, _, err := ldp.NewVerifier(nil).VerifyPresentation(vpToken, request.Nonce, "x509_san_dns:other.example.org"); err == nil {
+		t.Error("presentation for another client accepted")
+	}
+}
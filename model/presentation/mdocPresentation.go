@@ -0,0 +1,164 @@
+package presentation
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mdoc"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+/*
+Handover binds an mdoc device response to the authorization request. With MdocGeneratedNonce the handover of
+ISO 18013-7 (OID4VP drafts) is used, the nonce is sent as apu of the encrypted response. Otherwise the
+OpenID4VPHandover of OID4VP 1.0 is used, which contains the JwkThumbprint of the verifier encryption key if the
+response is encrypted.
+*/
+type Handover struct {
+	ClientId           string
+	ResponseUri        string
+	Nonce              string
+	MdocGeneratedNonce string
+	JwkThumbprint      []byte
+}
+
+// Handover returns the handover of the request, see Handover for the parameters.
+func (request *RequestObject) Handover(mdocGeneratedNonce string, jwkThumbprint []byte) Handover {
+	return Handover{
+		ClientId:           request.ClientID,
+		ResponseUri:        request.ResponseUri,
+		Nonce:              request.Nonce,
+		MdocGeneratedNonce: mdocGeneratedNonce,
+		JwkThumbprint:      jwkThumbprint,
+	}
+}
+
+// SessionTranscript returns the CBOR encoded session transcript [null, null, handover] which the device signs.
+func (handover Handover) SessionTranscript() ([]byte, error) {
+	var oid4vpHandover []interface{}
+	if handover.MdocGeneratedNonce != "" {
+		clientIdHash, err := cborHash(handover.ClientId, handover.MdocGeneratedNonce)
+		if err != nil {
+			return nil, err
+		}
+		responseUriHash, err := cborHash(handover.ResponseUri, handover.MdocGeneratedNonce)
+		if err != nil {
+			return nil, err
+		}
+		oid4vpHandover = []interface{}{clientIdHash, responseUriHash, handover.Nonce}
+	} else {
+		var thumbprint interface{}
+		if len(handover.JwkThumbprint) > 0 {
+			thumbprint = handover.JwkThumbprint
+		}
+		infoHash, err := cborHash(handover.ClientId, handover.Nonce, thumbprint, handover.ResponseUri)
+		if err != nil {
+			return nil, err
+		}
+		oid4vpHandover = []interface{}{"OpenID4VPHandover", infoHash}
+	}
+	return cbor.Marshal([]interface{}{nil, nil, oid4vpHandover})
+}
+
+func cborHash(values ...interface{}) ([]byte, error) {
+	b, err := cbor.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(b)
+	return hash[:], nil
+}
+
+/*
+PresentMdoc discloses the data elements requested by the fields of the descriptor, fields with path
+$['namespace']['element'], and returns the base64url encoded DeviceResponse for the vp_token. The signer must hold the
+device key of the mdoc.
+*/
+func (descriptor *InputDescriptor) PresentMdoc(credential string, signer signing.Signer, handover Handover) (string, error) {
+	issuerSigned, err := mdoc.ParseIssuerSigned(credential)
+	if err != nil {
+		return "", err
+	}
+
+	fields, err := descriptor.Constraints.ClaimPaths()
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]oauth.ClaimPath, 0, len(fields))
+	for _, alternatives := range fields {
+		found := false
+		for _, path := range alternatives {
+			if _, err := issuerSigned.Disclose(path); err == nil {
+				paths = append(paths, path)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("%w: %v", oauth.ErrClaimNotFound, alternatives)
+		}
+	}
+
+	disclosed, err := issuerSigned.Disclose(paths...)
+	if err != nil {
+		return "", err
+	}
+
+	transcript, err := handover.SessionTranscript()
+	if err != nil {
+		return "", err
+	}
+	document, err := mdoc.Present(disclosed, signer, transcript)
+	if err != nil {
+		return "", err
+	}
+	return mdoc.NewDeviceResponse(*document).String(), nil
+}
+
+/*
+VerifyMdocResponse checks the DeviceResponse of a vp_token entry against the handover of the request and returns the
+disclosed data elements of the document with the doctype. An empty doctype accepts a response with a single document.
+*/
+func VerifyMdocResponse(vpToken string, docType string, handover Handover, verifier *mdoc.Verifier) (*types.Credential, error) {
+	if verifier == nil {
+		return nil, errors.New("mdoc verifier is nil")
+	}
+
+	response, err := mdoc.ParseDeviceResponse(vpToken)
+	if err != nil {
+		return nil, err
+	}
+	if response.Status != mdoc.StatusOK {
+		return nil, fmt.Errorf("device response has status %d", response.Status)
+	}
+
+	var document *mdoc.ResponseDocument
+	for i, d := range response.Documents {
+		if d.DocType == docType || docType == "" && len(response.Documents) == 1 {
+			document = &response.Documents[i]
+		}
+	}
+	if document == nil {
+		return nil, fmt.Errorf("device response contains no document %s", docType)
+	}
+
+	transcript, err := handover.SessionTranscript()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := verifier.VerifyDocument(document, transcript); err != nil {
+		return nil, err
+	}
+
+	claims, err := document.IssuerSigned.Claims()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Credential{Format: types.MSOMDOC, Json: claims}, nil
+}
@@ -0,0 +1,127 @@
+package presentation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mdoc"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func testSigner(t *testing.T) signing.Signer {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := jwk.FromRaw(raw)
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// testMdoc issues an mDL bound to the device signer and returns it with the roots trusting the document signer.
+func testMdoc(t *testing.T, device signing.Signer) (string, *x509.CertPool) {
+	raw, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "document signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		UnknownExtKeyUsage:    []asn1.ObjectIdentifier{mdoc.DocumentSignerKeyUsage},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &raw.PublicKey, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	key, _ := jwk.FromRaw(raw)
+	signer, _ := signing.NewJwkSigner(key)
+	issued, err := mdoc.NewIssuer(signer, certificate).Issue(mdoc.Document{
+		DocType: "org.iso.18013.5.1.mDL",
+		NameSpaces: map[string]map[string]interface{}{
+			"org.iso.18013.5.1": {"family_name": "Doe", "given_name": "John", "portrait": []byte{0xff}},
+		},
+		DeviceKey:  device.PublicKey(),
+		ValidUntil: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issued.String(), roots
+}
+
+func TestSessionTranscript(t *testing.T) {
+	request := RequestObject{ClientID: "x509_san_dns:verifier.example.com", ResponseUri: "https://verifier.example.com/response", Nonce: "n"}
+
+	v1, err := request.Handover("", []byte{1, 2}).SessionTranscript()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unencrypted, _ := request.Handover("", nil).SessionTranscript()
+	draft, _ := request.Handover("mdoc-nonce", nil).SessionTranscript()
+
+	if bytes.Equal(v1, unencrypted) || bytes.Equal(v1, draft) {
+		t.Error("handover parameters must change the session transcript")
+	}
+	// [null, null, [...]]
+	if v1[0] != 0x83 || v1[1] != 0xf6 || v1[2] != 0xf6 {
+		t.Error()
+	}
+}
+
+func TestMdocPresentation(t *testing.T) {
+	device := testSigner(t)
+	credential, roots := testMdoc(t, device)
+
+	var definition PresentationDefinition
+	if err := json.Unmarshal([]byte(testDefinitionMdoc), &definition); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := definition.Filter(map[string]interface{}{"mdl": credential})
+	if err != nil || len(res) != 1 || res[0].FormatType != "mso_mdoc" {
+		t.Fatal(res, err)
+	}
+
+	request := RequestObject{ClientID: "verifier", ResponseUri: "https://verifier.example.com/response", Nonce: "n-0S6_WzA2Mj"}
+	handover := request.Handover("", nil)
+
+	descriptor := definition.InputDescriptors[0]
+	vpToken, err := descriptor.PresentMdoc(credential, device, handover)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err := VerifyMdocResponse(vpToken, descriptor.Id, handover, mdoc.NewVerifier(roots))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := verified.Json["org.iso.18013.5.1"].(map[string]interface{})
+	if len(elements) != 1 || elements["family_name"] != "Doe" {
+		t.Error("only the requested elements should be disclosed", elements)
+	}
+
+	request.Nonce = "other"
+	if _, err := VerifyMdocResponse(vpToken, descriptor.Id, request.Handover("", nil), mdoc.NewVerifier(roots)); err == nil {
+		t.Error("response for another nonce accepted")
+	}
+}
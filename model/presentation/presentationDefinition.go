@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
 	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
 	"github.com/oliveagle/jsonpath"
 )
@@ -68,13 +69,21 @@ const (
 )
 
 type Format struct {
-	SDJWT *FormatSpecification `json:"verifiable-credential+sd-jwt,omitempty"`
-	LDPVP *FormatSpecification `json:"ldp_vp,omitempty"`
-	LDP   *FormatSpecification `json:"ldp,omitempty"`
-	LDPVC *FormatSpecification `json:"ldp_vc,omitempty"`
-	JWT   *FormatSpecification `json:"jwt,omitempty"`
-	JWTVC *FormatSpecification `json:"jwt_vc,omitempty"`
-	JWTVP *FormatSpecification `json:"jwt_vp,omitempty"`
+	DCSDJWT *FormatSpecification `json:"dc+sd-jwt,omitempty"`
+	// VCSDJWT and SDJWT are the SD-JWT VC identifiers of older drafts
+	VCSDJWT *FormatSpecification `json:"vc+sd-jwt,omitempty"`
+	SDJWT   *FormatSpecification `json:"verifiable-credential+sd-jwt,omitempty"`
+	LDPVP   *FormatSpecification `json:"ldp_vp,omitempty"`
+	LDP     *FormatSpecification `json:"ldp,omitempty"`
+	LDPVC   *FormatSpecification `json:"ldp_vc,omitempty"`
+	JWT     *FormatSpecification `json:"jwt,omitempty"`
+	JWTVC   *FormatSpecification `json:"jwt_vc,omitempty"`
+	JWTVP   *FormatSpecification `json:"jwt_vp,omitempty"`
+	MSOMDOC *FormatSpecification `json:"mso_mdoc,omitempty"`
+	// JWTVCJSON and JWTVPJSON are the identifiers of OID4VP for W3C VCs secured as JWT, with and without JSON-LD
+	JWTVCJSON   *FormatSpecification `json:"jwt_vc_json,omitempty"`
+	JWTVCJSONLD *FormatSpecification `json:"jwt_vc_json-ld,omitempty"`
+	JWTVPJSON   *FormatSpecification `json:"jwt_vp_json,omitempty"`
 	//TODO: add others
 }
 
@@ -86,6 +95,36 @@ type Field struct {
 	Name    string   `json:"name,omitempty"`    //Optional Field
 }
 
+// ClaimPaths converts the alternative json paths of the field, expressions which are no claim path are skipped.
+func (field *Field) ClaimPaths() ([]oauth.ClaimPath, error) {
+	paths := make([]oauth.ClaimPath, 0, len(field.Path))
+	for _, p := range field.Path {
+		path, err := oauth.ParseJsonPath(p)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("field %s has no path which can be expressed as claim path", field.Id)
+	}
+	return paths, nil
+}
+
+// ClaimPaths returns the alternative claim paths of every field, e.g. to select the disclosures of an SD-JWT.
+func (constraints *Constraints) ClaimPaths() ([][]oauth.ClaimPath, error) {
+	fields := make([][]oauth.ClaimPath, 0, len(constraints.Fields))
+	for _, field := range constraints.Fields {
+		paths, err := field.ClaimPaths()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, paths)
+	}
+	return fields, nil
+}
+
 type Filter struct {
 	Type    string `json:"type"`
 	Pattern string `json:"pattern"`
@@ -122,6 +161,9 @@ const (
 	Ed25519Signature2018        ProofType = "Ed25519Signature2018"
 	EcdsaSecp256k1Signature2019 ProofType = "EcdsaSecp256k1Signature2019"
 	RsaSignature2018            ProofType = "RsaSignature2018"
+	Ed25519Signature2020        ProofType = "Ed25519Signature2020"
+	// DataIntegrityProof names the cryptosuite (eddsa-rdfc-2022, ecdsa-rdfc-2019) in the proof
+	DataIntegrityProof ProofType = "DataIntegrityProof"
 	//TODO Add More
 )
 
@@ -161,7 +203,7 @@ func (definition *PresentationDefinition) Filter(credentials map[string]interfac
 				}
 
 				if b {
-					d.Description.FormatType = string(credential.Format)
+					d.Description.FormatType = submissionFormat(credential.Format, &d.Format, &definition.Format)
 					if temp[d.Description] == nil {
 						temp[d.Description] = &FilterResult{
 							Credentials: make(map[string]CredentialResult),
@@ -198,14 +240,51 @@ func (definition *PresentationDefinition) Filter(credentials map[string]interfac
 	return result, nil
 }
 
+/*
+SdJwt returns the SD-JWT VC identifier the verifier asked for together with its specification, an empty identifier
+if no SD-JWT VC is requested. dc+sd-jwt is preferred over the identifiers of older drafts.
+*/
+func (format *Format) SdJwt() (string, *FormatSpecification) {
+	switch {
+	case format == nil:
+		return "", nil
+	case format.DCSDJWT != nil:
+		return string(types.DCSDJWT), format.DCSDJWT
+	case format.VCSDJWT != nil:
+		return string(types.SDJWT), format.VCSDJWT
+	case format.SDJWT != nil:
+		return "verifiable-credential+sd-jwt", format.SDJWT
+	}
+	return "", nil
+}
+
+// submissionFormat answers SD-JWT VCs with the identifier the verifier requested, so that older verifiers understand it.
+func submissionFormat(format types.CredentialFormat, requested ...*Format) string {
+	if !types.IsSdJwt(string(format)) {
+		return string(format)
+	}
+	for _, r := range requested {
+		if identifier, _ := r.SdJwt(); identifier != "" {
+			return identifier
+		}
+	}
+	return string(format)
+}
+
 func (format *Format) CheckFormats() error {
 	formats := []*FormatSpecification{
+		format.DCSDJWT,
+		format.VCSDJWT,
 		format.SDJWT,
 		format.LDPVP,
 		format.LDP,
 		format.JWT,
 		format.JWTVC,
-		format.JWTVP}
+		format.JWTVP,
+		format.MSOMDOC,
+		format.JWTVCJSON,
+		format.JWTVCJSONLD,
+		format.JWTVPJSON}
 
 	var allEmpty = true
 	for _, f := range formats {
@@ -245,7 +324,7 @@ func (descriptor *InputDescriptor) Filter(credential *types.Credential) (bool, e
 		var match = false
 
 		for _, p := range c.Path {
-			res, err := jsonpath.JsonPathLookup(credential.Json, p)
+			res, err := lookup(credential.Json, p)
 			if err != nil {
 				continue
 			}
@@ -256,6 +335,30 @@ func (descriptor *InputDescriptor) Filter(credential *types.Credential) (bool, e
 	return descriptorfullFilled, nil
 }
 
+/*
+lookup evaluates the json path, paths with bracket notation like $['org.iso.18013.5.1']['family_name'] of mdoc
+namespaces are resolved as claim path.
+*/
+func lookup(data map[string]interface{}, path string) (interface{}, error) {
+	res, err := jsonpath.JsonPathLookup(data, path)
+	if err == nil {
+		return res, nil
+	}
+
+	claimPath, parseErr := oauth.ParseJsonPath(path)
+	if parseErr != nil {
+		return nil, err
+	}
+	values, err := claimPath.Resolve(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	}
+	return values, nil
+}
+
 func (descriptor *InputDescriptor) ApplyFieldFilter(value interface{}, filter Field) bool {
 	if value != nil {
 		switch value.(type) {
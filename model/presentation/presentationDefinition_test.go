@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
 	"github.com/sirupsen/logrus"
 )
 
@@ -394,3 +395,79 @@ const testEmptyDefinition = `{
 	"id": "32f54163-7166-48f1-93d8-ff217bdb0653",
 	"input_descriptors": []
 }`
+
+func TestConstraintsClaimPaths(t *testing.T) {
+	constraints := Constraints{Fields: []Field{
+		{Path: []string{"$.given_name"}},
+		{Path: []string{"$.vc.credentialSubject.email", "$..email", "$.email"}},
+	}}
+
+	fields, err := constraints.ClaimPaths()
+	if err != nil || len(fields) != 2 || len(fields[1]) != 2 || fields[1][1][0] != "email" {
+		t.Error(fields, err)
+	}
+
+	constraints.Fields = append(constraints.Fields, Field{Id: "filter", Path: []string{"$.a[?(@.b)]"}})
+	if _, err := constraints.ClaimPaths(); err == nil {
+		t.Error("field without claim path accepted")
+	}
+}
+
+func TestSdJwtFormatIdentifiers(t *testing.T) {
+	var format Format
+	json.Unmarshal([]byte(`{"dc+sd-jwt": {"alg": ["ES256"]}, "vc+sd-jwt": {}}`), &format)
+
+	if identifier, specification := format.SdJwt(); identifier != "dc+sd-jwt" || specification.Alg[0] != ES256 {
+		t.Error(identifier)
+	}
+	if err := format.CheckFormats(); err != nil {
+		t.Error(err)
+	}
+
+	legacy := Format{SDJWT: &FormatSpecification{}}
+	if submissionFormat(types.DCSDJWT, nil, &legacy) != "verifiable-credential+sd-jwt" || submissionFormat(types.LDPVC, &legacy) != "ldp_vc" {
+		t.Error()
+	}
+	if submissionFormat(types.SDJWT, &Format{}) != "vc+sd-jwt" {
+		t.Error()
+	}
+}
+
+func TestInputDescriptorFilteringWithNamespaces(t *testing.T) {
+	var definition PresentationDefinition
+	err := json.Unmarshal([]byte(testDefinitionMdoc), &definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := definition.InputDescriptors[0].Format.CheckFormats(); err != nil {
+		t.Error(err)
+	}
+
+	res, err := definition.Filter(map[string]interface{}{
+		"mdl":   `{"org.iso.18013.5.1": {"family_name": "Doe", "given_name": "John"}}`,
+		"other": `{"org.iso.18013.5.1": {"given_name": "John"}}`,
+	})
+	if err != nil || len(res) != 1 {
+		t.Fatal(res, err)
+	}
+	if _, ok := res[0].Credentials["mdl"]; !ok || len(res[0].Credentials) != 1 {
+		t.Error()
+	}
+}
+
+const testDefinitionMdoc = `{
+	"id": "mdl-test",
+	"input_descriptors": [
+		{
+			"id": "org.iso.18013.5.1.mDL",
+			"format": {"mso_mdoc": {"alg": ["ES256"]}},
+			"constraints": {
+				"limit_disclosure": "required",
+				"fields": [
+					{"path": ["$['org.iso.18013.5.1']['family_name']"], "intent_to_retain": false}
+				]
+			}
+		}
+	]
+}`
@@ -66,3 +66,30 @@ func CreateSubmission(definitionId string, selection []Description) Presentation
 
 	return submission
 }
+
+/*
+CreateTokenSubmission maps every selected description to its own vp_token entry, as used for SD-JWT VCs and mdoc
+device responses. A single entry is the vp_token itself, multiple entries are elements of the vp_token array.
+*/
+func CreateTokenSubmission(definitionId string, selection []Description) PresentationSubmission {
+	submission := PresentationSubmission{
+		Id:            uuid.NewString(),
+		DefinitionId:  definitionId,
+		DescriptorMap: make([]Descriptor, 0, len(selection)),
+	}
+
+	for i, d := range selection {
+		path := "$"
+		if len(selection) > 1 {
+			path = fmt.Sprintf("$[%d]", i)
+		}
+
+		submission.DescriptorMap = append(submission.DescriptorMap, Descriptor{
+			Id:     d.Id,
+			Path:   path,
+			Format: d.FormatType,
+		})
+	}
+
+	return submission
+}
@@ -13,3 +13,15 @@ func TestSubmission(t *testing.T) {
 		t.Error()
 	}
 }
+
+func TestTokenSubmission(t *testing.T) {
+	sub := CreateTokenSubmission("123", []Description{{Id: "org.iso.18013.5.1.mDL", FormatType: "mso_mdoc"}})
+	if sub.DescriptorMap[0].Path != "$" || sub.DescriptorMap[0].Format != "mso_mdoc" {
+		t.Error()
+	}
+
+	sub = CreateTokenSubmission("123", []Description{{Id: "a"}, {Id: "b"}})
+	if sub.DescriptorMap[1].Path != "$[1]" {
+		t.Error()
+	}
+}
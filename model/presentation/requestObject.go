@@ -1,6 +1,12 @@
 package presentation
 
-import "github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+)
 
 type RequestObject struct {
 	Client_Id_Scheme          string                 `json:"client_id_scheme,omitempty"`
@@ -13,4 +19,52 @@ type RequestObject struct {
 	ClientID                  string                 `json:"client_id"`
 	ResponseUri               string                 `json:"response_uri,omitempty"`
 	ResponseMode              types.ResponseMode     `json:"response_mode"`
+	ClientMetadata            *ClientMetadata        `json:"client_metadata,omitempty"`
+}
+
+type ClientMetadata struct {
+	Jwks                              map[string]interface{} `json:"jwks,omitempty"`
+	AuthorizationEncryptedResponseAlg string                 `json:"authorization_encrypted_response_alg,omitempty"`
+	AuthorizationEncryptedResponseEnc string                 `json:"authorization_encrypted_response_enc,omitempty"`
+	VpFormats                         *Format                `json:"vp_formats,omitempty"`
+}
+
+/*
+Creates an openid4vp:// authorization request link which passes the request object by reference. Wallets fetch the
+request object from requestUri.
+*/
+func (request *RequestObject) CreateRequestUriLink(requestUri string) string {
+	return fmt.Sprintf("openid4vp://?client_id=%s&request_uri=%s", url.QueryEscape(request.ClientID), url.QueryEscape(requestUri))
+}
+
+// Creates an openid4vp:// authorization request link which contains all parameters of the request object by value.
+func (request *RequestObject) CreateRequestLink() (string, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal request object: %w", err)
+	}
+
+	var parameters map[string]interface{}
+	if err := json.Unmarshal(b, &parameters); err != nil {
+		return "", err
+	}
+
+	values := url.Values{}
+	for k, v := range parameters {
+		switch value := v.(type) {
+		case string:
+			if value != "" {
+				values.Set(k, value)
+			}
+		case nil:
+		default:
+			b, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			values.Set(k, string(b))
+		}
+	}
+
+	return "openid4vp://?" + values.Encode(), nil
 }
@@ -2,6 +2,13 @@
 // SPDX-License-Identifier: Apache-2.0
 package presentation
 
+const (
+	StatePending    = "pending"
+	StateInProgress = "in_progress"
+	StateAccepted   = "accepted"
+	StateRejected   = "rejected"
+)
+
 type StateResponse struct {
 	ID                 string             `json:"id,omitempty"`
 	State              string             `json:"state,omitempty"`
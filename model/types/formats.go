@@ -1,13 +1,17 @@
 package types
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"strings"
+	"time"
 
 	go_sd_jwt "github.com/MichaelFraser99/go-sd-jwt"
 	"github.com/lestrrat-go/jwx/v2/jwt"
 	"github.com/sirupsen/logrus"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mdoc"
 )
 
 type CredentialFormat string
@@ -15,18 +19,56 @@ type PresentationFormat string
 
 const (
 	SDJWT   CredentialFormat = "vc+sd-jwt"
+	DCSDJWT CredentialFormat = "dc+sd-jwt"
 	JWTVC   CredentialFormat = "jwt_vc"
 	LDPVC   CredentialFormat = "ldp_vc"
+	MSOMDOC CredentialFormat = "mso_mdoc"
 	UNKNOWN CredentialFormat = "unknown"
+
+	JWTVCJSON   CredentialFormat = "jwt_vc_json"
+	JWTVCJSONLD CredentialFormat = "jwt_vc_json-ld"
 )
 
+// SdJwtFormats are the identifiers of SD-JWT VCs. vc+sd-jwt is the identifier of older drafts and kept as alias.
+var SdJwtFormats = []CredentialFormat{DCSDJWT, SDJWT}
+
+// IsSdJwt reports if format is one of the SD-JWT VC identifiers.
+func IsSdJwt(format string) bool {
+	for _, f := range SdJwtFormats {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+// IsJwtVc reports if format is one of the identifiers of W3C VCs secured as JWT.
+func IsJwtVc(format string) bool {
+	return format == string(JWTVC) || format == string(JWTVCJSON) || format == string(JWTVCJSONLD)
+}
+
+// SameFormat compares credential format identifiers, the SD-JWT VC identifiers and jwt_vc/jwt_vc_json are considered equal.
+func SameFormat(a string, b string) bool {
+	return a == b || IsSdJwt(a) && IsSdJwt(b) || jwtVcJson(a) && jwtVcJson(b)
+}
+
+func jwtVcJson(format string) bool {
+	return format == string(JWTVC) || format == string(JWTVCJSON)
+}
+
 const (
-	LDPVP PresentationFormat = "ldp_vp"
+	LDPVP     PresentationFormat = "ldp_vp"
+	JWTVPJSON PresentationFormat = "jwt_vp_json"
 )
 
 type Credential struct {
 	Format CredentialFormat
 	Json   map[string]interface{}
+	// Issuer, Subject and the validity window are set by verification, see JwtVerifier.
+	Issuer     string
+	Subject    string
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
 }
 
 func CheckFormat(credential interface{}) (*Credential, error) {
@@ -76,7 +118,22 @@ func CheckFormat(credential interface{}) (*Credential, error) {
 				logrus.Info(s)
 				return &c, err
 			}
-			c.Format = SDJWT
+			c.Format = sdJwtFormat(s)
+
+		} else if !strings.Contains(s, ".") {
+			// mdocs are the base64url encoded IssuerSigned structure
+			issuerSigned, err := mdoc.ParseIssuerSigned(s)
+			if err != nil {
+				logrus.Error(err)
+				return &c, err
+			}
+
+			c.Json, err = issuerSigned.Claims()
+			if err != nil {
+				logrus.Error(err)
+				return &c, err
+			}
+			c.Format = MSOMDOC
 
 		} else {
 
@@ -88,6 +145,16 @@ func CheckFormat(credential interface{}) (*Credential, error) {
 			}
 			c.Json = tok.PrivateClaims()
 			c.Format = JWTVC
+
+			// VCDM 2.0 credentials are the payload itself and validated on parsing
+			if isVcdm2(s, c.Json) {
+				if _, err := ParseJwtCredential(s); err != nil {
+					logrus.Error(err)
+					logrus.Info(s)
+					return &c, err
+				}
+				c.Format = JWTVCJSON
+			}
 		}
 
 		return &c, nil
@@ -95,3 +162,30 @@ func CheckFormat(credential interface{}) (*Credential, error) {
 
 	return &c, errors.ErrUnsupported
 }
+
+// sdJwtFormat derives the format from the typ header, tokens without dc+sd-jwt typ keep the older identifier.
+func sdJwtFormat(s string) CredentialFormat {
+	segment, _, _ := strings.Cut(s, ".")
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return SDJWT
+	}
+
+	var header struct {
+		Typ string `json:"typ"`
+	}
+	if json.Unmarshal(b, &header) == nil && header.Typ == string(DCSDJWT) {
+		return DCSDJWT
+	}
+	return SDJWT
+}
+
+// isVcdm2 detects VCDM 2.0 credentials by the vc+jwt typ header or the base context in the payload.
+func isVcdm2(s string, claims map[string]interface{}) bool {
+	header, _, err := decodeJwt(s)
+	if err == nil && header["typ"] == MediaTypeVcJwt {
+		return true
+	}
+	contexts := stringValues(claims["@context"])
+	return len(contexts) > 0 && contexts[0] == ContextV2
+}
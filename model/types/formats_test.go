@@ -124,3 +124,37 @@ const Ldp_Vc = `{
 		"verificationMethod": "did:example:holder#key-1"
 	}
 }`
+
+func TestSdJwtFormats(t *testing.T) {
+	if !IsSdJwt("dc+sd-jwt") || !IsSdJwt("vc+sd-jwt") || IsSdJwt("jwt_vc_json") {
+		t.Error()
+	}
+
+	if !SameFormat("dc+sd-jwt", "vc+sd-jwt") || SameFormat("dc+sd-jwt", "ldp_vc") {
+		t.Error()
+	}
+
+	// header {"alg":"ES256","typ":"dc+sd-jwt"}
+	if sdJwtFormat("eyJhbGciOiJFUzI1NiIsInR5cCI6ImRjK3NkLWp3dCJ9.e30.c2ln~") != DCSDJWT || sdJwtFormat("e30.e30.c2ln~") != SDJWT {
+		t.Error()
+	}
+}
+
+func TestMdocFormat(t *testing.T) {
+	credential, err := CheckFormat(MsoMdoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if credential.Format != MSOMDOC {
+		t.Error()
+	}
+
+	elements, _ := credential.Json["org.iso.18013.5.1"].(map[string]interface{})
+	if elements["family_name"] != "Mustermann" || elements["given_name"] != "Erika" {
+		t.Error()
+	}
+}
+
+// IssuerSigned of an org.iso.18013.5.1.mDL with family_name and given_name
+const MsoMdoc = `omppc3N1ZXJBdXRohEOhASahGCFZAWgwggFkMIIBC6ADAgECAgEBMAoGCCqGSM49BAMCMBoxGDAWBgNVBAMTD2RvY3VtZW50IHNpZ25lcjAeFw0yNjEwMTkwMTA3MzNaFw0yNjEwMTkwMzA3MzNaMBoxGDAWBgNVBAMTD2RvY3VtZW50IHNpZ25lcjBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOaALZ7Oo0DNH3ObNs_0WLSiLa0p4vRFT2L9LtvLt5sOkyO5_tm6W62n_0TrXnRJzgeMIYKdiaq4SkSqdWwE_P-jQjBAMA4GA1UdDwEB_wQEAwIChDAPBgNVHRMBAf8EBTADAQH_MB0GA1UdDgQWBBSkXVkLUeyC6FiqbMPm2H6yRDkZXDAKBggqhkjOPQQDAgNHADBEAiBTJKuf_ZZwJqCb5pq60IKxpwNco_C_tedJjwrw_PnvfQIgNulkM4pgp933uOU-RPYP6kgh0sOZdLEnKbihxR0AyzBZAYHYGFkBfKZnZG9jVHlwZXVvcmcuaXNvLjE4MDEzLjUuMS5tRExndmVyc2lvbmMxLjBsdmFsaWRpdHlJbmZvo2ZzaWduZWTAdDIwMjUtMDEtMDFUMDA6MDA6MDBaaXZhbGlkRnJvbcB0MjAyNS0wMS0wMVQwMDowMDowMFpqdmFsaWRVbnRpbMB0MjAzMC0wMS0wMVQwMDowMDowMFpsdmFsdWVEaWdlc3RzoXFvcmcuaXNvLjE4MDEzLjUuMaIAWCDZM4RSfslHE-ITSr-eDBvwmSHGJEEKY6tFwNGvqm8uewFYIGk2NYW598dnCoGzAlg4gAnrZa6QPDnqjP1Qq5TgamCKbWRldmljZUtleUluZm-haWRldmljZUtleaUBAgMmIAEhWCAOAUTOv0pYLeUeUFre1xp6RRLM04WERe_pJgvmyfOhrCJYIN9IMmTzu2PTknLaJXy7KOcEn00je46HP_jCh61HnEsvb2RpZ2VzdEFsZ29yaXRobWdTSEEtMjU2WEDsU3q3wtXD3iHstPbPtagGkqfgCd9fvXP2J4k3c8281m7iaP7BFdCb_BDqwmuSBO212sUkzWat77AtBeYOLhFGam5hbWVTcGFjZXOhcW9yZy5pc28uMTgwMTMuNS4xgtgYWGqkZnJhbmRvbVggu6j_CRh3SBWhWX-L2Ps4uXZcRdCbKzGH8WTNrVGm6RJoZGlnZXN0SUQBbGVsZW1lbnRWYWx1ZWpNdXN0ZXJtYW5ucWVsZW1lbnRJZGVudGlmaWVya2ZhbWlseV9uYW1l2BhYZKRmcmFuZG9tWCC8ItEM5p_c6rHbUYOUrVQgUlAIV2T66BUPHxedfG6aM2hkaWdlc3RJRABsZWxlbWVudFZhbHVlZUVyaWthcWVsZW1lbnRJZGVudGlmaWVyamdpdmVuX25hbWU`
@@ -0,0 +1,197 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/jwtkeys"
+)
+
+var (
+	ErrJwtSignatureInvalid = errors.New("jwt signature invalid")
+	ErrIssuerMismatch      = errors.New("iss does not match the issuer of the credential")
+	ErrPresentationBinding = errors.New("presentation is not bound to the request")
+)
+
+// KeyResolver returns the keys which may have signed the jwt of an issuer or holder, see jwtkeys.
+type KeyResolver = jwtkeys.Resolver
+
+/*
+JwtVerifier checks W3C VCs and VPs secured as JWT: the signature with the keys of Keys, exp and nbf, the validity
+window of the credential and that iss names the issuer of the embedded credential.
+*/
+type JwtVerifier struct {
+	Keys KeyResolver
+	// Leeway for clock differences, defaults to config.DefaultLeeway
+	Leeway time.Duration
+}
+
+/*
+NewJwtVerifier creates a verifier, nil keys resolve the keys with jwtkeys.NewResolver: x5c headers against the
+system roots, DIDs with the DID resolver and other issuers by the jwks or jwks_uri of their issuer metadata.
+*/
+func NewJwtVerifier(keys KeyResolver) *JwtVerifier {
+	if keys == nil {
+		keys = jwtkeys.NewResolver(helper.NewHttpClient(), nil)
+	}
+	return &JwtVerifier{Keys: keys}
+}
+
+// VerifyCredential checks a JWT VC of VCDM 1.1 or 2.0 and returns the verified credential.
+func (verifier *JwtVerifier) VerifyCredential(token string) (*Credential, error) {
+	header, payload, err := decodeJwt(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	vc, err := ParseJwtCredential(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// VCDM 1.1 fills the issuer from iss, so the binding is checked against the issuer of the payload
+	if iss, ok := payload["iss"].(string); ok {
+		if issuer := embeddedIssuer(payload); issuer != "" && issuer != iss {
+			return nil, fmt.Errorf("%w: %s and %s", ErrIssuerMismatch, iss, issuer)
+		}
+	}
+
+	if err := verifier.verifySignature(token, vc.Issuer, header); err != nil {
+		return nil, err
+	}
+	if err := verifier.verifyTimes(payload); err != nil {
+		return nil, err
+	}
+	if err := vc.CheckValidity(time.Now(), verifier.leeway()); err != nil {
+		return nil, err
+	}
+
+	credential := Credential{
+		Format:     JWTVC,
+		Json:       payload,
+		Issuer:     vc.Issuer,
+		ValidFrom:  vc.ValidFrom,
+		ValidUntil: vc.ValidUntil,
+	}
+	if vc.Version == DataModelV2 {
+		credential.Format = JWTVCJSON
+	}
+	if id, ok := vc.CredentialSubject[0]["id"].(string); ok {
+		credential.Subject = id
+	}
+	return &credential, nil
+}
+
+/*
+VerifyPresentation checks a JWT VP signed by the holder for audience and nonce and all embedded JWT VCs. It returns
+the parsed presentation and the verified credentials.
+*/
+func (verifier *JwtVerifier) VerifyPresentation(token string, audience string, nonce string) (*VerifiablePresentation, []*Credential, error) {
+	header, payload, err := decodeJwt(token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidPresentation, err)
+	}
+
+	vp, err := ParseJwtPresentation(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vp.Holder == "" {
+		return nil, nil, fmt.Errorf("%w: holder missing", ErrInvalidPresentation)
+	}
+
+	if err := verifier.verifySignature(token, vp.Holder, header); err != nil {
+		return nil, nil, err
+	}
+	if err := verifier.verifyTimes(payload); err != nil {
+		return nil, nil, err
+	}
+
+	if !slices.Contains(stringValues(payload["aud"]), audience) {
+		return nil, nil, fmt.Errorf("%w: aud does not contain %s", ErrPresentationBinding, audience)
+	}
+	if payload["nonce"] != nonce {
+		return nil, nil, fmt.Errorf("%w: nonce does not match", ErrPresentationBinding)
+	}
+
+	credentials := make([]*Credential, 0, len(vp.VerifiableCredential))
+	for _, embedded := range vp.VerifiableCredential {
+		s, ok := embedded.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: only jwt credentials are supported", ErrInvalidPresentation)
+		}
+		credential, err := verifier.VerifyCredential(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return vp, credentials, nil
+}
+
+func (verifier *JwtVerifier) verifySignature(token string, issuer string, header map[string]interface{}) error {
+	alg, _ := header["alg"].(string)
+	switch jwa.SignatureAlgorithm(alg) {
+	case "", jwa.NoSignature, jwa.HS256, jwa.HS384, jwa.HS512:
+		return fmt.Errorf("%w: alg %s not allowed", ErrJwtSignatureInvalid, alg)
+	}
+
+	if verifier.Keys == nil {
+		return fmt.Errorf("%w: no key resolver", ErrJwtSignatureInvalid)
+	}
+	keys, err := verifier.Keys(issuer, header)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrJwtSignatureInvalid, err)
+	}
+
+	for _, key := range keys {
+		if _, err := jws.Verify([]byte(token), jws.WithKey(jwa.SignatureAlgorithm(alg), key)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no key of %s matches", ErrJwtSignatureInvalid, issuer)
+}
+
+func (verifier *JwtVerifier) verifyTimes(payload map[string]interface{}) error {
+	leeway := verifier.leeway()
+	now := time.Now()
+
+	if exp, ok := payload["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return ErrCredentialExpired
+	}
+	if nbf, ok := payload["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrCredentialNotValid
+	}
+	return nil
+}
+
+func (verifier *JwtVerifier) leeway() time.Duration {
+	if verifier.Leeway > 0 {
+		return verifier.Leeway
+	}
+	return config.DefaultLeeway
+}
+
+// embeddedIssuer returns the issuer of the vc claim (VCDM 1.1) or of the payload (VCDM 2.0).
+func embeddedIssuer(payload map[string]interface{}) string {
+	document := payload
+	if vc, ok := payload["vc"].(map[string]interface{}); ok {
+		document = vc
+	}
+
+	switch issuer := document["issuer"].(type) {
+	case string:
+		return issuer
+	case map[string]interface{}:
+		id, _ := issuer["id"].(string)
+		return id
+	}
+	return ""
+}
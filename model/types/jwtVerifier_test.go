@@ -0,0 +1,228 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/cert"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/jwtkeys"
+)
+
+func testDidJwk(t *testing.T, key jwk.Key) string {
+	public, err := key.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "did:jwk:" + base64.RawURLEncoding.EncodeToString(b)
+}
+
+func TestVerifyJwtCredential(t *testing.T) {
+	key := testJwtKey(t)
+	issuer := testDidJwk(t, key)
+
+	document := testCredentialV2()
+	document["issuer"] = issuer
+	token := signJwtWith(t, key, issuer+"#0", MediaTypeVcJwt, document)
+
+	credential, err := NewJwtVerifier(nil).VerifyCredential(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.Format != JWTVCJSON || credential.Issuer != issuer || credential.Subject != "did:example:subject" {
+		t.Error(credential)
+	}
+	if credential.ValidFrom == nil || !credential.ValidUntil.Equal(time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error(credential.ValidFrom, credential.ValidUntil)
+	}
+
+	other := signJwtWith(t, testJwtKey(t), issuer+"#0", MediaTypeVcJwt, document)
+	if _, err := NewJwtVerifier(nil).VerifyCredential(other); !errors.Is(err, ErrJwtSignatureInvalid) {
+		t.Error("signature of another key accepted", err)
+	}
+
+	// the kid names the key of the signer, which is not the issuer
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	attacker := testJwtKey(t)
+	for _, iss := range []string{srv.URL, "did:web:trusted-government.example"} {
+		document["issuer"] = iss
+		forged := signJwtWith(t, attacker, testDidJwk(t, attacker)+"#0", MediaTypeVcJwt, document)
+		if _, err := NewJwtVerifier(jwtkeys.NewResolver(srv.Client(), nil)).VerifyCredential(forged); !errors.Is(err, ErrJwtSignatureInvalid) {
+			t.Error("kid of another did accepted for", iss, err)
+		}
+	}
+}
+
+func TestVerifyJwtCredentialMetadataKeys(t *testing.T) {
+	key := testJwtKey(t)
+	key.Set(jwk.KeyIDKey, "key-1")
+	public, _ := key.PublicKey()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case jwtkeys.IssuerMetadataPath:
+			json.NewEncoder(w).Encode(jwtkeys.IssuerMetadata{Issuer: srv.URL, JwksUri: srv.URL + "/jwks"})
+		case "/jwks":
+			set := jwk.NewSet()
+			set.AddKey(public)
+			json.NewEncoder(w).Encode(set)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	document := testCredentialV2()
+	document["issuer"] = srv.URL
+	verifier := NewJwtVerifier(jwtkeys.NewResolver(srv.Client(), nil))
+
+	credential, err := verifier.VerifyCredential(signJwtWith(t, key, "key-1", MediaTypeVcJwt, document))
+	if err != nil || credential.Issuer != srv.URL {
+		t.Fatal(credential, err)
+	}
+
+	if _, err := verifier.VerifyCredential(signJwtWith(t, testJwtKey(t), "key-1", MediaTypeVcJwt, document)); !errors.Is(err, ErrJwtSignatureInvalid) {
+		t.Error("key outside of the jwks accepted", err)
+	}
+}
+
+func TestVerifyJwtCredentialX5c(t *testing.T) {
+	key := testJwtKey(t)
+	var raw ecdsa.PrivateKey
+	key.Raw(&raw)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		URIs:                  []*url.URL{{Scheme: "https", Host: "issuer.example.com"}},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &raw.PublicKey, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+
+	document := testCredentialV2()
+	document["issuer"] = "https://issuer.example.com"
+	b, _ := json.Marshal(document)
+
+	var chain cert.Chain
+	chain.AddString(base64.StdEncoding.EncodeToString(der))
+	headers := jws.NewHeaders()
+	headers.Set(jws.TypeKey, MediaTypeVcJwt)
+	headers.Set(jws.X509CertChainKey, &chain)
+	token, err := jws.Sign(b, jws.WithKey(jwa.ES256, key, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := NewJwtVerifier(jwtkeys.NewResolver(http.DefaultClient, roots)).VerifyCredential(string(token))
+	if err != nil || credential.Issuer != "https://issuer.example.com" {
+		t.Fatal(credential, err)
+	}
+
+	if _, err := NewJwtVerifier(jwtkeys.NewResolver(http.DefaultClient, x509.NewCertPool())).VerifyCredential(string(token)); !errors.Is(err, ErrJwtSignatureInvalid) {
+		t.Error("untrusted certificate accepted", err)
+	}
+}
+
+func TestVerifyJwtCredentialClaims(t *testing.T) {
+	key := testJwtKey(t)
+	public, _ := key.PublicKey()
+	verifier := NewJwtVerifier(func(string, map[string]interface{}) ([]jwk.Key, error) {
+		return []jwk.Key{public}, nil
+	})
+
+	vc := map[string]interface{}{
+		"@context":          []interface{}{ContextV1},
+		"type":              []interface{}{VerifiableCredentialType},
+		"issuer":            "did:example:other",
+		"credentialSubject": map[string]interface{}{"degree": "Bachelor of Science"},
+	}
+	mismatch := signJwtWith(t, key, "", "JWT", map[string]interface{}{"iss": "did:example:issuer", "nbf": 1704067200, "vc": vc})
+	if _, err := verifier.VerifyCredential(mismatch); !errors.Is(err, ErrIssuerMismatch) {
+		t.Error(err)
+	}
+
+	delete(vc, "issuer")
+	expired := signJwtWith(t, key, "", "JWT", map[string]interface{}{
+		"iss": "did:example:issuer",
+		"nbf": 1704067200,
+		"exp": time.Now().Add(-time.Hour).Unix(),
+		"vc":  vc,
+	})
+	if _, err := verifier.VerifyCredential(expired); !errors.Is(err, ErrCredentialExpired) {
+		t.Error(err)
+	}
+
+	valid := signJwtWith(t, key, "", "JWT", map[string]interface{}{"iss": "did:example:issuer", "sub": "did:example:subject", "nbf": 1704067200, "vc": vc})
+	credential, err := verifier.VerifyCredential(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.Format != JWTVC || credential.Issuer != "did:example:issuer" || credential.Subject != "did:example:subject" {
+		t.Error(credential)
+	}
+}
+
+func TestVerifyJwtPresentation(t *testing.T) {
+	issuerKey, holderKey := testJwtKey(t), testJwtKey(t)
+	issuer, holder := testDidJwk(t, issuerKey), testDidJwk(t, holderKey)
+
+	document := testCredentialV2()
+	document["issuer"] = issuer
+	credential := signJwtWith(t, issuerKey, issuer+"#0", MediaTypeVcJwt, document)
+
+	presentation := func(nonce string) string {
+		return signJwtWith(t, holderKey, holder+"#0", "JWT", map[string]interface{}{
+			"iss":   holder,
+			"aud":   "https://verifier.example.org",
+			"nonce": nonce,
+			"vp": map[string]interface{}{
+				"@context":             []interface{}{ContextV1},
+				"type":                 []interface{}{VerifiablePresentationType},
+				"verifiableCredential": []interface{}{credential},
+			},
+		})
+	}
+
+	verifier := NewJwtVerifier(nil)
+	vp, credentials, err := verifier.VerifyPresentation(presentation("n-0S6_WzA2Mj"), "https://verifier.example.org", "n-0S6_WzA2Mj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vp.Holder != holder || len(credentials) != 1 || credentials[0].Issuer != issuer {
+		t.Error(vp, credentials)
+	}
+
+	if _, _, err := verifier.VerifyPresentation(presentation("replayed"), "https://verifier.example.org", "n-0S6_WzA2Mj"); !errors.Is(err, ErrPresentationBinding) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,355 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"golang.org/x/exp/slices"
+)
+
+// https://www.w3.org/TR/vc-data-model/ and https://www.w3.org/TR/vc-data-model-2.0/
+const (
+	ContextV1 = "https://www.w3.org/2018/credentials/v1"
+	ContextV2 = "https://www.w3.org/ns/credentials/v2"
+
+	VerifiableCredentialType          = "VerifiableCredential"
+	VerifiablePresentationType        = "VerifiablePresentation"
+	EnvelopedVerifiableCredentialType = "EnvelopedVerifiableCredential"
+
+	// MediaTypeVcJwt and MediaTypeVpJwt are the typ headers of VCDM 2.0 credentials and presentations secured with JOSE.
+	MediaTypeVcJwt = "vc+jwt"
+	MediaTypeVpJwt = "vp+jwt"
+)
+
+type DataModelVersion string
+
+const (
+	DataModelV1 DataModelVersion = "1.1"
+	DataModelV2 DataModelVersion = "2.0"
+)
+
+var (
+	ErrInvalidCredential   = errors.New("invalid verifiable credential")
+	ErrInvalidPresentation = errors.New("invalid verifiable presentation")
+	ErrCredentialExpired   = errors.New("credential expired")
+	ErrCredentialNotValid  = errors.New("credential not yet valid")
+)
+
+/*
+VerifiableCredential is the parsed form of a VCDM 1.1 or 2.0 credential. The validity window is taken from
+issuanceDate/expirationDate for 1.1 and validFrom/validUntil for 2.0, Json keeps the complete credential.
+*/
+type VerifiableCredential struct {
+	Version           DataModelVersion
+	Id                string
+	Type              []string
+	Issuer            string
+	ValidFrom         *time.Time
+	ValidUntil        *time.Time
+	CredentialSubject []map[string]interface{}
+	Json              map[string]interface{}
+}
+
+// VerifiablePresentation is the parsed form of a VCDM 1.1 or 2.0 presentation.
+type VerifiablePresentation struct {
+	Version DataModelVersion
+	Id      string
+	Type    []string
+	Holder  string
+	// VerifiableCredential contains the embedded credentials, enveloped credentials are unwrapped to their token.
+	VerifiableCredential []interface{}
+	Json                 map[string]interface{}
+}
+
+// ParseVerifiableCredential checks @context, type, issuer, validity dates and credentialSubject of a credential.
+func ParseVerifiableCredential(document map[string]interface{}) (*VerifiableCredential, error) {
+	version, err := dataModelVersion(document)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	credential := VerifiableCredential{
+		Version: version,
+		Type:    stringValues(document["type"]),
+		Json:    document,
+	}
+	credential.Id, _ = document["id"].(string)
+
+	if !slices.Contains(credential.Type, VerifiableCredentialType) {
+		return nil, fmt.Errorf("%w: type %s missing", ErrInvalidCredential, VerifiableCredentialType)
+	}
+
+	switch issuer := document["issuer"].(type) {
+	case string:
+		credential.Issuer = issuer
+	case map[string]interface{}:
+		credential.Issuer, _ = issuer["id"].(string)
+	}
+	if credential.Issuer == "" {
+		return nil, fmt.Errorf("%w: issuer missing", ErrInvalidCredential)
+	}
+
+	from, until := "validFrom", "validUntil"
+	if version == DataModelV1 {
+		from, until = "issuanceDate", "expirationDate"
+		if _, ok := document[from]; !ok {
+			return nil, fmt.Errorf("%w: issuanceDate missing", ErrInvalidCredential)
+		}
+	}
+	if credential.ValidFrom, err = dateTime(document, from); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+	if credential.ValidUntil, err = dateTime(document, until); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+	if credential.ValidFrom != nil && credential.ValidUntil != nil && credential.ValidUntil.Before(*credential.ValidFrom) {
+		return nil, fmt.Errorf("%w: %s before %s", ErrInvalidCredential, until, from)
+	}
+
+	switch subject := document["credentialSubject"].(type) {
+	case map[string]interface{}:
+		credential.CredentialSubject = []map[string]interface{}{subject}
+	case []interface{}:
+		for _, s := range subject {
+			object, ok := s.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: credentialSubject is no object", ErrInvalidCredential)
+			}
+			credential.CredentialSubject = append(credential.CredentialSubject, object)
+		}
+	}
+	if len(credential.CredentialSubject) == 0 {
+		return nil, fmt.Errorf("%w: credentialSubject missing", ErrInvalidCredential)
+	}
+
+	return &credential, nil
+}
+
+// ParseVerifiablePresentation checks @context and type of a presentation and collects the embedded credentials.
+func ParseVerifiablePresentation(document map[string]interface{}) (*VerifiablePresentation, error) {
+	version, err := dataModelVersion(document)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPresentation, err)
+	}
+
+	presentation := VerifiablePresentation{
+		Version: version,
+		Type:    stringValues(document["type"]),
+		Json:    document,
+	}
+	presentation.Id, _ = document["id"].(string)
+
+	if !slices.Contains(presentation.Type, VerifiablePresentationType) {
+		return nil, fmt.Errorf("%w: type %s missing", ErrInvalidPresentation, VerifiablePresentationType)
+	}
+
+	switch holder := document["holder"].(type) {
+	case string:
+		presentation.Holder = holder
+	case map[string]interface{}:
+		presentation.Holder, _ = holder["id"].(string)
+	}
+
+	credentials, ok := document["verifiableCredential"].([]interface{})
+	if !ok && document["verifiableCredential"] != nil {
+		credentials = []interface{}{document["verifiableCredential"]}
+	}
+	for _, c := range credentials {
+		presentation.VerifiableCredential = append(presentation.VerifiableCredential, unwrapEnveloped(c))
+	}
+	return &presentation, nil
+}
+
+/*
+ParseJwtCredential decodes a JWT VC without verifying the signature. VCDM 2.0 credentials (typ vc+jwt) are the
+payload itself, VCDM 1.1 credentials are taken from the vc claim and completed by iss, nbf, exp, jti and sub.
+*/
+func ParseJwtCredential(token string) (*VerifiableCredential, error) {
+	header, payload, err := decodeJwt(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if header["typ"] == MediaTypeVcJwt || payload["vc"] == nil {
+		return ParseVerifiableCredential(payload)
+	}
+
+	vc, ok := payload["vc"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: vc claim is no object", ErrInvalidCredential)
+	}
+	vc = copyMap(vc)
+
+	if iss, ok := payload["iss"].(string); ok {
+		if issuer, isObject := vc["issuer"].(map[string]interface{}); isObject {
+			issuer = copyMap(issuer)
+			issuer["id"] = iss
+			vc["issuer"] = issuer
+		} else {
+			vc["issuer"] = iss
+		}
+	}
+	if nbf, ok := payload["nbf"].(float64); ok {
+		vc["issuanceDate"] = time.Unix(int64(nbf), 0).UTC().Format(time.RFC3339)
+	}
+	if exp, ok := payload["exp"].(float64); ok {
+		vc["expirationDate"] = time.Unix(int64(exp), 0).UTC().Format(time.RFC3339)
+	}
+	if jti, ok := payload["jti"].(string); ok {
+		vc["id"] = jti
+	}
+	if sub, ok := payload["sub"].(string); ok {
+		if subject, isObject := vc["credentialSubject"].(map[string]interface{}); isObject && subject["id"] == nil {
+			subject = copyMap(subject)
+			subject["id"] = sub
+			vc["credentialSubject"] = subject
+		}
+	}
+	return ParseVerifiableCredential(vc)
+}
+
+// ParseJwtPresentation decodes a JWT VP without verifying the signature, VCDM 1.1 presentations use the vp claim.
+func ParseJwtPresentation(token string) (*VerifiablePresentation, error) {
+	header, payload, err := decodeJwt(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPresentation, err)
+	}
+
+	if header["typ"] == MediaTypeVpJwt || payload["vp"] == nil {
+		return ParseVerifiablePresentation(payload)
+	}
+
+	vp, ok := payload["vp"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: vp claim is no object", ErrInvalidPresentation)
+	}
+	vp = copyMap(vp)
+
+	if iss, ok := payload["iss"].(string); ok && vp["holder"] == nil {
+		vp["holder"] = iss
+	}
+	if jti, ok := payload["jti"].(string); ok {
+		vp["id"] = jti
+	}
+	return ParseVerifiablePresentation(vp)
+}
+
+// CheckValidity checks the validity window of the credential at now, leeway allows clock differences.
+func (credential *VerifiableCredential) CheckValidity(now time.Time, leeway time.Duration) error {
+	if credential.ValidUntil != nil && now.After(credential.ValidUntil.Add(leeway)) {
+		return ErrCredentialExpired
+	}
+	if credential.ValidFrom != nil && now.Add(leeway).Before(*credential.ValidFrom) {
+		return ErrCredentialNotValid
+	}
+	return nil
+}
+
+// dataModelVersion derives the version from the first @context, which must be the base context of the data model.
+func dataModelVersion(document map[string]interface{}) (DataModelVersion, error) {
+	contexts, ok := document["@context"].([]interface{})
+	if !ok || len(contexts) == 0 {
+		if context, isString := document["@context"].(string); isString {
+			contexts = []interface{}{context}
+		}
+	}
+	if len(contexts) == 0 {
+		return "", errors.New("@context missing")
+	}
+
+	switch contexts[0] {
+	case ContextV1:
+		return DataModelV1, nil
+	case ContextV2:
+		return DataModelV2, nil
+	}
+	return "", fmt.Errorf("first @context must be %s or %s", ContextV2, ContextV1)
+}
+
+func dateTime(document map[string]interface{}, name string) (*time.Time, error) {
+	value, ok := document[name]
+	if !ok {
+		return nil, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s is no string", name)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		// VCDM 1.1 credentials in the wild omit the time zone
+		t, err = time.Parse("2006-01-02T15:04:05", s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is no date time: %s", name, s)
+	}
+	return &t, nil
+}
+
+// unwrapEnveloped returns the token of an EnvelopedVerifiableCredential, e.g. data:application/vc+jwt,eyJ...
+func unwrapEnveloped(credential interface{}) interface{} {
+	object, ok := credential.(map[string]interface{})
+	if !ok || !slices.Contains(stringValues(object["type"]), EnvelopedVerifiableCredentialType) {
+		return credential
+	}
+
+	id, _ := object["id"].(string)
+	if _, token, found := strings.Cut(id, ","); found && strings.HasPrefix(id, "data:") {
+		return token
+	}
+	return credential
+}
+
+func decodeJwt(token string) (map[string]interface{}, map[string]interface{}, error) {
+	message, err := jws.ParseString(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(message.Signatures()) == 0 {
+		return nil, nil, errors.New("jwt without signature")
+	}
+
+	b, err := json.Marshal(message.Signatures()[0].ProtectedHeaders())
+	if err != nil {
+		return nil, nil, err
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, nil, err
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(message.Payload(), &payload); err != nil {
+		return nil, nil, fmt.Errorf("jwt payload is no json object: %w", err)
+	}
+	return header, payload, nil
+}
+
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
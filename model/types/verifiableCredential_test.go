@@ -0,0 +1,162 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+func testJwtKey(t *testing.T) jwk.Key {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signJwt(t *testing.T, typ string, payload map[string]interface{}) string {
+	return signJwtWith(t, testJwtKey(t), "", typ, payload)
+}
+
+func signJwtWith(t *testing.T, key jwk.Key, kid string, typ string, payload map[string]interface{}) string {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := jws.NewHeaders()
+	_ = headers.Set(jws.TypeKey, typ)
+	if kid != "" {
+		_ = headers.Set(jws.KeyIDKey, kid)
+	}
+	token, err := jws.Sign(b, jws.WithKey(jwa.ES256, key, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(token)
+}
+
+func testCredentialV2() map[string]interface{} {
+	return map[string]interface{}{
+		"@context":          []interface{}{ContextV2, "https://www.w3.org/ns/credentials/examples/v2"},
+		"id":                "urn:uuid:58172aac-d8ba-11ed-83dd-0b3aef56cc33",
+		"type":              []interface{}{VerifiableCredentialType, "ExampleDegreeCredential"},
+		"issuer":            map[string]interface{}{"id": "did:example:issuer", "name": "Example University"},
+		"validFrom":         "2024-01-01T00:00:00Z",
+		"validUntil":        "2034-01-01T00:00:00Z",
+		"credentialSubject": map[string]interface{}{"id": "did:example:subject", "degree": "Bachelor of Science"},
+	}
+}
+
+func TestVcdm2Credential(t *testing.T) {
+	token := signJwt(t, MediaTypeVcJwt, testCredentialV2())
+
+	credential, err := CheckFormat(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.Format != JWTVCJSON || credential.Json["credentialSubject"] == nil {
+		t.Error("vc+jwt should carry the credential in the payload", credential.Format)
+	}
+
+	vc, err := ParseJwtCredential(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.Version != DataModelV2 || vc.Issuer != "did:example:issuer" || vc.ValidFrom == nil || vc.ValidUntil == nil {
+		t.Error(vc)
+	}
+	if err := vc.CheckValidity(time.Now(), time.Minute); err != nil {
+		t.Error(err)
+	}
+	if err := vc.CheckValidity(vc.ValidUntil.Add(time.Hour), time.Minute); !errors.Is(err, ErrCredentialExpired) {
+		t.Error(err)
+	}
+	if err := vc.CheckValidity(vc.ValidFrom.Add(-time.Hour), time.Minute); !errors.Is(err, ErrCredentialNotValid) {
+		t.Error(err)
+	}
+}
+
+func TestVcdm2CredentialInvalid(t *testing.T) {
+	tests := map[string]func(map[string]interface{}){
+		"context":  func(c map[string]interface{}) { c["@context"] = []interface{}{"https://example.org/context"} },
+		"type":     func(c map[string]interface{}) { c["type"] = []interface{}{"ExampleDegreeCredential"} },
+		"issuer":   func(c map[string]interface{}) { delete(c, "issuer") },
+		"subject":  func(c map[string]interface{}) { delete(c, "credentialSubject") },
+		"date":     func(c map[string]interface{}) { c["validFrom"] = "yesterday" },
+		"validity": func(c map[string]interface{}) { c["validUntil"] = "2023-01-01T00:00:00Z" },
+	}
+
+	for name, modify := range tests {
+		credential := testCredentialV2()
+		modify(credential)
+		if _, err := CheckFormat(signJwt(t, MediaTypeVcJwt, credential)); !errors.Is(err, ErrInvalidCredential) {
+			t.Error(name, err)
+		}
+	}
+}
+
+func TestVcdm1JwtCredential(t *testing.T) {
+	token := signJwt(t, "JWT", map[string]interface{}{
+		"iss": "did:example:issuer",
+		"sub": "did:example:subject",
+		"nbf": 1704067200,
+		"exp": 2019600000,
+		"jti": "http://example.edu/credentials/3732",
+		"vc": map[string]interface{}{
+			"@context":          []interface{}{ContextV1},
+			"type":              []interface{}{VerifiableCredentialType, "UniversityDegreeCredential"},
+			"credentialSubject": map[string]interface{}{"degree": "Bachelor of Science"},
+		},
+	})
+
+	credential, err := CheckFormat(token)
+	if err != nil || credential.Format != JWTVC {
+		t.Fatal(err)
+	}
+
+	vc, err := ParseJwtCredential(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.Version != DataModelV1 || vc.Issuer != "did:example:issuer" || vc.Id != "http://example.edu/credentials/3732" {
+		t.Error(vc)
+	}
+	if vc.CredentialSubject[0]["id"] != "did:example:subject" || !vc.ValidFrom.Equal(time.Unix(1704067200, 0)) {
+		t.Error(vc.CredentialSubject, vc.ValidFrom)
+	}
+}
+
+func TestVcdm2Presentation(t *testing.T) {
+	credential := signJwt(t, MediaTypeVcJwt, testCredentialV2())
+	token := signJwt(t, MediaTypeVpJwt, map[string]interface{}{
+		"@context": []interface{}{ContextV2},
+		"type":     VerifiablePresentationType,
+		"holder":   "did:example:subject",
+		"verifiableCredential": []interface{}{map[string]interface{}{
+			"@context": ContextV2,
+			"type":     EnvelopedVerifiableCredentialType,
+			"id":       "data:application/vc+jwt," + credential,
+		}},
+	})
+
+	vp, err := ParseJwtPresentation(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vp.Holder != "did:example:subject" || len(vp.VerifiableCredential) != 1 || vp.VerifiableCredential[0] != credential {
+		t.Error(vp)
+	}
+}
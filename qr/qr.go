@@ -0,0 +1,152 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/presentation"
+	"github.com/sirupsen/logrus"
+	"github.com/skip2/go-qrcode"
+)
+
+type ErrorCorrection int
+
+// Error correction levels, the higher the level the more damage can be recovered but the less data fits.
+const (
+	Low ErrorCorrection = iota
+	Medium
+	High
+	Highest
+)
+
+// DefaultSize is the default edge length of PNG images in pixels
+var DefaultSize = 256
+
+// DenseVersion is the QR version above which codes get hard to scan from screens. Larger payloads are logged.
+var DenseVersion = 15
+
+var ErrPayloadTooLarge = errors.New("payload exceeds the capacity of a QR code, pass the offer or request by reference (credential_offer_uri or request_uri) instead")
+
+/*
+Code is a QR code of a credential offer or authorization request link.
+*/
+type Code struct {
+	Content string
+	code    *qrcode.QRCode
+}
+
+// capacity is the number of bytes a version 40 code holds in byte mode at the level.
+func (level ErrorCorrection) capacity() int {
+	switch level {
+	case Low:
+		return 2953
+	case Medium:
+		return 2331
+	case High:
+		return 1663
+	}
+	return 1273
+}
+
+func (level ErrorCorrection) recoveryLevel() (qrcode.RecoveryLevel, error) {
+	switch level {
+	case Low:
+		return qrcode.Low, nil
+	case Medium:
+		return qrcode.Medium, nil
+	case High:
+		return qrcode.High, nil
+	case Highest:
+		return qrcode.Highest, nil
+	}
+	return 0, fmt.Errorf("unknown error correction level %d", level)
+}
+
+// New encodes content with the given error correction level.
+func New(content string, level ErrorCorrection) (*Code, error) {
+	recovery, err := level.recoveryLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > level.capacity() {
+		return nil, fmt.Errorf("%w (%d bytes)", ErrPayloadTooLarge, len(content))
+	}
+
+	code, err := qrcode.New(content, recovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if code.VersionNumber > DenseVersion {
+		logrus.Warnf("QR code version %d for %d bytes is hard to scan, consider passing the payload by reference", code.VersionNumber, len(content))
+	}
+
+	return &Code{Content: content, code: code}, nil
+}
+
+// FromCredentialOffer encodes the credential_offer_uri link if present, otherwise the credential_offer link.
+func FromCredentialOffer(offer credential.CredentialOffer, level ErrorCorrection) (*Code, error) {
+	if offer.CredentialOfferUri != "" {
+		return New(offer.CredentialOfferUri, level)
+	}
+	if offer.CredentialOffer != "" {
+		return New(offer.CredentialOffer, level)
+	}
+	return nil, errors.New("credential offer contains no link")
+}
+
+/*
+FromAuthorizationRequest encodes an openid4vp:// link of the request. If requestUri is set, the request is passed by
+reference, otherwise all parameters are passed by value.
+*/
+func FromAuthorizationRequest(request presentation.RequestObject, requestUri string, level ErrorCorrection) (*Code, error) {
+	if requestUri != "" {
+		return New(request.CreateRequestUriLink(requestUri), level)
+	}
+
+	link, err := request.CreateRequestLink()
+	if err != nil {
+		return nil, err
+	}
+	return New(link, level)
+}
+
+// Version returns the QR version (1-40) chosen for the content.
+func (code *Code) Version() int {
+	return code.code.VersionNumber
+}
+
+// PNG renders the code as PNG image with the given edge length in pixels, size <= 0 uses DefaultSize.
+func (code *Code) PNG(size int) ([]byte, error) {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return code.code.PNG(size)
+}
+
+// SVG renders the code as scalable SVG image. Every module is one unit of the view box.
+func (code *Code) SVG() []byte {
+	bitmap := code.code.Bitmap()
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bitmap), len(bitmap))
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="#ffffff"/><path fill="#000000" d="%s"/></svg>`, path.String())
+	return []byte(svg.String())
+}
@@ -0,0 +1,83 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/presentation"
+)
+
+func TestCredentialOfferPNG(t *testing.T) {
+	offer, err := credential.CreateOfferUriLink("https://issuer.example.com/offers/123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := FromCredentialOffer(*offer, Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code.Content != offer.CredentialOfferUri {
+		t.Error("offer uri link should be encoded")
+	}
+
+	b, err := code.PNG(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.Bounds().Dx() != DefaultSize {
+		t.Errorf("unexpected image size %d", img.Bounds().Dx())
+	}
+}
+
+func TestAuthorizationRequestSVG(t *testing.T) {
+	request := presentation.RequestObject{ClientID: "https://verifier.example.com/response"}
+
+	code, err := FromAuthorizationRequest(request, "https://verifier.example.com/request/1", High)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(code.Content, "openid4vp://?client_id=") {
+		t.Error("unexpected content " + code.Content)
+	}
+
+	svg := string(code.SVG())
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, "<path") {
+		t.Error("invalid svg")
+	}
+}
+
+func TestPayloadTooLarge(t *testing.T) {
+	_, err := New(strings.Repeat("a", 5000), Highest)
+	if !errors.Is(err, ErrPayloadTooLarge) {
+		t.Error("payload should be too large")
+	}
+
+	for _, level := range []ErrorCorrection{Low, Medium, High, Highest} {
+		if _, err := New(strings.Repeat("a", level.capacity()), level); err != nil {
+			t.Error(level, err)
+		}
+		if _, err := New(strings.Repeat("a", level.capacity()+1), level); !errors.Is(err, ErrPayloadTooLarge) {
+			t.Error(level, err)
+		}
+	}
+}
+
+func TestUnknownLevel(t *testing.T) {
+	_, err := New("test", ErrorCorrection(9))
+	if err == nil {
+		t.Error("unknown level should fail")
+	}
+}
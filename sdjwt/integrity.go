@@ -0,0 +1,80 @@
+package sdjwt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+var ErrIntegrity = errors.New("integrity check failed")
+
+var integrityAlgorithms = map[string]struct {
+	strength int
+	hash     func() hash.Hash
+}{
+	"sha256": {1, sha256.New},
+	"sha384": {2, sha512.New384},
+	"sha512": {3, sha512.New},
+}
+
+/*
+VerifyIntegrity checks data against a W3C Subresource Integrity value like "sha256-<base64>" as used by the
+#integrity claims of SD-JWT VC type metadata. Of multiple hashes only the strongest algorithm is evaluated, one
+matching hash of it is sufficient.
+*/
+func VerifyIntegrity(data []byte, integrity string) error {
+	type candidate struct {
+		expected []byte
+		actual   []byte
+	}
+
+	strongest := 0
+	candidates := make(map[int][]candidate)
+
+	for _, entry := range strings.Fields(integrity) {
+		// options after ? are reserved by SRI and ignored
+		entry, _, _ = strings.Cut(entry, "?")
+		name, value, ok := strings.Cut(entry, "-")
+		algorithm, known := integrityAlgorithms[name]
+		if !ok || !known {
+			continue
+		}
+
+		digest, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			digest, err = base64.RawURLEncoding.DecodeString(value)
+		}
+		if err != nil {
+			continue
+		}
+
+		h := algorithm.hash()
+		h.Write(data)
+		candidates[algorithm.strength] = append(candidates[algorithm.strength], candidate{expected: digest, actual: h.Sum(nil)})
+		if algorithm.strength > strongest {
+			strongest = algorithm.strength
+		}
+	}
+
+	if strongest == 0 {
+		return fmt.Errorf("%w: no supported hash in %q", ErrIntegrity, integrity)
+	}
+
+	for _, c := range candidates[strongest] {
+		if bytes.Equal(c.expected, c.actual) {
+			return nil
+		}
+	}
+	return ErrIntegrity
+}
+
+// Integrity returns the sha256 Subresource Integrity value of data.
+func Integrity(data []byte) string {
+	digest := sha256.Sum256(data)
+	return "sha256-" + base64.StdEncoding.EncodeToString(digest[:])
+}
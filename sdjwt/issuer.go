@@ -0,0 +1,389 @@
+package sdjwt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+const (
+	// MediaType is the typ header of issued SD-JWT VCs.
+	MediaType = "dc+sd-jwt"
+	// LegacyMediaType is the typ header of older drafts, set Issuer.Type to issue for verifiers which expect it.
+	LegacyMediaType = "vc+sd-jwt"
+)
+
+// Policy defines how a claim is disclosed.
+type Policy string
+
+const (
+	// Selectively makes the claim selectively disclosable, it is the default of top level claims.
+	Selectively Policy = "selectively"
+	// Always keeps the claim in plain in the payload, it is the default of nested claims and array elements.
+	Always Policy = "always"
+	// Never withholds the claim, it is not part of the credential.
+	Never Policy = "never"
+	// ArrayElements keeps an array claim in plain, but makes every element selectively disclosable.
+	ArrayElements Policy = "array_elements"
+)
+
+// claims which are required to verify the credential and therefore must not be selectively disclosable
+var plainClaims = []string{"iss", "nbf", "exp", "cnf", "vct", VctIntegrityKey, "status"}
+
+// ClaimPolicy sets the policy of the claims selected by Path, a null component selects all array elements.
+type ClaimPolicy struct {
+	Path   oauth.ClaimPath
+	Policy Policy
+}
+
+// Credential holds the content of an SD-JWT VC to issue. Zero values are left out of the payload.
+type Credential struct {
+	Vct       string
+	Issuer    string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	// Holder is the public key of the holder for the cnf claim.
+	Holder jwk.Key
+	// Status is the status claim, e.g. a status_list reference.
+	Status map[string]interface{}
+	Claims map[string]interface{}
+}
+
+/*
+Issuer creates SD-JWT VCs. Policies are evaluated in order and later entries override earlier ones, claims without
+policy use DefaultPolicy on the top level. Decoys is the maximum number of decoy digests added to every _sd array and,
+as {"...": digest} elements at random positions, to every array with selectively disclosed elements. The actual
+number is random to hide the number of claims.
+*/
+type Issuer struct {
+	Signer signing.Signer
+	// HashAlgorithm for the digests, defaults to sha-256
+	HashAlgorithm string
+	Policies      []ClaimPolicy
+	// DefaultPolicy of top level claims, defaults to Selectively
+	DefaultPolicy Policy
+	Decoys        int
+	// Type is the typ header, defaults to MediaType
+	Type string
+}
+
+func NewIssuer(signer signing.Signer, policies ...ClaimPolicy) *Issuer {
+	return &Issuer{
+		Signer:   signer,
+		Policies: policies,
+	}
+}
+
+// NewDisclosure creates a salted disclosure, an empty name creates an array element disclosure.
+func NewDisclosure(name string, value interface{}) (*Disclosure, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	disclosure := Disclosure{Salt: base64.RawURLEncoding.EncodeToString(salt), Name: name, Value: value}
+	parts := []interface{}{disclosure.Salt, value}
+	if name != "" {
+		parts = []interface{}{disclosure.Salt, name, value}
+	}
+
+	b, err := json.Marshal(parts)
+	if err != nil {
+		return nil, fmt.Errorf("can not encode disclosure: %w", err)
+	}
+	disclosure.Encoded = base64.RawURLEncoding.EncodeToString(b)
+	return &disclosure, nil
+}
+
+// Issue signs the credential and returns the compact serialization with all disclosures and without key binding.
+func (issuer *Issuer) Issue(credential Credential) (string, error) {
+	if issuer.Signer == nil {
+		return "", errors.New("signer is nil")
+	}
+
+	algorithm := issuer.HashAlgorithm
+	if algorithm == "" {
+		algorithm = DefaultHashAlgorithm
+	}
+	if _, err := Digest(algorithm, ""); err != nil {
+		return "", err
+	}
+
+	claims, err := normalizeClaims(credential.Claims)
+	if err != nil {
+		return "", fmt.Errorf("can not read claims: %w", err)
+	}
+
+	for _, name := range append(append([]string{}, plainClaims...), "iat", DigestsKey, HashAlgorithmKey) {
+		if _, ok := claims[name]; ok {
+			return "", fmt.Errorf("claim %s is reserved", name)
+		}
+	}
+
+	b := builder{issuer: issuer, algorithm: algorithm}
+	payload, err := b.object(oauth.ClaimPath{}, claims)
+	if err != nil {
+		return "", err
+	}
+
+	if credential.Vct != "" {
+		payload["vct"] = credential.Vct
+	}
+	if credential.Issuer != "" {
+		payload["iss"] = credential.Issuer
+	}
+	if !credential.IssuedAt.IsZero() {
+		payload["iat"] = credential.IssuedAt.Unix()
+	}
+	if !credential.NotBefore.IsZero() {
+		payload["nbf"] = credential.NotBefore.Unix()
+	}
+	if !credential.ExpiresAt.IsZero() {
+		payload["exp"] = credential.ExpiresAt.Unix()
+	}
+	if credential.Status != nil {
+		payload["status"] = credential.Status
+	}
+	if credential.Holder != nil {
+		holder, err := jwk.PublicKeyOf(credential.Holder)
+		if err != nil {
+			return "", fmt.Errorf("invalid holder key: %w", err)
+		}
+		payload["cnf"] = map[string]interface{}{"jwk": holder}
+	}
+	if len(b.disclosures) > 0 || issuer.Decoys > 0 {
+		payload[HashAlgorithmKey] = algorithm
+	}
+
+	typ := issuer.Type
+	if typ == "" {
+		typ = MediaType
+	}
+	headers := map[string]interface{}{"typ": typ}
+	if kid := issuer.Signer.KeyID(); kid != "" {
+		headers["kid"] = kid
+	}
+
+	token, err := signing.SignJwt(issuer.Signer, headers, payload)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(b.disclosures)+2)
+	parts = append(parts, token)
+	for _, disclosure := range b.disclosures {
+		parts = append(parts, disclosure.Encoded)
+	}
+	return strings.Join(parts, Separator) + Separator, nil
+}
+
+func (issuer *Issuer) policy(path oauth.ClaimPath) (Policy, bool) {
+	var policy Policy
+	found := false
+	for _, p := range issuer.Policies {
+		if p.Path.Matches(path) {
+			policy, found = p.Policy, true
+		}
+	}
+	return policy, found
+}
+
+type builder struct {
+	issuer      *Issuer
+	algorithm   string
+	disclosures []*Disclosure
+}
+
+func (b *builder) object(path oauth.ClaimPath, object map[string]interface{}) (map[string]interface{}, error) {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]interface{}, len(object))
+	digests := make([]string, 0)
+	for _, name := range names {
+		claimPath := append(append(oauth.ClaimPath{}, path...), name)
+
+		policy, ok := b.issuer.policy(claimPath)
+		if !ok {
+			policy = Always
+			if len(path) == 0 {
+				policy = b.issuer.DefaultPolicy
+			}
+		}
+		if len(path) == 0 && slices.Contains(plainClaims, name) {
+			policy = Always
+		}
+		if policy == Never {
+			continue
+		}
+
+		value, err := b.value(claimPath, object[name], policy == ArrayElements)
+		if err != nil {
+			return nil, err
+		}
+
+		switch policy {
+		case Always, ArrayElements:
+			result[name] = value
+		case Selectively, "":
+			digest, err := b.disclose(name, value)
+			if err != nil {
+				return nil, err
+			}
+			digests = append(digests, digest)
+		default:
+			return nil, fmt.Errorf("unknown disclosure policy %s of %s", policy, claimPath)
+		}
+	}
+
+	decoys, err := b.decoys()
+	if err != nil {
+		return nil, err
+	}
+	digests = append(digests, decoys...)
+
+	if len(digests) > 0 {
+		// sorted digests do not reveal the order of the claims
+		sort.Strings(digests)
+		result[DigestsKey] = digests
+	}
+	return result, nil
+}
+
+func (b *builder) value(path oauth.ClaimPath, value interface{}, discloseElements bool) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return b.object(path, v)
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		disclosedElements := false
+		for i, element := range v {
+			elementPath := append(append(oauth.ClaimPath{}, path...), i)
+
+			policy, ok := b.issuer.policy(elementPath)
+			if !ok {
+				policy = Always
+				if discloseElements {
+					policy = Selectively
+				}
+			}
+			if policy == Never {
+				continue
+			}
+
+			disclosed, err := b.value(elementPath, element, policy == ArrayElements)
+			if err != nil {
+				return nil, err
+			}
+
+			switch policy {
+			case Always, ArrayElements:
+				result = append(result, disclosed)
+			case Selectively:
+				digest, err := b.disclose("", disclosed)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, map[string]interface{}{ArrayElementKey: digest})
+				disclosedElements = true
+			default:
+				return nil, fmt.Errorf("unknown disclosure policy %s of %s", policy, elementPath)
+			}
+		}
+
+		if disclosedElements {
+			return b.elementDecoys(result)
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+func (b *builder) disclose(name string, value interface{}) (string, error) {
+	disclosure, err := NewDisclosure(name, value)
+	if err != nil {
+		return "", err
+	}
+	b.disclosures = append(b.disclosures, disclosure)
+	return disclosure.Digest(b.algorithm)
+}
+
+func (b *builder) decoys() ([]string, error) {
+	if b.issuer.Decoys <= 0 {
+		return nil, nil
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(b.issuer.Decoys)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	decoys := make([]string, 0, n.Int64())
+	for i := int64(0); i < n.Int64(); i++ {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		digest, err := Digest(b.algorithm, base64.RawURLEncoding.EncodeToString(salt))
+		if err != nil {
+			return nil, err
+		}
+		decoys = append(decoys, digest)
+	}
+	return decoys, nil
+}
+
+// elementDecoys inserts decoy array element digests at random positions.
+func (b *builder) elementDecoys(array []interface{}) ([]interface{}, error) {
+	decoys, err := b.decoys()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decoy := range decoys {
+		position, err := rand.Int(rand.Reader, big.NewInt(int64(len(array))+1))
+		if err != nil {
+			return nil, err
+		}
+		array = slices.Insert(array, int(position.Int64()), interface{}(map[string]interface{}{ArrayElementKey: decoy}))
+	}
+	return array, nil
+}
+
+// normalizeClaims converts the claims into their generic json representation, numbers are kept as json.Number.
+func normalizeClaims(claims map[string]interface{}) (map[string]interface{}, error) {
+	if claims == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var normalized map[string]interface{}
+	if err := decoder.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
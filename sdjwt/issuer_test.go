@@ -0,0 +1,175 @@
+package sdjwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func testKey(t *testing.T) jwk.Key {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestIssue(t *testing.T) {
+	key := testKey(t)
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := NewIssuer(signer,
+		ClaimPolicy{Path: oauth.ClaimPath{"family_name"}, Policy: Always},
+		ClaimPolicy{Path: oauth.ClaimPath{"internal_id"}, Policy: Never},
+		ClaimPolicy{Path: oauth.ClaimPath{"nationalities"}, Policy: ArrayElements},
+		ClaimPolicy{Path: oauth.ClaimPath{"address", "street_address"}, Policy: Selectively},
+	)
+	issuer.Decoys = 3
+
+	now := time.Now()
+	serialized, err := issuer.Issue(Credential{
+		Vct:       "https://credentials.example.com/identity_credential",
+		Issuer:    "https://issuer.example.com",
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+		Holder:    testKey(t),
+		Status:    map[string]interface{}{"status_list": map[string]interface{}{"idx": 1, "uri": "https://issuer.example.com/status"}},
+		Claims: map[string]interface{}{
+			"given_name":    "Erika",
+			"family_name":   "Mustermann",
+			"internal_id":   "4711",
+			"age":           62,
+			"nationalities": []string{"DE", "FR"},
+			"address":       map[string]interface{}{"street_address": "Heidestrasse 17", "locality": "Köln"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(serialized, Separator) {
+		t.Error("serialization must end with the separator")
+	}
+
+	token, err := Parse(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, _ := jwk.PublicKeyOf(key)
+	if _, err := jws.Verify([]byte(token.Jwt), jws.WithKey(signer.Algorithm(), public)); err != nil {
+		t.Error(err)
+	}
+
+	if token.Header["typ"] != MediaType || token.Payload[HashAlgorithmKey] != DefaultHashAlgorithm {
+		t.Error(token.Header)
+	}
+
+	// given_name, age, address, street_address and both nationalities
+	if len(token.Disclosures) != 6 {
+		t.Error(len(token.Disclosures))
+	}
+
+	for _, name := range []string{"family_name", "vct", "iss", "cnf", "status", "nationalities"} {
+		if _, ok := token.Payload[name]; !ok {
+			t.Error(name, "must be plain")
+		}
+	}
+	for _, name := range []string{"given_name", "internal_id", "address"} {
+		if _, ok := token.Payload[name]; ok {
+			t.Error(name, "must not be plain")
+		}
+	}
+
+	claims, err := token.Claims()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"given_name": "Erika",
+		"family_name": "Mustermann",
+		"age": 62,
+		"nationalities": ["DE", "FR"],
+		"address": {"street_address": "Heidestrasse 17", "locality": "Köln"}
+	}`), &expected)
+
+	for name, value := range expected {
+		if !reflect.DeepEqual(claims[name], value) {
+			t.Error(name, claims[name])
+		}
+	}
+	if _, ok := claims["internal_id"]; ok {
+		t.Error("withheld claim issued")
+	}
+}
+
+func TestIssueArrayDecoys(t *testing.T) {
+	signer, err := signing.NewJwkSigner(testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := NewIssuer(signer, ClaimPolicy{Path: oauth.ClaimPath{"nationalities"}, Policy: ArrayElements})
+	issuer.Decoys = 3
+
+	decoys := false
+	for i := 0; i < 20; i++ {
+		serialized, err := issuer.Issue(Credential{Claims: map[string]interface{}{"nationalities": []string{"DE", "FR"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := Parse(serialized)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		elements, _ := token.Payload["nationalities"].([]interface{})
+		decoys = decoys || len(elements) > 2
+
+		claims, err := token.Claims()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(claims["nationalities"], []interface{}{"DE", "FR"}) {
+			t.Error(claims["nationalities"])
+		}
+	}
+	if !decoys {
+		t.Error("no array element decoys added")
+	}
+}
+
+func TestIssueReservedClaims(t *testing.T) {
+	signer, err := signing.NewJwkSigner(testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewIssuer(signer).Issue(Credential{Claims: map[string]interface{}{"cnf": "x"}}); err == nil {
+		t.Error("reserved claim accepted")
+	}
+
+	if _, err := (&Issuer{Signer: signer, HashAlgorithm: "md5"}).Issue(Credential{}); err == nil {
+		t.Error("unknown hash algorithm accepted")
+	}
+}
@@ -0,0 +1,210 @@
+package sdjwt
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+// KeyBindingType is the typ header of key binding jwts.
+const KeyBindingType = "kb+jwt"
+
+/*
+Present discloses the claims of the paths and appends a key binding jwt signed by the holder key. Use
+SdJwt.DiscloseFields for alternative paths, e.g. of presentation definition fields.
+*/
+func Present(serialized string, paths []oauth.ClaimPath, signer signing.Signer, audience string, nonce string) (string, error) {
+	token, err := Parse(serialized)
+	if err != nil {
+		return "", err
+	}
+
+	presentation, err := token.Disclose(paths...)
+	if err != nil {
+		return "", err
+	}
+	return presentation.Present(signer, audience, nonce)
+}
+
+// String returns the compact serialization, including the key binding jwt if present.
+func (token *SdJwt) String() string {
+	return token.serialize() + token.KeyBinding
+}
+
+// serialize returns the serialization without key binding jwt, which is the input of sd_hash.
+func (token *SdJwt) serialize() string {
+	parts := make([]string, 0, len(token.Disclosures)+2)
+	parts = append(parts, token.Jwt)
+	for _, disclosure := range token.Disclosures {
+		parts = append(parts, disclosure.Encoded)
+	}
+	return strings.Join(parts, Separator) + Separator
+}
+
+// Disclose returns a copy of the token with only the disclosures which are needed to reveal the claims of the paths.
+func (token *SdJwt) Disclose(paths ...oauth.ClaimPath) (*SdJwt, error) {
+	fields := make([][]oauth.ClaimPath, 0, len(paths))
+	for _, path := range paths {
+		fields = append(fields, []oauth.ClaimPath{path})
+	}
+	return token.DiscloseFields(fields...)
+}
+
+/*
+DiscloseFields reveals one claim per field, the first path of the alternatives which resolves is taken. Disclosing an
+object or array reveals all of its nested claims and elements, the disclosures of parents are revealed as well.
+It fails with oauth.ErrClaimNotFound if no alternative of a field resolves.
+*/
+func (token *SdJwt) DiscloseFields(fields ...[]oauth.ClaimPath) (*SdJwt, error) {
+	digests, err := token.digests()
+	if err != nil {
+		return nil, err
+	}
+
+	revealed := make(map[*Disclosure]bool)
+	for _, alternatives := range fields {
+		found := false
+		for _, path := range alternatives {
+			selected, err := token.resolve(path, digests)
+			if errors.Is(err, oauth.ErrClaimNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			for _, s := range selected {
+				for _, disclosure := range s.Disclosures {
+					revealed[disclosure] = true
+				}
+				reveal(s.Value, digests, revealed)
+			}
+			found = true
+			break
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: %v", oauth.ErrClaimNotFound, alternatives)
+		}
+	}
+
+	presentation := SdJwt{
+		Jwt:         token.Jwt,
+		Header:      token.Header,
+		Payload:     token.Payload,
+		Disclosures: make([]*Disclosure, 0, len(revealed)),
+	}
+	for _, disclosure := range token.Disclosures {
+		if revealed[disclosure] {
+			presentation.Disclosures = append(presentation.Disclosures, disclosure)
+		}
+	}
+	return &presentation, nil
+}
+
+/*
+Present appends a key binding jwt with aud, nonce, iat and the sd_hash of the disclosed token. The signer must hold
+the key of the cnf claim, if the token is bound to a jwk.
+*/
+func (token *SdJwt) Present(signer signing.Signer, audience string, nonce string) (string, error) {
+	if signer == nil {
+		return "", errors.New("signer is nil")
+	}
+
+	if err := token.checkHolder(signer.PublicKey()); err != nil {
+		return "", err
+	}
+
+	serialized := token.serialize()
+	sdHash, err := Digest(token.HashAlgorithm(), serialized)
+	if err != nil {
+		return "", err
+	}
+
+	keyBinding, err := signing.SignJwt(signer, map[string]interface{}{"typ": KeyBindingType}, map[string]interface{}{
+		"iat":     time.Now().Unix(),
+		"aud":     audience,
+		"nonce":   nonce,
+		"sd_hash": sdHash,
+	})
+	if err != nil {
+		return "", fmt.Errorf("can not sign key binding jwt: %w", err)
+	}
+	return serialized + keyBinding, nil
+}
+
+// HolderKey returns the jwk of the cnf claim, nil if the token is not bound to a jwk.
+func (token *SdJwt) HolderKey() (jwk.Key, error) {
+	cnf, ok := token.Payload["cnf"].(map[string]interface{})
+	if !ok || cnf["jwk"] == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(cnf["jwk"])
+	if err != nil {
+		return nil, err
+	}
+	key, err := jwk.ParseKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cnf jwk: %w", err)
+	}
+	return key, nil
+}
+
+func (token *SdJwt) checkHolder(key jwk.Key) error {
+	holder, err := token.HolderKey()
+	if err != nil || holder == nil || key == nil {
+		return err
+	}
+
+	expected, err := holder.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	actual, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, actual) {
+		return errors.New("signer does not hold the key of the cnf claim")
+	}
+	return nil
+}
+
+// reveal marks all disclosures nested in value.
+func reveal(value interface{}, digests map[string]*Disclosure, revealed map[*Disclosure]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, digest := range sdDigests(v) {
+			if disclosure, ok := digests[digest]; ok && !disclosure.IsArrayElement() {
+				revealed[disclosure] = true
+				reveal(disclosure.Value, digests, revealed)
+			}
+		}
+		for key, child := range v {
+			if key != DigestsKey {
+				reveal(child, digests, revealed)
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			if digest, ok := elementDigest(element); ok {
+				if disclosure, ok := digests[digest]; ok && disclosure.IsArrayElement() {
+					revealed[disclosure] = true
+					reveal(disclosure.Value, digests, revealed)
+				}
+				continue
+			}
+			reveal(element, digests, revealed)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sdjwt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func TestPresent(t *testing.T) {
+	issuerSigner, err := signing.NewJwkSigner(testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	holderKey := testKey(t)
+	holder, err := signing.NewJwkSigner(holderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := NewIssuer(issuerSigner,
+		ClaimPolicy{Path: oauth.ClaimPath{"nationalities"}, Policy: ArrayElements},
+		ClaimPolicy{Path: oauth.ClaimPath{"address", "street_address"}, Policy: Selectively},
+	)
+	serialized, err := issuer.Issue(Credential{
+		Vct:    "https://credentials.example.com/identity_credential",
+		Holder: holderKey,
+		Claims: map[string]interface{}{
+			"given_name":    "Erika",
+			"family_name":   "Mustermann",
+			"nationalities": []string{"DE", "FR"},
+			"address":       map[string]interface{}{"street_address": "Heidestrasse 17", "locality": "Köln"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	presented, err := Present(serialized, []oauth.ClaimPath{{"given_name"}, {"nationalities", 1}, {"address"}}, holder, "https://verifier.example.com", "n-0S6_WzA2Mj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := Parse(presented)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := token.Claims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := claims["family_name"]; ok {
+		t.Error("family_name must not be disclosed")
+	}
+	nationalities, _ := claims["nationalities"].([]interface{})
+	address, _ := claims["address"].(map[string]interface{})
+	if claims["given_name"] != "Erika" || len(nationalities) != 1 || nationalities[0] != "FR" || address["street_address"] != "Heidestrasse 17" {
+		t.Error(claims)
+	}
+
+	public, _ := jwk.PublicKeyOf(holderKey)
+	kb, err := jwt.ParseString(token.KeyBinding, jwt.WithKey(holder.Algorithm(), public), jwt.WithAudience("https://verifier.example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdHash, _ := Digest(DefaultHashAlgorithm, strings.TrimSuffix(presented, token.KeyBinding))
+	if nonce, _ := kb.Get("nonce"); nonce != "n-0S6_WzA2Mj" {
+		t.Error(nonce)
+	}
+	if hash, _ := kb.Get("sd_hash"); hash != sdHash {
+		t.Error("sd_hash mismatch")
+	}
+
+	if _, err := Present(serialized, []oauth.ClaimPath{{"given_name"}}, issuerSigner, "aud", "nonce"); err == nil {
+		t.Error("key binding with foreign key")
+	}
+
+	parsed, _ := Parse(serialized)
+	disclosed, err := parsed.DiscloseFields([]oauth.ClaimPath{{"birthdate"}, {"family_name"}})
+	if err != nil || len(disclosed.Disclosures) != 1 || disclosed.Disclosures[0].Name != "family_name" {
+		t.Error(err)
+	}
+	if _, err := parsed.Disclose(oauth.ClaimPath{"birthdate"}); !errors.Is(err, oauth.ErrClaimNotFound) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,387 @@
+package sdjwt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const (
+	Separator            = "~"
+	DefaultHashAlgorithm = "sha-256"
+	// ArrayElementKey marks digests of array element disclosures
+	ArrayElementKey  = "..."
+	DigestsKey       = "_sd"
+	HashAlgorithmKey = "_sd_alg"
+)
+
+var (
+	ErrUnknownHashAlgorithm = errors.New("unsupported _sd_alg")
+	ErrClaimDuplicate       = errors.New("disclosed claim already exists")
+)
+
+// Disclosure is a salted claim. Name is empty for array elements.
+type Disclosure struct {
+	Salt    string
+	Name    string
+	Value   interface{}
+	Encoded string
+}
+
+/*
+SdJwt is a parsed SD-JWT in compact serialization: the issuer signed jwt, the disclosures and an optional key binding
+jwt. Parsing does not verify any signature.
+*/
+type SdJwt struct {
+	Jwt         string
+	Header      map[string]interface{}
+	Payload     map[string]interface{}
+	Disclosures []*Disclosure
+	KeyBinding  string
+}
+
+// Selection is a claim selected by a claim path together with the disclosures which reveal it.
+type Selection struct {
+	Path        oauth.ClaimPath
+	Value       interface{}
+	Disclosures []*Disclosure
+}
+
+func (disclosure *Disclosure) IsArrayElement() bool {
+	return disclosure.Name == ""
+}
+
+func ParseDisclosure(encoded string) (*Disclosure, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid disclosure encoding: %w", err)
+	}
+
+	var parts []interface{}
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return nil, fmt.Errorf("invalid disclosure: %w", err)
+	}
+
+	if len(parts) == 0 {
+		return nil, errors.New("empty disclosure")
+	}
+
+	salt, ok := parts[0].(string)
+	if !ok {
+		return nil, errors.New("disclosure salt missing")
+	}
+	disclosure := Disclosure{Salt: salt, Encoded: encoded}
+
+	switch len(parts) {
+	case 2:
+		disclosure.Value = parts[1]
+	case 3:
+		name, ok := parts[1].(string)
+		if !ok || name == "" || name == DigestsKey || name == ArrayElementKey {
+			return nil, fmt.Errorf("invalid disclosure claim name %v", parts[1])
+		}
+		disclosure.Name = name
+		disclosure.Value = parts[2]
+	default:
+		return nil, fmt.Errorf("disclosure with %d elements", len(parts))
+	}
+	return &disclosure, nil
+}
+
+// Digest returns the base64url encoded digest of the encoded disclosure.
+func (disclosure *Disclosure) Digest(algorithm string) (string, error) {
+	return Digest(algorithm, disclosure.Encoded)
+}
+
+// Digest hashes value with the IANA named hash algorithm of _sd_alg and returns it base64url encoded.
+func Digest(algorithm string, value string) (string, error) {
+	var h hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "", "sha-256":
+		h = sha256.New()
+	case "sha-384":
+		h = sha512.New384()
+	case "sha-512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownHashAlgorithm, algorithm)
+	}
+	h.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// Parse splits the compact serialization and decodes the jwt and disclosures.
+func Parse(serialized string) (*SdJwt, error) {
+	parts := strings.Split(strings.TrimSpace(serialized), Separator)
+	if len(parts) < 2 {
+		return nil, errors.New("no sd-jwt: separator missing")
+	}
+
+	token := SdJwt{
+		Jwt:         parts[0],
+		KeyBinding:  parts[len(parts)-1],
+		Disclosures: make([]*Disclosure, 0, len(parts)-2),
+	}
+
+	segments := strings.Split(token.Jwt, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("no sd-jwt: invalid jwt")
+	}
+
+	if err := decodeSegment(segments[0], &token.Header); err != nil {
+		return nil, fmt.Errorf("invalid sd-jwt header: %w", err)
+	}
+	if err := decodeSegment(segments[1], &token.Payload); err != nil {
+		return nil, fmt.Errorf("invalid sd-jwt payload: %w", err)
+	}
+
+	for _, encoded := range parts[1 : len(parts)-1] {
+		if encoded == "" {
+			return nil, errors.New("empty disclosure")
+		}
+		disclosure, err := ParseDisclosure(encoded)
+		if err != nil {
+			return nil, err
+		}
+		token.Disclosures = append(token.Disclosures, disclosure)
+	}
+	return &token, nil
+}
+
+// HashAlgorithm returns _sd_alg of the payload, sha-256 if absent.
+func (token *SdJwt) HashAlgorithm() string {
+	if alg, ok := token.Payload[HashAlgorithmKey].(string); ok && alg != "" {
+		return alg
+	}
+	return DefaultHashAlgorithm
+}
+
+func (token *SdJwt) digests() (map[string]*Disclosure, error) {
+	digests := make(map[string]*Disclosure, len(token.Disclosures))
+	for _, disclosure := range token.Disclosures {
+		digest, err := disclosure.Digest(token.HashAlgorithm())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := digests[digest]; ok {
+			return nil, errors.New("disclosure included twice")
+		}
+		digests[digest] = disclosure
+	}
+	return digests, nil
+}
+
+// Claims returns the payload with all disclosed claims and without the sd-jwt specific claims.
+func (token *SdJwt) Claims() (map[string]interface{}, error) {
+	digests, err := token.digests()
+	if err != nil {
+		return nil, err
+	}
+
+	disclosed, err := disclose(token.Payload, digests)
+	if err != nil {
+		return nil, err
+	}
+	claims, _ := disclosed.(map[string]interface{})
+	return claims, nil
+}
+
+/*
+Resolve selects the claims of a claim path. Undisclosed claims are looked up in the disclosures, every selection
+lists the disclosures which are needed to reveal it, including those of parent objects and arrays.
+*/
+func (token *SdJwt) Resolve(path oauth.ClaimPath) ([]Selection, error) {
+	if len(path) == 0 {
+		return nil, errors.New("claim path must not be empty")
+	}
+
+	digests, err := token.digests()
+	if err != nil {
+		return nil, err
+	}
+	// a disclosure must not shadow a plain claim the selection would prefer
+	if _, err := disclose(token.Payload, digests); err != nil {
+		return nil, err
+	}
+
+	selected, err := token.resolve(path, digests)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range selected {
+		selected[i].Value, _ = disclose(selected[i].Value, digests)
+	}
+	return selected, nil
+}
+
+// resolve selects the claims of the path, the values still contain the digests of nested disclosures.
+func (token *SdJwt) resolve(path oauth.ClaimPath, digests map[string]*Disclosure) ([]Selection, error) {
+	selected := []Selection{{Path: oauth.ClaimPath{}, Value: interface{}(token.Payload)}}
+	for _, component := range path {
+		next := make([]Selection, 0)
+		for _, s := range selected {
+			children, err := s.children(component, digests)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", err, path)
+			}
+			next = append(next, children...)
+		}
+		selected = next
+	}
+	return selected, nil
+}
+
+func (s Selection) child(component interface{}, value interface{}, disclosure *Disclosure) Selection {
+	child := Selection{
+		Path:        append(append(oauth.ClaimPath{}, s.Path...), component),
+		Value:       value,
+		Disclosures: append([]*Disclosure{}, s.Disclosures...),
+	}
+	if disclosure != nil {
+		child.Disclosures = append(child.Disclosures, disclosure)
+	}
+	return child
+}
+
+func (s Selection) children(component interface{}, digests map[string]*Disclosure) ([]Selection, error) {
+	switch c := component.(type) {
+	case string:
+		object, ok := s.Value.(map[string]interface{})
+		if !ok {
+			return nil, oauth.ErrClaimNotFound
+		}
+		if c != DigestsKey && c != HashAlgorithmKey {
+			if value, ok := object[c]; ok {
+				return []Selection{s.child(c, value, nil)}, nil
+			}
+		}
+		for _, digest := range sdDigests(object) {
+			if disclosure, ok := digests[digest]; ok && disclosure.Name == c {
+				return []Selection{s.child(c, disclosure.Value, disclosure)}, nil
+			}
+		}
+		return nil, oauth.ErrClaimNotFound
+	default:
+		array, ok := s.Value.([]interface{})
+		if !ok {
+			return nil, oauth.ErrClaimNotFound
+		}
+
+		elements := make([]Selection, 0, len(array))
+		for _, element := range array {
+			if digest, ok := elementDigest(element); ok {
+				if disclosure, ok := digests[digest]; ok && disclosure.IsArrayElement() {
+					elements = append(elements, s.child(len(elements), disclosure.Value, disclosure))
+				}
+				continue
+			}
+			elements = append(elements, s.child(len(elements), element, nil))
+		}
+
+		if c == nil {
+			return elements, nil
+		}
+
+		i, ok := c.(int)
+		if f, isFloat := c.(float64); isFloat {
+			i, ok = int(f), true
+		}
+		if !ok || i < 0 || i >= len(elements) {
+			return nil, oauth.ErrClaimNotFound
+		}
+		return []Selection{elements[i]}, nil
+	}
+}
+
+/*
+disclose replaces all digests of value by the disclosed claims, undisclosed digests are removed. A disclosed claim
+whose name already exists in its object is rejected with ErrClaimDuplicate.
+*/
+func disclose(value interface{}, digests map[string]*Disclosure) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if key == DigestsKey || key == HashAlgorithmKey {
+				continue
+			}
+			disclosed, err := disclose(child, digests)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = disclosed
+		}
+		for _, digest := range sdDigests(v) {
+			disclosure, ok := digests[digest]
+			if !ok || disclosure.IsArrayElement() {
+				continue
+			}
+			if _, exists := result[disclosure.Name]; exists {
+				return nil, fmt.Errorf("%w: %s", ErrClaimDuplicate, disclosure.Name)
+			}
+			disclosed, err := disclose(disclosure.Value, digests)
+			if err != nil {
+				return nil, err
+			}
+			result[disclosure.Name] = disclosed
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, element := range v {
+			if digest, ok := elementDigest(element); ok {
+				if disclosure, ok := digests[digest]; ok && disclosure.IsArrayElement() {
+					disclosed, err := disclose(disclosure.Value, digests)
+					if err != nil {
+						return nil, err
+					}
+					result = append(result, disclosed)
+				}
+				continue
+			}
+			disclosed, err := disclose(element, digests)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, disclosed)
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+func sdDigests(object map[string]interface{}) []string {
+	values, _ := object[DigestsKey].([]interface{})
+	digests := make([]string, 0, len(values))
+	for _, v := range values {
+		if digest, ok := v.(string); ok {
+			digests = append(digests, digest)
+		}
+	}
+	return digests
+}
+
+func elementDigest(element interface{}) (string, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return "", false
+	}
+	digest, ok := object[ArrayElementKey].(string)
+	return digest, ok
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,137 @@
+package sdjwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+func encode(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func digest(t *testing.T, encoded string) string {
+	d, err := Digest(DefaultHashAlgorithm, encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// testSdJwt discloses given_name, the street of address and the second nationality.
+func testSdJwt(t *testing.T) string {
+	givenName := encode(t, []interface{}{"salt1", "given_name", "Erika"})
+	street := encode(t, []interface{}{"salt2", "street_address", "Heidestrasse 17"})
+	address := encode(t, []interface{}{"salt3", "address", map[string]interface{}{
+		"_sd":      []interface{}{digest(t, street), "decoy"},
+		"locality": "Köln",
+	}})
+	nationality := encode(t, []interface{}{"salt4", "DE"})
+
+	payload := map[string]interface{}{
+		"iss":     "https://issuer.example.com",
+		"_sd_alg": "sha-256",
+		"_sd":     []interface{}{digest(t, givenName), digest(t, address)},
+		"nationalities": []interface{}{
+			"FR",
+			map[string]interface{}{"...": digest(t, nationality)},
+			map[string]interface{}{"...": "undisclosed"},
+		},
+	}
+
+	jwt := encode(t, map[string]interface{}{"alg": "ES256", "typ": "dc+sd-jwt"}) + "." + encode(t, payload) + ".c2ln"
+	return strings.Join([]string{jwt, givenName, address, street, nationality, ""}, Separator)
+}
+
+func TestClaims(t *testing.T) {
+	token, err := Parse(testSdJwt(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(token.Disclosures) != 4 || token.KeyBinding != "" {
+		t.Error()
+	}
+
+	claims, err := token.Claims()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"iss": "https://issuer.example.com",
+		"given_name": "Erika",
+		"address": {"street_address": "Heidestrasse 17", "locality": "Köln"},
+		"nationalities": ["FR", "DE"]
+	}`), &expected)
+
+	if !reflect.DeepEqual(claims, expected) {
+		t.Error(claims)
+	}
+}
+
+func TestClaimsDuplicate(t *testing.T) {
+	iss := encode(t, []interface{}{"salt1", "iss", "https://attacker.example.com"})
+	payload := map[string]interface{}{
+		"iss": "https://issuer.example.com",
+		"_sd": []interface{}{digest(t, iss)},
+	}
+	jwt := encode(t, map[string]interface{}{"alg": "ES256"}) + "." + encode(t, payload) + ".c2ln"
+
+	token, err := Parse(strings.Join([]string{jwt, iss, ""}, Separator))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := token.Claims(); !errors.Is(err, ErrClaimDuplicate) {
+		t.Error("disclosure overrides a plain claim", err)
+	}
+	if _, err := token.Resolve(oauth.ClaimPath{"iss"}); !errors.Is(err, ErrClaimDuplicate) {
+		t.Error(err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	token, _ := Parse(testSdJwt(t))
+
+	selections, err := token.Resolve(oauth.ClaimPath{"address", "street_address"})
+	if err != nil || len(selections) != 1 {
+		t.Fatal(err)
+	}
+
+	if selections[0].Value != "Heidestrasse 17" || len(selections[0].Disclosures) != 2 || selections[0].Disclosures[0].Name != "address" {
+		t.Error(selections[0])
+	}
+
+	selections, err = token.Resolve(oauth.ClaimPath{"nationalities", nil})
+	if err != nil || len(selections) != 2 || selections[1].Value != "DE" || len(selections[1].Disclosures) != 1 || len(selections[0].Disclosures) != 0 {
+		t.Error(selections, err)
+	}
+
+	selections, err = token.Resolve(oauth.ClaimPath{"nationalities", 1})
+	if err != nil || selections[0].Value != "DE" {
+		t.Error(err)
+	}
+
+	if _, err = token.Resolve(oauth.ClaimPath{"family_name"}); err == nil {
+		t.Error("undisclosed claim resolved")
+	}
+}
+
+func TestInvalidDisclosure(t *testing.T) {
+	for _, invalid := range []interface{}{[]interface{}{}, []interface{}{1, "a", "b"}, []interface{}{"salt", "_sd", 1}, []interface{}{"a", "b", "c", "d"}} {
+		if _, err := ParseDisclosure(encode(t, invalid)); err == nil {
+			t.Error(invalid)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package sdjwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+const (
+	VctIntegrityKey = "vct#integrity"
+	// DefaultMaxExtendsDepth limits extends chains
+	DefaultMaxExtendsDepth = 10
+)
+
+var DefaultTypeMetadataCacheExpiry = time.Hour
+
+var (
+	ErrTypeMetadataCycle    = errors.New("type metadata extends cycle")
+	ErrTypeMetadataNotFound = errors.New("type metadata not found")
+)
+
+// Selective disclosure rules of claims in type metadata
+const (
+	DisclosureAlways  = "always"
+	DisclosureAllowed = "allowed"
+	DisclosureNever   = "never"
+)
+
+// TypeMetadata describes a vct according to SD-JWT VC.
+type TypeMetadata struct {
+	Vct                string                 `json:"vct"`
+	Name               string                 `json:"name,omitempty"`
+	Description        string                 `json:"description,omitempty"`
+	Extends            string                 `json:"extends,omitempty"`
+	ExtendsIntegrity   string                 `json:"extends#integrity,omitempty"`
+	Display            []TypeDisplay          `json:"display,omitempty"`
+	Claims             []TypeClaim            `json:"claims,omitempty"`
+	Schema             map[string]interface{} `json:"schema,omitempty"`
+	SchemaUri          string                 `json:"schema_uri,omitempty"`
+	SchemaUriIntegrity string                 `json:"schema_uri#integrity,omitempty"`
+}
+
+type TypeDisplay struct {
+	// Lang is used by older drafts, Locale by newer ones.
+	Lang        string     `json:"lang,omitempty"`
+	Locale      string     `json:"locale,omitempty"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Rendering   *Rendering `json:"rendering,omitempty"`
+}
+
+type Rendering struct {
+	Simple       *SimpleRendering `json:"simple,omitempty"`
+	SvgTemplates []SvgTemplate    `json:"svg_templates,omitempty"`
+}
+
+type SimpleRendering struct {
+	Logo            *RenderingImage `json:"logo,omitempty"`
+	BackgroundImage *RenderingImage `json:"background_image,omitempty"`
+	BackgroundColor string          `json:"background_color,omitempty"`
+	TextColor       string          `json:"text_color,omitempty"`
+}
+
+type RenderingImage struct {
+	Uri          string `json:"uri"`
+	UriIntegrity string `json:"uri#integrity,omitempty"`
+	AltText      string `json:"alt_text,omitempty"`
+}
+
+type SvgTemplate struct {
+	Uri          string                 `json:"uri"`
+	UriIntegrity string                 `json:"uri#integrity,omitempty"`
+	Properties   *SvgTemplateProperties `json:"properties,omitempty"`
+}
+
+type SvgTemplateProperties struct {
+	Orientation string `json:"orientation,omitempty"`
+	ColorScheme string `json:"color_scheme,omitempty"`
+	Contrast    string `json:"contrast,omitempty"`
+}
+
+type TypeClaim struct {
+	Path    oauth.ClaimPath    `json:"path"`
+	Display []TypeClaimDisplay `json:"display,omitempty"`
+	Sd      string             `json:"sd,omitempty"`
+	SvgId   string             `json:"svg_id,omitempty"`
+}
+
+type TypeClaimDisplay struct {
+	Lang        string `json:"lang,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+}
+
+// Language returns the locale of the display entry.
+func (display *TypeDisplay) Language() string {
+	if display.Locale != "" {
+		return display.Locale
+	}
+	return display.Lang
+}
+
+// Language returns the locale of the display entry.
+func (display *TypeClaimDisplay) Language() string {
+	if display.Locale != "" {
+		return display.Locale
+	}
+	return display.Lang
+}
+
+/*
+ResolvedType is a vct together with all types it extends. Display and Claims are merged along the chain, the
+extending type overrides the display entries of a locale and the claims of a path. Every schema of the chain must be
+satisfied by the credential.
+*/
+type ResolvedType struct {
+	// Chain starts with the resolved type followed by the types it extends.
+	Chain   []TypeMetadata
+	Display []TypeDisplay
+	Claims  []TypeClaim
+	Schemas []map[string]interface{}
+}
+
+// TypeMetadataCache stores the raw type metadata documents by url, so that integrity is checked on every resolution.
+type TypeMetadataCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, document []byte)
+}
+
+type cachedDocument struct {
+	document  []byte
+	expiresAt time.Time
+}
+
+type MemoryTypeMetadataCache struct {
+	mutex     sync.Mutex
+	Expiry    time.Duration
+	documents map[string]cachedDocument
+}
+
+func NewMemoryTypeMetadataCache() *MemoryTypeMetadataCache {
+	return &MemoryTypeMetadataCache{
+		Expiry:    DefaultTypeMetadataCacheExpiry,
+		documents: make(map[string]cachedDocument),
+	}
+}
+
+func (cache *MemoryTypeMetadataCache) Get(key string) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cached, ok := cache.documents[key]
+	if !ok || time.Now().After(cached.expiresAt) {
+		delete(cache.documents, key)
+		return nil, false
+	}
+	return cached.document, true
+}
+
+func (cache *MemoryTypeMetadataCache) Set(key string, document []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.documents[key] = cachedDocument{document: document, expiresAt: time.Now().Add(cache.Expiry)}
+}
+
+/*
+TypeMetadataResolver fetches SD-JWT VC type metadata from https vct urls, falling back to the .well-known/vct location
+of the vct. Types which are no urls (e.g. urns) can be registered in the cache with their vct as key.
+*/
+type TypeMetadataResolver struct {
+	Client   helper.HttpClient
+	Cache    TypeMetadataCache
+	MaxDepth int
+}
+
+func NewTypeMetadataResolver() *TypeMetadataResolver {
+	return &TypeMetadataResolver{
+		Client:   helper.NewHttpClient(),
+		Cache:    NewMemoryTypeMetadataCache(),
+		MaxDepth: DefaultMaxExtendsDepth,
+	}
+}
+
+// ResolveCredential resolves the type of the vct claim of an SD-JWT VC and checks its vct#integrity.
+func (resolver *TypeMetadataResolver) ResolveCredential(token *SdJwt) (*ResolvedType, error) {
+	vct, ok := token.Payload["vct"].(string)
+	if !ok || vct == "" {
+		return nil, errors.New("sd-jwt has no vct")
+	}
+
+	integrity, _ := token.Payload[VctIntegrityKey].(string)
+	return resolver.Resolve(vct, integrity)
+}
+
+// Resolve resolves the type metadata of vct and all types it extends. integrity is optional.
+func (resolver *TypeMetadataResolver) Resolve(vct string, integrity string) (*ResolvedType, error) {
+	maxDepth := resolver.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxExtendsDepth
+	}
+
+	resolved := ResolvedType{}
+	visited := make(map[string]bool)
+
+	for vct != "" {
+		if visited[vct] {
+			return nil, fmt.Errorf("%w: %s", ErrTypeMetadataCycle, vct)
+		}
+		if len(resolved.Chain) >= maxDepth {
+			return nil, fmt.Errorf("type metadata extends chain longer than %d", maxDepth)
+		}
+		visited[vct] = true
+
+		metadata, err := resolver.typeMetadata(vct, integrity)
+		if err != nil {
+			return nil, err
+		}
+
+		if metadata.Vct != "" && metadata.Vct != vct {
+			return nil, fmt.Errorf("type metadata of %s describes %s", vct, metadata.Vct)
+		}
+
+		schema, err := resolver.schema(*metadata)
+		if err != nil {
+			return nil, err
+		}
+		if schema != nil {
+			resolved.Schemas = append(resolved.Schemas, schema)
+		}
+
+		resolved.Chain = append(resolved.Chain, *metadata)
+		vct, integrity = metadata.Extends, metadata.ExtendsIntegrity
+	}
+
+	resolved.merge()
+	return &resolved, nil
+}
+
+/*
+Fetch loads a document referenced by type metadata (e.g. a logo or svg template) and checks its integrity if given.
+*/
+func (resolver *TypeMetadataResolver) Fetch(uri string, integrity string) ([]byte, error) {
+	b, err := helper.GetWithClient(resolver.client(), uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if integrity != "" {
+		if err := VerifyIntegrity(b, integrity); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, uri)
+		}
+	}
+	return b, nil
+}
+
+func (resolver *TypeMetadataResolver) typeMetadata(vct string, integrity string) (*TypeMetadata, error) {
+	document, err := resolver.document(vct)
+	if err != nil {
+		return nil, err
+	}
+
+	if integrity != "" {
+		if err := VerifyIntegrity(document, integrity); err != nil {
+			return nil, fmt.Errorf("%w: type metadata of %s", err, vct)
+		}
+	}
+
+	var metadata TypeMetadata
+	if err := json.Unmarshal(document, &metadata); err != nil {
+		return nil, fmt.Errorf("invalid type metadata of %s: %w", vct, err)
+	}
+	return &metadata, nil
+}
+
+func (resolver *TypeMetadataResolver) document(vct string) ([]byte, error) {
+	if resolver.Cache != nil {
+		if document, ok := resolver.Cache.Get(vct); ok {
+			return document, nil
+		}
+	}
+
+	locations := TypeMetadataLocations(vct)
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTypeMetadataNotFound, vct)
+	}
+
+	var errs []error
+	for _, location := range locations {
+		document, err := helper.GetWithClient(resolver.client(), location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if resolver.Cache != nil {
+			resolver.Cache.Set(vct, document)
+		}
+		return document, nil
+	}
+	return nil, fmt.Errorf("%w: %s: %w", ErrTypeMetadataNotFound, vct, errors.Join(errs...))
+}
+
+func (resolver *TypeMetadataResolver) schema(metadata TypeMetadata) (map[string]interface{}, error) {
+	if metadata.Schema != nil {
+		return metadata.Schema, nil
+	}
+
+	if metadata.SchemaUri == "" {
+		return nil, nil
+	}
+
+	b, err := resolver.Fetch(metadata.SchemaUri, metadata.SchemaUriIntegrity)
+	if err != nil {
+		return nil, fmt.Errorf("can not load schema of %s: %w", metadata.Vct, err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema of %s: %w", metadata.Vct, err)
+	}
+	return schema, nil
+}
+
+func (resolver *TypeMetadataResolver) client() helper.HttpClient {
+	if resolver.Client == nil {
+		return helper.NewHttpClient()
+	}
+	return resolver.Client
+}
+
+/*
+TypeMetadataLocations returns the urls to fetch the type metadata of vct from: the vct itself and its .well-known/vct
+location (https://example.com/a/b -> https://example.com/.well-known/vct/a/b). Non https types have none.
+*/
+func TypeMetadataLocations(vct string) []string {
+	u, err := url.Parse(vct)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil
+	}
+
+	wellKnown := *u
+	wellKnown.Path = "/.well-known/vct" + u.Path
+	wellKnown.RawPath = ""
+	return []string{vct, wellKnown.String()}
+}
+
+func (resolved *ResolvedType) merge() {
+	displays := make(map[string]bool)
+	claims := make(map[string]bool)
+
+	for _, metadata := range resolved.Chain {
+		for _, display := range metadata.Display {
+			key := strings.ToLower(display.Language())
+			if displays[key] {
+				continue
+			}
+			displays[key] = true
+			resolved.Display = append(resolved.Display, display)
+		}
+
+		for _, claim := range metadata.Claims {
+			key := claim.Path.String()
+			if claims[key] {
+				continue
+			}
+			claims[key] = true
+			resolved.Claims = append(resolved.Claims, claim)
+		}
+	}
+}
+
+// Claim returns the merged metadata of the claim at path, nil if the type does not describe it.
+func (resolved *ResolvedType) Claim(path oauth.ClaimPath) *TypeClaim {
+	for i, claim := range resolved.Claims {
+		if claim.Path.Matches(path) {
+			return &resolved.Claims[i]
+		}
+	}
+	return nil
+}
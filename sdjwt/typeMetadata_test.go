@@ -0,0 +1,129 @@
+package sdjwt
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+)
+
+func typeMetadataServer(t *testing.T, documents map[string]interface{}) (*httptest.Server, map[string][]byte) {
+	raw := make(map[string][]byte)
+	for path, document := range documents {
+		b, err := json.Marshal(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw[path] = b
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok := raw[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, raw
+}
+
+func TestTypeMetadataResolution(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "required": []interface{}{"given_name"}}
+
+	srv, raw := typeMetadataServer(t, map[string]interface{}{
+		"/.well-known/vct/identity": TypeMetadata{
+			Name:    "Identity",
+			Display: []TypeDisplay{{Locale: "en-US", Name: "Base Identity"}, {Locale: "de-DE", Name: "Identität"}},
+			Claims:  []TypeClaim{{Path: oauth.ClaimPath{"given_name"}, Sd: DisclosureAlways}},
+			Schema:  schema,
+		},
+		"/schema.json": schema,
+	})
+
+	base := srv.URL + "/identity"
+	child := TypeMetadata{
+		Vct:       srv.URL + "/person",
+		Extends:   base,
+		Display:   []TypeDisplay{{Lang: "en-US", Name: "Person", Rendering: &Rendering{Simple: &SimpleRendering{BackgroundColor: "#fff"}}}},
+		Claims:    []TypeClaim{{Path: oauth.ClaimPath{"given_name"}, Sd: DisclosureAllowed}, {Path: oauth.ClaimPath{"nationalities", nil}}},
+		SchemaUri: srv.URL + "/schema.json",
+	}
+	child.ExtendsIntegrity = Integrity(raw["/.well-known/vct/identity"])
+	child.SchemaUriIntegrity = Integrity(raw["/schema.json"])
+
+	childDocument, _ := json.Marshal(child)
+
+	resolver := NewTypeMetadataResolver()
+	resolver.Client = srv.Client()
+	resolver.Cache.Set(child.Vct, childDocument)
+
+	resolved, err := resolver.Resolve(child.Vct, Integrity(childDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resolved.Chain) != 2 || len(resolved.Schemas) != 2 || resolved.Chain[1].Name != "Identity" {
+		t.Error(resolved)
+	}
+
+	if len(resolved.Display) != 2 || resolved.Display[0].Name != "Person" || resolved.Display[1].Name != "Identität" {
+		t.Error(resolved.Display)
+	}
+
+	if claim := resolved.Claim(oauth.ClaimPath{"given_name"}); claim == nil || claim.Sd != DisclosureAllowed {
+		t.Error(claim)
+	}
+
+	if claim := resolved.Claim(oauth.ClaimPath{"nationalities", 2}); claim == nil {
+		t.Error()
+	}
+
+	if _, err = resolver.Resolve(child.Vct, Integrity([]byte("other"))); !errors.Is(err, ErrIntegrity) {
+		t.Error(err)
+	}
+}
+
+func TestTypeMetadataCycle(t *testing.T) {
+	resolver := NewTypeMetadataResolver()
+
+	a, _ := json.Marshal(TypeMetadata{Vct: "urn:a", Extends: "urn:b"})
+	b, _ := json.Marshal(TypeMetadata{Vct: "urn:b", Extends: "urn:a"})
+	resolver.Cache.Set("urn:a", a)
+	resolver.Cache.Set("urn:b", b)
+
+	if _, err := resolver.Resolve("urn:a", ""); !errors.Is(err, ErrTypeMetadataCycle) {
+		t.Error(err)
+	}
+
+	if _, err := resolver.Resolve("urn:unknown", ""); !errors.Is(err, ErrTypeMetadataNotFound) {
+		t.Error(err)
+	}
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	data := []byte("hello")
+	integrity := Integrity(data)
+
+	if VerifyIntegrity(data, integrity) != nil {
+		t.Error()
+	}
+
+	// only the strongest algorithm counts
+	if VerifyIntegrity(data, integrity+" sha512-AAAA") == nil {
+		t.Error()
+	}
+
+	if VerifyIntegrity(data, "md5-abc") == nil {
+		t.Error()
+	}
+
+	if got := TypeMetadataLocations("https://example.com/a/b"); len(got) != 2 || !strings.HasSuffix(got[1], "/.well-known/vct/a/b") {
+		t.Error(got)
+	}
+}
@@ -0,0 +1,316 @@
+package sdjwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"golang.org/x/exp/slices"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/config"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/helper"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/jwtkeys"
+)
+
+// DefaultMaxKeyBindingAge limits how old the iat of a key binding jwt may be.
+var DefaultMaxKeyBindingAge = 5 * time.Minute
+
+var (
+	ErrSignatureInvalid  = errors.New("sd-jwt signature invalid")
+	ErrDisclosureInvalid = errors.New("sd-jwt disclosure invalid")
+	ErrKeyBindingInvalid = errors.New("key binding jwt invalid")
+	ErrExpired           = errors.New("sd-jwt expired")
+)
+
+// KeyResolver returns the keys which may have signed the jwt of an issuer, see jwtkeys.
+type KeyResolver = jwtkeys.Resolver
+
+// MediaTypes are the accepted typ headers of SD-JWT VCs.
+var MediaTypes = []string{MediaType, LegacyMediaType}
+
+/*
+Verifier checks SD-JWT VCs: the issuer signature with the keys of Keys, the time claims, the disclosures and, for
+presentations, the key binding jwt.
+*/
+type Verifier struct {
+	Keys KeyResolver
+	// Leeway for clock differences, defaults to config.DefaultLeeway
+	Leeway time.Duration
+	// MaxKeyBindingAge defaults to DefaultMaxKeyBindingAge
+	MaxKeyBindingAge time.Duration
+}
+
+// NewVerifier creates a verifier, nil keys resolve the issuer keys with jwtkeys.NewResolver.
+func NewVerifier(keys KeyResolver) *Verifier {
+	if keys == nil {
+		keys = jwtkeys.NewResolver(helper.NewHttpClient(), nil)
+	}
+	return &Verifier{Keys: keys}
+}
+
+// Verify checks an issued SD-JWT VC without key binding and returns the disclosed claims.
+func (verifier *Verifier) Verify(serialized string) (map[string]interface{}, error) {
+	token, err := verifier.verify(serialized)
+	if err != nil {
+		return nil, err
+	}
+	if token.KeyBinding != "" {
+		return nil, fmt.Errorf("%w: unexpected key binding jwt", ErrKeyBindingInvalid)
+	}
+	return token.Claims()
+}
+
+/*
+VerifyPresentation checks a presentation, which must end with a key binding jwt for the audience and nonce, and
+returns the disclosed claims.
+*/
+func (verifier *Verifier) VerifyPresentation(serialized string, audience string, nonce string) (map[string]interface{}, error) {
+	token, err := verifier.verify(serialized)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifier.VerifyKeyBinding(token, audience, nonce); err != nil {
+		return nil, err
+	}
+	return token.Claims()
+}
+
+func (verifier *Verifier) verify(serialized string) (*SdJwt, error) {
+	token, err := Parse(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifier.VerifySignature(token); err != nil {
+		return nil, err
+	}
+	if err := verifier.verifyValidity(token); err != nil {
+		return nil, err
+	}
+	if err := token.VerifyDisclosures(); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// VerifySignature checks the typ header and the signature of the issuer jwt with the keys of the issuer.
+func (verifier *Verifier) VerifySignature(token *SdJwt) error {
+	if typ, _ := token.Header["typ"].(string); !slices.Contains(MediaTypes, typ) {
+		return fmt.Errorf("%w: typ %s", ErrSignatureInvalid, typ)
+	}
+
+	alg, err := signatureAlgorithm(token.Header)
+	if err != nil {
+		return err
+	}
+
+	if verifier.Keys == nil {
+		return fmt.Errorf("%w: no key resolver", ErrSignatureInvalid)
+	}
+
+	issuer, _ := token.Payload["iss"].(string)
+	keys, err := verifier.Keys(issuer, token.Header)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+
+	for _, key := range keys {
+		if _, err := jws.Verify([]byte(token.Jwt), jws.WithKey(alg, key)); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no key of %s matches", ErrSignatureInvalid, issuer)
+}
+
+func (verifier *Verifier) verifyValidity(token *SdJwt) error {
+	leeway := verifier.leeway()
+	now := time.Now()
+
+	if exp, ok := numericDate(token.Payload["exp"]); ok && now.After(exp.Add(leeway)) {
+		return ErrExpired
+	}
+	if nbf, ok := numericDate(token.Payload["nbf"]); ok && now.Add(leeway).Before(nbf) {
+		return fmt.Errorf("%w: not yet valid", ErrSignatureInvalid)
+	}
+	return nil
+}
+
+/*
+VerifyDisclosures checks that every disclosure is referenced by exactly one digest of the payload or another
+disclosure and that disclosed claims do not overwrite plain claims.
+*/
+func (token *SdJwt) VerifyDisclosures() error {
+	digests, err := token.digests()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDisclosureInvalid, err)
+	}
+
+	referenced := make(map[string]bool, len(digests))
+	if err := checkDigests(token.Payload, digests, referenced); err != nil {
+		return err
+	}
+
+	for digest, disclosure := range digests {
+		if !referenced[digest] {
+			return fmt.Errorf("%w: disclosure of %s not referenced", ErrDisclosureInvalid, disclosure.Name)
+		}
+	}
+	return nil
+}
+
+func checkDigests(value interface{}, digests map[string]*Disclosure, referenced map[string]bool) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if raw, ok := v[DigestsKey]; ok {
+			if _, isArray := raw.([]interface{}); !isArray {
+				return fmt.Errorf("%w: _sd is no array", ErrDisclosureInvalid)
+			}
+		}
+
+		for _, digest := range sdDigests(v) {
+			if referenced[digest] {
+				return fmt.Errorf("%w: digest %s used twice", ErrDisclosureInvalid, digest)
+			}
+			referenced[digest] = true
+
+			disclosure, ok := digests[digest]
+			if !ok {
+				continue
+			}
+			if disclosure.IsArrayElement() {
+				return fmt.Errorf("%w: array element disclosed as object property", ErrDisclosureInvalid)
+			}
+			if _, exists := v[disclosure.Name]; exists {
+				return fmt.Errorf("%w: claim %s disclosed and plain", ErrDisclosureInvalid, disclosure.Name)
+			}
+			if err := checkDigests(disclosure.Value, digests, referenced); err != nil {
+				return err
+			}
+		}
+
+		for key, child := range v {
+			if key == DigestsKey {
+				continue
+			}
+			if err := checkDigests(child, digests, referenced); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, element := range v {
+			digest, ok := elementDigest(element)
+			if !ok {
+				if err := checkDigests(element, digests, referenced); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if referenced[digest] {
+				return fmt.Errorf("%w: digest %s used twice", ErrDisclosureInvalid, digest)
+			}
+			referenced[digest] = true
+
+			disclosure, ok := digests[digest]
+			if !ok {
+				continue
+			}
+			if !disclosure.IsArrayElement() {
+				return fmt.Errorf("%w: object property %s disclosed as array element", ErrDisclosureInvalid, disclosure.Name)
+			}
+			if err := checkDigests(disclosure.Value, digests, referenced); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+VerifyKeyBinding checks the key binding jwt of a presentation: typ, signature of the cnf key, aud, nonce, iat and
+the sd_hash over the presented sd-jwt.
+*/
+func (verifier *Verifier) VerifyKeyBinding(token *SdJwt, audience string, nonce string) error {
+	if token.KeyBinding == "" {
+		return fmt.Errorf("%w: missing", ErrKeyBindingInvalid)
+	}
+
+	holder, err := token.HolderKey()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKeyBindingInvalid, err)
+	}
+	if holder == nil {
+		return fmt.Errorf("%w: sd-jwt without cnf jwk", ErrKeyBindingInvalid)
+	}
+
+	segments := strings.Split(token.KeyBinding, ".")
+	var header map[string]interface{}
+	if len(segments) != 3 || decodeSegment(segments[0], &header) != nil {
+		return fmt.Errorf("%w: malformed", ErrKeyBindingInvalid)
+	}
+	if header["typ"] != KeyBindingType {
+		return fmt.Errorf("%w: typ %v", ErrKeyBindingInvalid, header["typ"])
+	}
+
+	alg, err := signatureAlgorithm(header)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKeyBindingInvalid, err)
+	}
+
+	leeway := verifier.leeway()
+	kb, err := jwt.ParseString(token.KeyBinding,
+		jwt.WithKey(alg, holder),
+		jwt.WithAcceptableSkew(leeway),
+		jwt.WithAudience(audience),
+		jwt.WithClaimValue("nonce", nonce),
+		jwt.WithRequiredClaim(jwt.IssuedAtKey))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrKeyBindingInvalid, err)
+	}
+
+	maxAge := verifier.MaxKeyBindingAge
+	if maxAge <= 0 {
+		maxAge = DefaultMaxKeyBindingAge
+	}
+	if time.Since(kb.IssuedAt()) > maxAge+leeway || time.Until(kb.IssuedAt()) > leeway {
+		return fmt.Errorf("%w: iat not fresh", ErrKeyBindingInvalid)
+	}
+
+	sdHash, err := Digest(token.HashAlgorithm(), token.serialize())
+	if err != nil {
+		return err
+	}
+	if value, _ := kb.Get("sd_hash"); value != sdHash {
+		return fmt.Errorf("%w: sd_hash does not match", ErrKeyBindingInvalid)
+	}
+	return nil
+}
+
+func (verifier *Verifier) leeway() time.Duration {
+	if verifier.Leeway > 0 {
+		return verifier.Leeway
+	}
+	return config.DefaultLeeway
+}
+
+// signatureAlgorithm returns the alg header, unsigned and symmetric algorithms are rejected.
+func signatureAlgorithm(header map[string]interface{}) (jwa.SignatureAlgorithm, error) {
+	alg, _ := header["alg"].(string)
+	switch jwa.SignatureAlgorithm(alg) {
+	case "", jwa.NoSignature, jwa.HS256, jwa.HS384, jwa.HS512:
+		return "", fmt.Errorf("%w: alg %s not allowed", ErrSignatureInvalid, alg)
+	}
+	return jwa.SignatureAlgorithm(alg), nil
+}
+
+func numericDate(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
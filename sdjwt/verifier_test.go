@@ -0,0 +1,134 @@
+package sdjwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/jwtkeys"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+)
+
+func issueTestCredential(t *testing.T, issuerKey jwk.Key, holderKey jwk.Key, iss string) string {
+	signer, err := signing.NewJwkSigner(issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialized, err := NewIssuer(signer).Issue(Credential{
+		Vct:       "https://credentials.example.com/identity_credential",
+		Issuer:    iss,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		Holder:    holderKey,
+		Claims:    map[string]interface{}{"given_name": "Erika", "family_name": "Mustermann"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serialized
+}
+
+func TestVerifyPresentation(t *testing.T) {
+	issuerKey, holderKey := testKey(t), testKey(t)
+	issuerPublic, _ := jwk.PublicKeyOf(issuerKey)
+	holder, _ := signing.NewJwkSigner(holderKey)
+
+	serialized := issueTestCredential(t, issuerKey, holderKey, "https://issuer.example.com")
+	presented, err := Present(serialized, []oauth.ClaimPath{{"given_name"}}, holder, "https://verifier.example.com", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := NewVerifier(jwtkeys.Static(issuerPublic))
+	claims, err := verifier.VerifyPresentation(presented, "https://verifier.example.com", "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["given_name"] != "Erika" || claims["family_name"] != nil {
+		t.Error(claims)
+	}
+
+	if _, err := verifier.Verify(serialized); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := verifier.VerifyPresentation(presented, "https://verifier.example.com", "other"); !errors.Is(err, ErrKeyBindingInvalid) {
+		t.Error("nonce not checked", err)
+	}
+	if _, err := verifier.VerifyPresentation(presented, "https://attacker.example.com", "nonce"); !errors.Is(err, ErrKeyBindingInvalid) {
+		t.Error("audience not checked", err)
+	}
+	if _, err := verifier.VerifyPresentation(serialized, "https://verifier.example.com", "nonce"); !errors.Is(err, ErrKeyBindingInvalid) {
+		t.Error("missing key binding accepted", err)
+	}
+
+	// a disclosure which is added after key binding breaks sd_hash
+	token, _ := Parse(presented)
+	foreign, _ := NewDisclosure("family_name", "Musterfrau")
+	token.Disclosures = append(token.Disclosures, foreign)
+	if _, err := verifier.VerifyPresentation(token.String(), "https://verifier.example.com", "nonce"); !errors.Is(err, ErrDisclosureInvalid) {
+		t.Error("unreferenced disclosure accepted", err)
+	}
+
+	otherPublic, _ := jwk.PublicKeyOf(testKey(t))
+	if _, err := NewVerifier(jwtkeys.Static(otherPublic)).Verify(serialized); !errors.Is(err, ErrSignatureInvalid) {
+		t.Error("foreign signature accepted", err)
+	}
+}
+
+func TestMetadataKeys(t *testing.T) {
+	issuerKey := testKey(t)
+	issuerKey.Set(jwk.KeyIDKey, "key-1")
+	public, _ := jwk.PublicKeyOf(issuerKey)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != jwtkeys.IssuerMetadataPath+"/tenant" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		set := jwk.NewSet()
+		set.AddKey(public)
+		jwks, _ := json.Marshal(set)
+		json.NewEncoder(w).Encode(jwtkeys.IssuerMetadata{Issuer: srv.URL + "/tenant", Jwks: jwks})
+	}))
+	defer srv.Close()
+
+	serialized := issueTestCredential(t, issuerKey, nil, srv.URL+"/tenant")
+	if _, err := NewVerifier(jwtkeys.NewResolver(srv.Client(), nil)).Verify(serialized); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDidKeys(t *testing.T) {
+	issuerKey := testKey(t)
+	public, _ := jwk.PublicKeyOf(issuerKey)
+	b, _ := json.Marshal(public)
+	did := "did:jwk:" + base64.RawURLEncoding.EncodeToString(b)
+	issuerKey.Set(jwk.KeyIDKey, did+"#0")
+
+	serialized := issueTestCredential(t, issuerKey, nil, did)
+	if _, err := NewVerifier(nil).Verify(serialized); err != nil {
+		t.Error(err)
+	}
+
+	// the kid names the key of the signer, which is not the issuer
+	forged := issueTestCredential(t, issuerKey, nil, "did:web:issuer.example.com")
+	if _, err := NewVerifier(nil).Verify(forged); !errors.Is(err, ErrSignatureInvalid) {
+		t.Error("kid of another did accepted", err)
+	}
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	forged = issueTestCredential(t, issuerKey, nil, srv.URL)
+	if _, err := NewVerifier(jwtkeys.NewResolver(srv.Client(), nil)).Verify(forged); !errors.Is(err, ErrSignatureInvalid) {
+		t.Error("kid did accepted for https issuer", err)
+	}
+}
@@ -0,0 +1,150 @@
+package signing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+/*
+Signer abstracts the private key operations, so that keys can be kept outside of the process (HSM, KMS, crypto provider).
+Sign must return the signature in the JWS format of the algorithm (e.g. R||S for ECDSA).
+*/
+type Signer interface {
+	Algorithm() jwa.SignatureAlgorithm
+	PublicKey() jwk.Key
+	KeyID() string
+	Sign(data []byte) ([]byte, error)
+}
+
+type jwkSigner struct {
+	alg    jwa.SignatureAlgorithm
+	key    interface{}
+	public jwk.Key
+	kid    string
+}
+
+// NewJwkSigner creates a signer for a private jwk. The algorithm is taken from the key or derived from the key type.
+func NewJwkSigner(key jwk.Key) (Signer, error) {
+	if key == nil {
+		return nil, errors.New("key is nil")
+	}
+
+	private, err := jwk.IsPrivateKey(key)
+	if err != nil || !private {
+		return nil, errors.New("signing requires a private key")
+	}
+
+	alg, err := algorithmOf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return nil, fmt.Errorf("can not extract raw key: %w", err)
+	}
+
+	public, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &jwkSigner{
+		alg:    alg,
+		key:    raw,
+		public: public,
+		kid:    key.KeyID(),
+	}, nil
+}
+
+func (s *jwkSigner) Algorithm() jwa.SignatureAlgorithm {
+	return s.alg
+}
+
+func (s *jwkSigner) PublicKey() jwk.Key {
+	return s.public
+}
+
+func (s *jwkSigner) KeyID() string {
+	return s.kid
+}
+
+func (s *jwkSigner) Sign(data []byte) ([]byte, error) {
+	signer, err := jws.NewSigner(s.alg)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(data, s.key)
+}
+
+func algorithmOf(key jwk.Key) (jwa.SignatureAlgorithm, error) {
+	if alg := key.Algorithm().String(); alg != "" {
+		return jwa.SignatureAlgorithm(alg), nil
+	}
+
+	switch k := key.(type) {
+	case jwk.ECDSAPrivateKey:
+		switch k.Crv() {
+		case jwa.P256:
+			return jwa.ES256, nil
+		case jwa.P384:
+			return jwa.ES384, nil
+		case jwa.P521:
+			return jwa.ES512, nil
+		}
+	case jwk.OKPPrivateKey:
+		return jwa.EdDSA, nil
+	case jwk.RSAPrivateKey:
+		return jwa.PS256, nil
+	}
+	return "", fmt.Errorf("can not derive signature algorithm for key type %s", key.KeyType())
+}
+
+/*
+SignJwt creates a compact JWS over the JSON encoded claims. The alg header is always taken from the signer,
+further headers (typ, kid, jwk, x5c ...) can be provided by the caller.
+*/
+func SignJwt(signer Signer, headers map[string]interface{}, claims interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal claims: %w", err)
+	}
+	return SignCompact(signer, headers, payload)
+}
+
+// SignCompact signs an arbitrary payload and returns the compact JWS serialization.
+func SignCompact(signer Signer, headers map[string]interface{}, payload []byte) (string, error) {
+	if signer == nil {
+		return "", errors.New("signer is nil")
+	}
+
+	protected := make(map[string]interface{}, len(headers)+1)
+	for k, v := range headers {
+		protected[k] = v
+	}
+	protected["alg"] = signer.Algorithm().String()
+
+	h, err := json.Marshal(protected)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal headers: %w", err)
+	}
+
+	input := strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString(h),
+		base64.RawURLEncoding.EncodeToString(payload),
+	}, ".")
+
+	signature, err := signer.Sign([]byte(input))
+	if err != nil {
+		return "", fmt.Errorf("signing failed: %w", err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,44 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+func TestSignJwt(t *testing.T) {
+	_, raw, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := jwk.FromRaw(raw)
+	signer, err := NewJwkSigner(key)
+
+	if err != nil || signer.Algorithm() != jwa.EdDSA {
+		t.Fatal(err)
+	}
+
+	token, err := SignJwt(signer, map[string]interface{}{"typ": "JWT"}, map[string]interface{}{"sub": "test"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := jws.Verify([]byte(token), jws.WithKey(jwa.EdDSA, signer.PublicKey()))
+
+	if err != nil || string(payload) != `{"sub":"test"}` {
+		t.Error(err)
+	}
+
+	public, _ := key.PublicKey()
+	_, err = NewJwkSigner(public)
+
+	if err == nil {
+		t.Error()
+	}
+}
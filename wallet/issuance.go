@@ -0,0 +1,447 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/oauth"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/types"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+)
+
+var DefaultDeferredInterval = 5 * time.Second
+
+// TxCodePrompt asks the user for the transaction code which was transmitted by the issuer on another channel.
+type TxCodePrompt func(ctx context.Context, txCode credential.TxCode) (string, error)
+
+// ConfigurationSelector decides which of the offered credential configurations are requested.
+type ConfigurationSelector func(offer credential.CredentialOfferParameters, metadata credential.IssuerMetadata) ([]string, error)
+
+// CredentialStorage persists the results of the flow. Failing to store a credential is reported to the issuer.
+type CredentialStorage interface {
+	StoreCredential(ctx context.Context, credential IssuedCredential) error
+	StorePending(ctx context.Context, pending PendingCredential) error
+}
+
+type IssuedCredential struct {
+	Issuer          string
+	ConfigurationId string
+	Format          string
+	Credential      interface{}
+	NotificationId  string
+}
+
+type PendingCredential struct {
+	Issuer          string
+	ConfigurationId string
+	TransactionId   string
+	Token           oauth.Token
+}
+
+type IssuanceResult struct {
+	Offer    *credential.CredentialOfferParameters
+	Metadata *credential.IssuerMetadata
+	Issued   []IssuedCredential
+	Pending  []PendingCredential
+}
+
+/*
+IssuanceFlow drives the wallet side of OID4VCI: offer -> issuer metadata -> token -> credential(s) -> deferred -> notification.
+Only the pre-authorized code flow is supported, because the authorization code flow requires user agent interaction.
+*/
+type IssuanceFlow struct {
+	// Signer for the key proofs. Without signer no proof is sent.
+	Signer signing.Signer
+	// ClientId is sent as client_id of the token request and as iss of the key proof, if set.
+	ClientId string
+	// TxCodePrompt is required when the offer demands a transaction code.
+	TxCodePrompt TxCodePrompt
+	// SelectConfigurations defaults to all offered configurations.
+	SelectConfigurations ConfigurationSelector
+	Storage              CredentialStorage
+	// DeferredAttempts defines how often a deferred credential is polled. 0 returns pending credentials without polling.
+	DeferredAttempts int
+	DeferredInterval time.Duration
+	// ValidateClaims checks received credentials against the schema of their configuration, if the issuer publishes one.
+	ValidateClaims bool
+}
+
+/*
+offerParameters resolves the offer and the issuer metadata. Draft 11 offers with credential objects are resolved
+with the metadata, which is fetched only once.
+*/
+func (flow *IssuanceFlow) offerParameters(offer credential.CredentialOffer) (*credential.CredentialOfferParameters, *credential.IssuerMetadata, error) {
+	params, err := offer.GetOfferParameters()
+
+	var required *credential.MetadataRequiredError
+	if errors.As(err, &required) {
+		metadata, err := (&credential.CredentialOfferParameters{CredentialIssuer: required.CredentialIssuer}).GetIssuerMetadata()
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not resolve issuer metadata: %w", err)
+		}
+		params, err = required.Resolve(metadata)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can not resolve credential offer: %w", err)
+		}
+		return params, metadata, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not resolve credential offer: %w", err)
+	}
+
+	metadata, err := params.GetIssuerMetadata()
+	if err != nil {
+		return nil, nil, fmt.Errorf("can not resolve issuer metadata: %w", err)
+	}
+	return params, metadata, nil
+}
+
+func (flow *IssuanceFlow) Run(ctx context.Context, offer credential.CredentialOffer) (*IssuanceResult, error) {
+	params, metadata, err := flow.offerParameters(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(*metadata); err != nil {
+		return nil, err
+	}
+
+	result := &IssuanceResult{
+		Offer:    params,
+		Metadata: metadata,
+	}
+
+	ids, err := flow.selectConfigurations(*params, *metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := flow.token(ctx, params, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	cNonce := token.CNonce
+	for _, id := range ids {
+		configuration, ok := metadata.CredentialConfigurationsSupported[id]
+		if !ok {
+			return result, fmt.Errorf("credential configuration %s not supported by issuer", id)
+		}
+
+		for _, request := range credentialRequests(id, token) {
+			response, err := flow.requestCredential(ctx, metadata, configuration, request, *token, &cNonce)
+			if err != nil {
+				return result, fmt.Errorf("credential request for %s failed: %w", id, err)
+			}
+
+			if response.TransactionID != "" {
+				pending := PendingCredential{
+					Issuer:          metadata.CredentialIssuer,
+					ConfigurationId: id,
+					TransactionId:   response.TransactionID,
+					Token:           *token,
+				}
+
+				if flow.DeferredAttempts <= 0 {
+					if flow.Storage != nil {
+						if err := flow.Storage.StorePending(ctx, pending); err != nil {
+							return result, err
+						}
+					}
+					result.Pending = append(result.Pending, pending)
+					continue
+				}
+
+				response, err = flow.pollDeferred(ctx, metadata, pending, response.Interval)
+				if err != nil {
+					return result, err
+				}
+			}
+
+			issued, err := flow.accept(ctx, metadata, *token, id, configuration.Format, response)
+			if err != nil {
+				return result, err
+			}
+			result.Issued = append(result.Issued, issued...)
+		}
+	}
+
+	return result, nil
+}
+
+// Continue fetches the credentials which were returned as pending by a previous run.
+func (flow *IssuanceFlow) Continue(ctx context.Context, metadata *credential.IssuerMetadata, pending PendingCredential) ([]IssuedCredential, error) {
+	response, err := flow.pollDeferred(ctx, metadata, pending, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	format := ""
+	if configuration, ok := metadata.CredentialConfigurationsSupported[pending.ConfigurationId]; ok {
+		format = configuration.Format
+	}
+	return flow.accept(ctx, metadata, pending.Token, pending.ConfigurationId, format, response)
+}
+
+func (flow *IssuanceFlow) selectConfigurations(params credential.CredentialOfferParameters, metadata credential.IssuerMetadata) ([]string, error) {
+	if flow.SelectConfigurations == nil {
+		if len(params.Credentials) == 0 {
+			return nil, errors.New("offer contains no credential configurations")
+		}
+		return params.Credentials, nil
+	}
+
+	ids, err := flow.SelectConfigurations(params, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if !slices.Contains(params.Credentials, id) {
+			return nil, fmt.Errorf("credential configuration %s was not offered", id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("no credential configuration selected")
+	}
+	return ids, nil
+}
+
+func (flow *IssuanceFlow) token(ctx context.Context, params *credential.CredentialOfferParameters, metadata *credential.IssuerMetadata) (*oauth.Token, error) {
+	grant := params.Grants.PreAuthorizedCode
+	if grant == nil {
+		return nil, errors.New("offer contains no pre-authorized code grant")
+	}
+
+	config, err := metadata.FindAuthorizationServer(oauth.PreAuthorizedCodeGrant, grant.AuthorizationServerHint)
+	if err != nil {
+		return nil, err
+	}
+
+	request := oauth.TokenRequest{
+		GrantType:         oauth.PreAuthorizedCodeGrant,
+		PreAuthorizedCode: grant.PreAuthorizationCode,
+		Interval:          grant.Interval,
+		ClientId:          flow.ClientId,
+	}
+
+	if grant.TxCode != nil {
+		if flow.TxCodePrompt == nil {
+			return nil, errors.New("offer requires a tx_code, but no prompt is configured")
+		}
+
+		txCode, err := flow.TxCodePrompt(ctx, *grant.TxCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := grant.TxCode.Validate(txCode); err != nil {
+			return nil, err
+		}
+		request.TxCode = txCode
+	}
+
+	token, err := config.GetToken(request)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	return token, nil
+}
+
+// credentialRequests uses the credential identifiers of the authorization details, if the authorization server has returned some.
+func credentialRequests(id string, token *oauth.Token) []credential.CredentialRequest {
+	requests := make([]credential.CredentialRequest, 0)
+
+	for _, detail := range token.AuthorizationDetails {
+		if detail.CredentialConfigurationID != id {
+			continue
+		}
+		for _, identifier := range detail.CredentialIdentifiers {
+			requests = append(requests, credential.CredentialRequest{CredentialIdentifier: identifier})
+		}
+	}
+
+	if len(requests) == 0 {
+		requests = append(requests, credential.CredentialRequest{CredentialConfigurationId: id})
+	}
+	return requests
+}
+
+func (flow *IssuanceFlow) requestCredential(ctx context.Context, metadata *credential.IssuerMetadata, configuration credential.CredentialConfiguration, request credential.CredentialRequest, token oauth.Token, cNonce *string) (*credential.CredentialResponse, error) {
+	retried := false
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		proof, err := flow.proof(metadata.CredentialIssuer, configuration, *cNonce)
+		if err != nil {
+			return nil, err
+		}
+		request.Proof = proof
+
+		response, err := metadata.CredentialRequest(request, token)
+		if err != nil {
+			// a stale nonce is answered with a fresh one, which is worth exactly one retry
+			errResponse, ok := credential.AsCredentialError(err)
+			if ok && !retried && errResponse.CNonce != "" &&
+				(errResponse.ErrorMsg == credential.InvalidProof || errResponse.ErrorMsg == credential.InvalidNonce) {
+				*cNonce = errResponse.CNonce
+				retried = true
+				continue
+			}
+			return nil, err
+		}
+
+		if response.CNonce != "" {
+			*cNonce = response.CNonce
+		}
+		return response, nil
+	}
+}
+
+func (flow *IssuanceFlow) proof(audience string, configuration credential.CredentialConfiguration, cNonce string) (*credential.Proof, error) {
+	if len(configuration.ProofTypesSupported) == 0 {
+		return nil, nil
+	}
+
+	proofType, ok := configuration.ProofTypesSupported[credential.ProofTypeJWT]
+	if !ok {
+		return nil, errors.New("issuer requires a proof type which is not supported")
+	}
+
+	if flow.Signer == nil {
+		return nil, errors.New("issuer requires a key proof, but no signer is configured")
+	}
+
+	alg := flow.Signer.Algorithm().String()
+	if len(proofType.ProofSigningAlgValuesSupported) > 0 && !slices.Contains(proofType.ProofSigningAlgValuesSupported, alg) {
+		return nil, fmt.Errorf("signing algorithm %s not supported by issuer", alg)
+	}
+
+	return credential.CreateJwtProof(flow.Signer, audience, cNonce, flow.ClientId)
+}
+
+func (flow *IssuanceFlow) pollDeferred(ctx context.Context, metadata *credential.IssuerMetadata, pending PendingCredential, interval int) (*credential.CredentialResponse, error) {
+	wait := flow.DeferredInterval
+	if wait <= 0 {
+		wait = DefaultDeferredInterval
+	}
+	if interval > 0 {
+		wait = time.Duration(interval) * time.Second
+	}
+
+	attempts := flow.DeferredAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+
+	request := credential.CredentialDeferredRequest{TransactionID: pending.TransactionId}
+	for i := 0; i < attempts; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		response, err := metadata.DeferredCredentialRequest(request, pending.Token)
+		if err == nil {
+			return response, nil
+		}
+
+		errResponse, ok := credential.AsCredentialError(err)
+		if !ok || errResponse.ErrorMsg != credential.IssuancePending {
+			return nil, fmt.Errorf("deferred credential request failed: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("credential %s still pending after %d attempts", pending.TransactionId, attempts)
+}
+
+/*
+accept validates and stores every credential of the response, a batch is only accepted as a whole. The issuer is
+notified once for the response.
+*/
+func (flow *IssuanceFlow) accept(ctx context.Context, metadata *credential.IssuerMetadata, token oauth.Token, id string, format string, response *credential.CredentialResponse) ([]IssuedCredential, error) {
+	entries := response.Credentials
+	if len(entries) == 0 && response.Credential != nil {
+		entries = []credential.CredentialObject{{Credential: response.Credential}}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("issuer returned no credential for %s", id)
+	}
+
+	if response.Format != "" {
+		format = response.Format
+	}
+
+	issued := make([]IssuedCredential, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Credential == nil {
+			return nil, fmt.Errorf("issuer returned an empty credential for %s", id)
+		}
+		issued = append(issued, IssuedCredential{
+			Issuer:          metadata.CredentialIssuer,
+			ConfigurationId: id,
+			Format:          format,
+			Credential:      entry.Credential,
+			NotificationId:  response.NotificationId,
+		})
+	}
+
+	event := credential.CredentialAccepted
+	var validationErr, storeErr error
+	if flow.ValidateClaims {
+		for _, entry := range issued {
+			if validationErr = validateCredential(metadata.CredentialConfigurationsSupported[id], entry.Credential); validationErr != nil {
+				event = credential.CredentialFailure
+				break
+			}
+		}
+	}
+
+	if validationErr == nil && flow.Storage != nil {
+		for _, entry := range issued {
+			if storeErr = flow.Storage.StoreCredential(ctx, entry); storeErr != nil {
+				event = credential.CredentialFailure
+				break
+			}
+		}
+	}
+
+	if response.NotificationId != "" && metadata.NotificationEndpoint != nil {
+		err := metadata.Notify(credential.NotificationRequest{
+			NotificationId: response.NotificationId,
+			Event:          event,
+		}, token)
+		if err != nil {
+			logrus.Warnf("notification for %s failed: %v", response.NotificationId, err)
+		}
+	}
+
+	if validationErr != nil {
+		return nil, fmt.Errorf("credential %s rejected: %w", id, validationErr)
+	}
+	if storeErr != nil {
+		return nil, fmt.Errorf("can not store credential: %w", storeErr)
+	}
+	return issued, nil
+}
+
+func validateCredential(configuration credential.CredentialConfiguration, issued interface{}) error {
+	if configuration.Schema == nil {
+		return nil
+	}
+
+	decoded, err := types.CheckFormat(issued)
+	if err != nil {
+		return fmt.Errorf("can not decode credential: %w", err)
+	}
+	return configuration.ValidateCredential(decoded.Json)
+}
@@ -0,0 +1,266 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/mock"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/model/credential"
+	"github.com/eclipse-xfsc/oid4-vci-vp-library/signing"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+type memoryStorage struct {
+	credentials []IssuedCredential
+	pending     []PendingCredential
+}
+
+func (s *memoryStorage) StoreCredential(ctx context.Context, credential IssuedCredential) error {
+	s.credentials = append(s.credentials, credential)
+	return nil
+}
+
+func (s *memoryStorage) StorePending(ctx context.Context, pending PendingCredential) error {
+	s.pending = append(s.pending, pending)
+	return nil
+}
+
+func newTestIssuer(t *testing.T) *mock.Issuer {
+	issuer, err := mock.NewIssuer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func testOffer(t *testing.T, issuer *mock.Issuer, ids ...string) credential.CredentialOffer {
+	offer, err := issuer.CreateOffer("1234", ids...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *offer
+}
+
+func testSigner(t *testing.T) signing.Signer {
+	raw, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signing.NewJwkSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func testPrompt(ctx context.Context, txCode credential.TxCode) (string, error) {
+	return "1234", nil
+}
+
+func TestIssuanceFlow(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.DeferNext(1)
+	storage := &memoryStorage{}
+
+	flow := IssuanceFlow{
+		Signer:           testSigner(t),
+		TxCodePrompt:     testPrompt,
+		Storage:          storage,
+		DeferredAttempts: 3,
+		DeferredInterval: 10 * time.Millisecond,
+	}
+
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration, mock.JwtConfiguration))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Issued) != 2 || len(result.Pending) != 0 || len(storage.credentials) != 2 {
+		t.Fatal()
+	}
+
+	if storage.credentials[0].Format != "vc+sd-jwt" || storage.credentials[1].Format != "jwt_vc_json" {
+		t.Error()
+	}
+
+	notifications := issuer.Notifications()
+	if len(notifications) != 2 || notifications[0].Event != credential.CredentialAccepted {
+		t.Error()
+	}
+}
+
+func TestIssuanceFlowPending(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.DeferNext(1)
+	storage := &memoryStorage{}
+
+	flow := IssuanceFlow{
+		Signer:           testSigner(t),
+		TxCodePrompt:     testPrompt,
+		Storage:          storage,
+		DeferredInterval: 10 * time.Millisecond,
+	}
+
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration))
+
+	if err != nil || len(result.Pending) != 1 || len(storage.pending) != 1 {
+		t.Fatal(err)
+	}
+
+	flow.DeferredAttempts = 3
+	issued, err := flow.Continue(context.Background(), result.Metadata, result.Pending[0])
+
+	if err != nil || len(issued) != 1 || issued[0].Credential == nil || issued[0].Format != "vc+sd-jwt" {
+		t.Error(err)
+	}
+}
+
+func TestIssuanceFlowBatch(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.IssueBatchNext(3)
+	storage := &memoryStorage{}
+
+	flow := IssuanceFlow{
+		Signer:       testSigner(t),
+		TxCodePrompt: testPrompt,
+		Storage:      storage,
+	}
+
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Issued) != 3 || len(storage.credentials) != 3 {
+		t.Fatal(len(result.Issued), len(storage.credentials))
+	}
+	if result.Issued[0].Credential == result.Issued[1].Credential {
+		t.Error("batch entries must be distinct credentials")
+	}
+
+	notifications := issuer.Notifications()
+	if len(notifications) != 1 || notifications[0].Event != credential.CredentialAccepted {
+		t.Error(notifications)
+	}
+}
+
+func TestIssuanceFlowNonceRetry(t *testing.T) {
+	issuer := newTestIssuer(t)
+	issuer.FailNext(mock.CredentialEndpoint, credential.InvalidNonce, 1)
+
+	flow := IssuanceFlow{
+		Signer:       testSigner(t),
+		TxCodePrompt: testPrompt,
+	}
+
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.JwtConfiguration))
+
+	if err != nil || len(result.Issued) != 1 {
+		t.Error(err)
+	}
+
+	issuer.FailNext(mock.CredentialEndpoint, credential.InvalidNonce, 2)
+
+	_, err = flow.Run(context.Background(), testOffer(t, issuer, mock.JwtConfiguration))
+
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestIssuanceFlowWithoutPrompt(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	flow := IssuanceFlow{
+		Signer: testSigner(t),
+	}
+
+	_, err := flow.Run(context.Background(), testOffer(t, issuer, mock.JwtConfiguration))
+
+	if err == nil {
+		t.Error()
+	}
+}
+
+func TestIssuanceFlowSelection(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	flow := IssuanceFlow{
+		Signer:       testSigner(t),
+		TxCodePrompt: testPrompt,
+		SelectConfigurations: func(offer credential.CredentialOfferParameters, metadata credential.IssuerMetadata) ([]string, error) {
+			return []string{"Unknown"}, nil
+		},
+	}
+
+	_, err := flow.Run(context.Background(), testOffer(t, issuer, mock.JwtConfiguration))
+
+	if err == nil {
+		t.Error()
+	}
+
+	flow.SelectConfigurations = func(offer credential.CredentialOfferParameters, metadata credential.IssuerMetadata) ([]string, error) {
+		return []string{mock.JwtConfiguration}, nil
+	}
+
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.JwtConfiguration, mock.SdJwtConfiguration))
+
+	if err != nil || len(result.Issued) != 1 {
+		t.Error(err)
+	}
+}
+
+func TestIssuanceFlowValidateClaims(t *testing.T) {
+	issuer := newTestIssuer(t)
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"given_name"},
+		"properties": map[string]interface{}{
+			"given_name": map[string]interface{}{"type": "string"},
+		},
+	}
+
+	vct := "https://credentials.example.com/identity_credential"
+	issuer.AddConfiguration(mock.SdJwtConfiguration, credential.CredentialConfiguration{
+		Format: "vc+sd-jwt",
+		Vct:    &vct,
+		Schema: schema,
+	}, map[string]interface{}{"given_name": "Erika"})
+
+	flow := IssuanceFlow{TxCodePrompt: testPrompt, ValidateClaims: true}
+	result, err := flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration))
+	if err != nil || len(result.Issued) != 1 {
+		t.Fatal(err)
+	}
+
+	// the issuer refuses to sign claims which do not fit the schema
+	issuer.AddConfiguration(mock.SdJwtConfiguration, credential.CredentialConfiguration{
+		Format: "vc+sd-jwt",
+		Vct:    &vct,
+		Schema: schema,
+	}, map[string]interface{}{"given_name": 42})
+
+	if _, err = flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration)); err == nil {
+		t.Error("invalid claims issued")
+	}
+
+	// the wallet rejects a signed credential which does not fit the schema
+	issuer.IssueInvalidClaims()
+	_, err = flow.Run(context.Background(), testOffer(t, issuer, mock.SdJwtConfiguration))
+
+	var validation *credential.ClaimValidationError
+	if !errors.As(err, &validation) {
+		t.Error("credential with invalid claims accepted", err)
+	}
+}